@@ -27,34 +27,46 @@ type PendingPreset struct {
 	Timestamp   time.Time
 }
 
-// PendingPunishment holds the data for a punishment awaiting action selection.
+// PendingPunishment holds the data for a punishment awaiting action selection, or, once Action and Level
+// are set, awaiting confirmation of its preview.
 type PendingPunishment struct {
 	TargetUser    *discordgo.User
 	Reason        string
 	EvidenceLinks string
-	Interaction   *discordgo.Interaction
+	Action        string
+	Level         *model.PunishLevel
+	LevelKey      string
+	Interaction   *discordgo.Interaction `json:"-"`
 	Timestamp     time.Time
 }
 
+// PendingSearch holds the filters of a /punish_search result while its pages are browsed.
+type PendingSearch struct {
+	SearchBy string
+	Input    string
+	Query    string
+	Since    string
+	Until    string
+	Action   string
+	Status   string
+	GuildID  string
+}
+
 type Bot struct {
-	Session                 *discordgo.Session
-	AppID                   string
-	RegisteredCommands      []*discordgo.ApplicationCommand
-	commandsMutex           sync.Mutex
-	config                  atomic.Value // *model.Config
-	CommandHandlers         map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate)
-	PresetCooldowns         map[string]time.Time
-	CooldownMutex           sync.Mutex
-	PendingPresets          map[string]*PendingPreset
-	PendingPresetsMutex     sync.Mutex
-	PendingPunishments      map[string]*PendingPunishment
-	PendingPunishmentsMutex sync.Mutex
-	DB                      *sql.DB
-	DBX                     *sqlx.DB
-	activeScanCount         int
-	scheduler               *Scheduler
-	ctx                     context.Context
-	cancel                  context.CancelFunc
+	Session            *discordgo.Session
+	AppID              string
+	RegisteredCommands []*discordgo.ApplicationCommand
+	commandsMutex      sync.Mutex
+	config             atomic.Value                                                                               // *model.Config
+	CommandHandlers    map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) // ctx carries the interaction's trace ID
+	PresetCooldowns    map[string]time.Time
+	CooldownMutex      sync.Mutex
+	DB                 *sql.DB
+	DBX                *sqlx.DB
+	activeScanCount    int
+	scheduler          *Scheduler
+	ctx                context.Context
+	cancel             context.CancelFunc
 }
 
 func (b *Bot) GetConfig() *model.Config {
@@ -94,29 +106,30 @@ func New(cfg *model.Config, db *sql.DB, dbx *sqlx.DB) (*Bot, error) {
 	if err != nil {
 		return nil, err
 	}
-	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentMessageContent
+	// IntentMessageContent and IntentsGuildMembers are privileged and must be enabled in the Developer Portal, see README.md
+	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentMessageContent | discordgo.IntentsGuildMembers
 	dg.StateEnabled = false
 
 	ctx, cancel := context.WithCancel(context.Background())
 
 	b := &Bot{
-		Session:            dg,
-		AppID:              cfg.AppID,
-		PresetCooldowns:    make(map[string]time.Time),
-		PendingPresets:     make(map[string]*PendingPreset),
-		PendingPunishments: make(map[string]*PendingPunishment),
-		DB:                 db,
-		DBX:                dbx,
-		activeScanCount:    0,
-		ctx:                ctx,
-		cancel:             cancel,
+		Session:         dg,
+		AppID:           cfg.AppID,
+		PresetCooldowns: make(map[string]time.Time),
+		DB:              db,
+		DBX:             dbx,
+		activeScanCount: 0,
+		ctx:             ctx,
+		cancel:          cancel,
 	}
 	b.config.Store(cfg)
 	b.scheduler = NewScheduler(b)
 	// b.CommandHandlers = handlers.commandHandlers(b)
 
+	b.loadPendingActions()
 	go b.cleanupExpiredPresets(ctx)
 	go b.cleanupExpiredPunishments(ctx)
+	go b.cleanupExpiredSearches(ctx)
 
 	taskConfig, err := utils.LoadTaskConfig("data/task_config.json")
 	if err != nil {
@@ -190,22 +203,6 @@ func (b *Bot) GetScheduler() *Scheduler {
 	return b.scheduler
 }
 
-func (b *Bot) GetPendingPresets() map[string]*PendingPreset {
-	return b.PendingPresets
-}
-
-func (b *Bot) GetPendingPresetsMutex() *sync.Mutex {
-	return &b.PendingPresetsMutex
-}
-
-func (b *Bot) GetPendingPunishments() map[string]*PendingPunishment {
-	return b.PendingPunishments
-}
-
-func (b *Bot) GetPendingPunishmentsMutex() *sync.Mutex {
-	return &b.PendingPunishmentsMutex
-}
-
 func (b *Bot) ReloadConfig() error {
 	log.Println("Reloading configuration...")
 	newCfg, err := config.Load()
@@ -231,50 +228,6 @@ func (b *Bot) ReloadConfig() error {
 	return nil
 }
 
-func (b *Bot) cleanupExpiredPresets(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			b.PendingPresetsMutex.Lock()
-			for id, preset := range b.PendingPresets {
-				if time.Since(preset.Timestamp) > 5*time.Minute {
-					log.Printf("Removing expired pending preset: %s", id)
-					delete(b.PendingPresets, id)
-				}
-			}
-			b.PendingPresetsMutex.Unlock()
-		case <-ctx.Done():
-			log.Println("Stopping expired presets cleanup.")
-			return
-		}
-	}
-}
-
-func (b *Bot) cleanupExpiredPunishments(ctx context.Context) {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			b.PendingPunishmentsMutex.Lock()
-			for id, punishment := range b.PendingPunishments {
-				if time.Since(punishment.Timestamp) > 5*time.Minute {
-					log.Printf("Removing expired pending punishment: %s", id)
-					delete(b.PendingPunishments, id)
-				}
-			}
-			b.PendingPunishmentsMutex.Unlock()
-		case <-ctx.Done():
-			log.Println("Stopping expired punishments cleanup.")
-			return
-		}
-	}
-}
-
 // FindPresetByID searches for a preset message by its ID across all server configurations.
 func (b *Bot) FindPresetByID(presetID string) *model.PresetMessage {
 	config := b.GetConfig()
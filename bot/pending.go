@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"newer_helper/utils/database"
+	"time"
+)
+
+const (
+	pendingKindPreset     = "preset"
+	pendingKindPunishment = "punishment"
+	pendingKindSearch     = "search"
+
+	// PendingActionTTL is how long a pending preset or punishment waits for a button click.
+	PendingActionTTL = 5 * time.Minute
+	// PendingSearchTTL is how long the pages of a search result can be browsed.
+	PendingSearchTTL = 30 * time.Minute
+)
+
+// IsPendingExpired reports whether err means the pending entry no longer exists or has timed out.
+func IsPendingExpired(err error) bool {
+	return errors.Is(err, database.ErrPendingActionExpired) || errors.Is(err, database.ErrPendingActionNotFound)
+}
+
+// SavePendingPreset stores a preset awaiting confirmation.
+func (b *Bot) SavePendingPreset(id string, preset *PendingPreset) error {
+	return b.savePending(pendingKindPreset, id, preset)
+}
+
+// GetPendingPreset returns a pending preset without removing it.
+func (b *Bot) GetPendingPreset(id string) (*PendingPreset, error) {
+	payload, err := database.GetPendingAction(b.DB, pendingKindPreset, id)
+	if err != nil {
+		return nil, err
+	}
+	var preset PendingPreset
+	if err := json.Unmarshal(payload, &preset); err != nil {
+		return nil, fmt.Errorf("failed to decode pending preset %s: %w", id, err)
+	}
+	return &preset, nil
+}
+
+// TakePendingPreset removes a pending preset and returns it. Only one caller can take a given preset.
+func (b *Bot) TakePendingPreset(id string) (*PendingPreset, error) {
+	var preset PendingPreset
+	if err := b.takePending(pendingKindPreset, id, &preset); err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+// DeletePendingPreset discards a pending preset.
+func (b *Bot) DeletePendingPreset(id string) error {
+	return database.DeletePendingAction(b.DB, pendingKindPreset, id)
+}
+
+// SavePendingPunishment stores a punishment awaiting action selection or preview confirmation.
+func (b *Bot) SavePendingPunishment(id string, punishment *PendingPunishment) error {
+	return b.savePending(pendingKindPunishment, id, punishment)
+}
+
+// TakePendingPunishment removes a pending punishment and returns it. Only one caller can take a given punishment.
+func (b *Bot) TakePendingPunishment(id string) (*PendingPunishment, error) {
+	var punishment PendingPunishment
+	if err := b.takePending(pendingKindPunishment, id, &punishment); err != nil {
+		return nil, err
+	}
+	return &punishment, nil
+}
+
+// SavePendingSearch stores the filters of a search result so that its pagination buttons can refer to them by ID.
+func (b *Bot) SavePendingSearch(id string, search *PendingSearch) error {
+	return b.savePendingFor(pendingKindSearch, id, search, PendingSearchTTL)
+}
+
+// GetPendingSearch returns the filters of a search result without removing them.
+func (b *Bot) GetPendingSearch(id string) (*PendingSearch, error) {
+	payload, err := database.GetPendingAction(b.DB, pendingKindSearch, id)
+	if err != nil {
+		return nil, err
+	}
+	var search PendingSearch
+	if err := json.Unmarshal(payload, &search); err != nil {
+		return nil, fmt.Errorf("failed to decode pending search %s: %w", id, err)
+	}
+	return &search, nil
+}
+
+func (b *Bot) savePending(kind, id string, value interface{}) error {
+	return b.savePendingFor(kind, id, value, PendingActionTTL)
+}
+
+func (b *Bot) savePendingFor(kind, id string, value interface{}, ttl time.Duration) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode pending %s %s: %w", kind, id, err)
+	}
+	return database.SavePendingAction(b.DB, kind, id, payload, ttl)
+}
+
+func (b *Bot) takePending(kind, id string, value interface{}) error {
+	payload, err := database.TakePendingAction(b.DB, kind, id)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(payload, value); err != nil {
+		return fmt.Errorf("failed to decode pending %s %s: %w", kind, id, err)
+	}
+	return nil
+}
+
+// loadPendingActions purges entries that expired while the bot was offline and reports the ones still waiting.
+func (b *Bot) loadPendingActions() {
+	for _, kind := range []string{pendingKindPreset, pendingKindPunishment, pendingKindSearch} {
+		removed, err := database.DeleteExpiredPendingActions(b.DB, kind)
+		if err != nil {
+			log.Printf("Error purging expired pending %s entries: %v", kind, err)
+			continue
+		}
+		count, err := database.CountPendingActions(b.DB, kind)
+		if err != nil {
+			log.Printf("Error counting pending %s entries: %v", kind, err)
+			continue
+		}
+		log.Printf("Restored %d pending %s entries (purged %d expired).", count, kind, removed)
+	}
+}
+
+func (b *Bot) cleanupExpiredPresets(ctx context.Context) {
+	b.cleanupExpiredPending(ctx, pendingKindPreset)
+	log.Println("Stopping expired presets cleanup.")
+}
+
+func (b *Bot) cleanupExpiredPunishments(ctx context.Context) {
+	b.cleanupExpiredPending(ctx, pendingKindPunishment)
+	log.Println("Stopping expired punishments cleanup.")
+}
+
+func (b *Bot) cleanupExpiredSearches(ctx context.Context) {
+	b.cleanupExpiredPending(ctx, pendingKindSearch)
+	log.Println("Stopping expired searches cleanup.")
+}
+
+func (b *Bot) cleanupExpiredPending(ctx context.Context, kind string) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := database.DeleteExpiredPendingActions(b.DB, kind)
+			if err != nil {
+				log.Printf("Error removing expired pending %s entries: %v", kind, err)
+			} else if removed > 0 {
+				log.Printf("Removed %d expired pending %s entries", removed, kind)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
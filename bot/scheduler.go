@@ -120,7 +120,10 @@ func (s *Scheduler) runInitialScan() {
 
 func (s *Scheduler) startPunishmentTimer() {
 	defer s.wg.Done()
-	scanner.StartPunishmentTimer(s.bot.GetSession())
+	scanner.CheckPunishConfig(s.bot.GetSession(), s.bot.GetConfig())
+	scanner.StartApprovalExpiryTimer(s.bot.GetSession(), s.bot.GetConfig().LogChannelID, s.ctx)
+	scanner.StartPunishmentReconcileTimer(s.bot.GetSession(), s.bot.GetConfig(), s.ctx)
+	scanner.StartPunishmentTimer(s.bot.GetSession(), s.ctx)
 }
 
 func (s *Scheduler) startChannelCleaner() {
@@ -202,7 +205,7 @@ func (s *Scheduler) updateLeaderboard() {
 
 func (s *Scheduler) startDailyTasks() {
 	defer s.wg.Done()
-	runHours := []int{3, 4, 5, 13, 21} // 3 AM (nav update), 4 AM, 5 AM, 1 PM, 9 PM
+	runHours := []int{3, 4, 5, 13, 21} // 3 AM (nav update), 4 AM (cleanup), 5 AM, 1 PM, 9 PM
 
 	for {
 		now := time.Now()
@@ -230,6 +233,7 @@ func (s *Scheduler) startDailyTasks() {
 				s.runPersonalNavAutoUpdate()
 			case 4:
 				s.runDailyEvidenceCleaner()
+				s.runDailyDeletedPunishmentPurge()
 			case 5, 13, 21:
 				s.runDailyScanTasks()
 				s.runDailyPunishmentReport()
@@ -245,6 +249,11 @@ func (s *Scheduler) runDailyEvidenceCleaner() {
 	scanner.CleanOldEvidence(s.bot.GetSession(), s.bot.GetConfig())
 }
 
+func (s *Scheduler) runDailyDeletedPunishmentPurge() {
+	log.Println("Starting daily purge of deleted punishments...")
+	scanner.PurgeDeletedPunishments(s.bot.GetSession(), s.bot.GetConfig())
+}
+
 func (s *Scheduler) runDailyScanTasks() {
 	log.Println("Starting scheduled active forum scan...")
 	scanner.Scan(s.bot.GetSession(), s.bot.GetConfig().LogChannelID, "active", "", s.ctx)
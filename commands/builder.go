@@ -26,7 +26,12 @@ func GenerateCommands(_ *model.ServerConfig) []*discordgo.ApplicationCommand {
 		defs.PunishSearch,
 		defs.PunishRevoke,
 		defs.PunishDelete,
+		defs.PunishRestore,
+		defs.PunishReconcile,
+		defs.PunishConfig,
 		defs.PunishPrintEvidence,
+		defs.PunishAudit,
+		defs.PunishNote,
 		defs.RegisterTopChannel,
 		defs.AdsBoardAdmin,
 		defs.DailyPunishmentStats,
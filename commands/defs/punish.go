@@ -1,6 +1,12 @@
 package defs
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"newer_helper/model"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var minLevelIndex = 0.0
 
 var Punish = &discordgo.ApplicationCommand{
 	Name:        "punish",
@@ -58,8 +64,8 @@ var (
 			{
 				Type:        discordgo.ApplicationCommandOptionString,
 				Name:        "search_by",
-				Description: "选择搜索方式",
-				Required:    true,
+				Description: "选择按ID搜索的方式",
+				Required:    false,
 				Choices: []*discordgo.ApplicationCommandOptionChoice{
 					{Name: "处罚ID", Value: "punishment_id"},
 					{Name: "被处罚者ID", Value: "punished_user_id"},
@@ -69,8 +75,56 @@ var (
 			{
 				Type:        discordgo.ApplicationCommandOptionString,
 				Name:        "input",
-				Description: "输入要搜索的ID",
-				Required:    true,
+				Description: "输入要搜索的ID，需与 search_by 一起使用",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "query",
+				Description: "在处罚原因、用户名和证据内容中搜索的关键词，多个关键词用空格分开",
+				Required:    false,
+				MaxLength:   100,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "since",
+				Description: "开始日期 (YYYY-MM-DD)",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "until",
+				Description: "结束日期 (YYYY-MM-DD，包含当天)",
+				Required:    false,
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "action",
+				Description:  "处罚类型",
+				Required:     false,
+				Autocomplete: true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "status",
+				Description: "处罚状态",
+				Required:    false,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "生效中", Value: model.PunishmentStatusActive},
+					{Name: "已完成", Value: model.PunishmentStatusCompleted},
+					{Name: "已撤销", Value: model.PunishmentStatusCancelled},
+					{Name: "申诉中", Value: model.PunishmentStatusAppealed},
+					{Name: "申诉被驳回", Value: model.PunishmentStatusAppealRejected},
+					{Name: "待审批", Value: model.PunishmentStatusPendingApproval},
+					{Name: "审批被拒绝", Value: model.PunishmentStatusRejected},
+					{Name: "审批已过期", Value: model.PunishmentStatusExpired},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "guild_id",
+				Description: "只搜索该服务器的记录，默认搜索你拥有管理权限的所有服务器",
+				Required:    false,
 			},
 		},
 	}
@@ -93,6 +147,12 @@ var (
 				Description: "要撤销的处罚ID",
 				Required:    true,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "撤销原因（将记录在审计日志中）",
+				Required:    false,
+			},
 		},
 	}
 
@@ -114,6 +174,114 @@ var (
 				Description: "要删除的处罚ID",
 				Required:    true,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "reason",
+				Description: "删除原因（将记录在审计日志中）",
+				Required:    false,
+			},
+		},
+	}
+
+	PunishReconcile = &discordgo.ApplicationCommand{
+		Name:        "punish_reconcile",
+		Description: "核对处罚身份组与处罚记录是否一致",
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.ChineseCN: "核对处罚身份组",
+			discordgo.ChineseTW: "核對處罰身份組",
+		},
+		DescriptionLocalizations: &map[discordgo.Locale]string{
+			discordgo.ChineseCN: "检查生效中处罚的身份组是否与成员当前身份组一致",
+			discordgo.ChineseTW: "檢查生效中處罰的身份組是否與成員當前身份組一致",
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "fix",
+				Description: "是否自动修复不一致的身份组（默认仅报告）",
+				Required:    false,
+			},
+		},
+	}
+
+	PunishConfig = &discordgo.ApplicationCommand{
+		Name:        "punish_config",
+		Description: "管理本服务器的处罚类型和等级",
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.ChineseCN: "处罚配置",
+			discordgo.ChineseTW: "處罰配置",
+		},
+		DescriptionLocalizations: &map[discordgo.Locale]string{
+			discordgo.ChineseCN: "查看、编辑、排序处罚类型和等级，并管理配置版本",
+			discordgo.ChineseTW: "查看、編輯、排序處罰類型和等級，並管理配置版本",
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "operation",
+				Description: "要执行的操作",
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "查看配置 (list)", Value: "list"},
+					{Name: "从文件导入 (import)", Value: "import"},
+					{Name: "新增/编辑处罚类型 (action_edit)", Value: "action_edit"},
+					{Name: "删除处罚类型 (action_remove)", Value: "action_remove"},
+					{Name: "新增/编辑等级 (level_edit)", Value: "level_edit"},
+					{Name: "删除等级 (level_remove)", Value: "level_remove"},
+					{Name: "移动等级 (reorder)", Value: "reorder"},
+					{Name: "版本历史 (history)", Value: "history"},
+					{Name: "恢复版本 (restore)", Value: "restore"},
+					{Name: "检查配置 (validate)", Value: "validate"},
+				},
+			},
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "action",
+				Description:  "处罚类型标识",
+				Required:     false,
+				Autocomplete: true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "level",
+				Description: "等级序号（从 0 开始，level_edit 留空则新增等级）",
+				Required:    false,
+				MinValue:    &minLevelIndex,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "position",
+				Description: "reorder 时等级的目标位置（从 0 开始）",
+				Required:    false,
+				MinValue:    &minLevelIndex,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "version",
+				Description: "restore 时要恢复的版本号",
+				Required:    false,
+			},
+		},
+	}
+
+	PunishRestore = &discordgo.ApplicationCommand{
+		Name:        "punish_restore",
+		Description: "恢复一个已删除的处罚记录",
+		NameLocalizations: &map[discordgo.Locale]string{
+			discordgo.ChineseCN: "恢复处罚",
+			discordgo.ChineseTW: "恢復處罰",
+		},
+		DescriptionLocalizations: &map[discordgo.Locale]string{
+			discordgo.ChineseCN: "通过处罚ID恢复一个已删除的处罚记录",
+			discordgo.ChineseTW: "通過處罰ID恢復一個已刪除的處罰記錄",
+		},
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "punishment_id",
+				Description: "要恢复的处罚ID",
+				Required:    true,
+			},
 		},
 	}
 
@@ -139,6 +307,97 @@ var (
 	}
 )
 
+var PunishAudit = &discordgo.ApplicationCommand{
+	Name:        "punish_audit",
+	Description: "查看处罚记录的审计日志",
+	NameLocalizations: &map[discordgo.Locale]string{
+		discordgo.ChineseCN: "处罚审计",
+		discordgo.ChineseTW: "處罰審計",
+	},
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.ChineseCN: "按处罚ID、管理员、日期范围和追踪ID查看处罚记录的变更历史",
+		discordgo.ChineseTW: "按處罰ID、管理員、日期範圍和追蹤ID查看處罰記錄的變更歷史",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "punishment_id",
+			Description: "只显示该处罚ID的记录",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "admin",
+			Description: "只显示该管理员执行的操作",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "since",
+			Description: "开始日期 (YYYY-MM-DD)",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "until",
+			Description: "结束日期，包含当天 (YYYY-MM-DD)",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "trace_id",
+			Description: "只显示该追踪ID的记录（见日志消息页脚）",
+			Required:    false,
+		},
+	},
+}
+
+var PunishNote = &discordgo.ApplicationCommand{
+	Name:        "punish_note",
+	Description: "管理用户的管理员备注和警告",
+	NameLocalizations: &map[discordgo.Locale]string{
+		discordgo.ChineseCN: "用户备注",
+		discordgo.ChineseTW: "用戶備註",
+	},
+	DescriptionLocalizations: &map[discordgo.Locale]string{
+		discordgo.ChineseCN: "记录不构成处罚的管理员备注和正式警告",
+		discordgo.ChineseTW: "記錄不構成處罰的管理員備註和正式警告",
+	},
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "operation",
+			Description: "要执行的操作",
+			Required:    true,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "添加备注 (add)", Value: "add"},
+				{Name: "添加警告 (warn)", Value: "warn"},
+				{Name: "查看备注 (list)", Value: "list"},
+				{Name: "删除备注 (delete)", Value: "delete"},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "备注对象（add、warn、list 时必填）",
+			Required:    false,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "content",
+			Description: "备注或警告内容（add、warn 时必填）",
+			Required:    false,
+			MaxLength:   1000,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "note_id",
+			Description: "要删除的备注ID（delete 时必填）",
+			Required:    false,
+		},
+	},
+}
+
 var QuickPunish = &discordgo.ApplicationCommand{
 	Name: "快速处罚",
 	Type: discordgo.MessageApplicationCommand,
@@ -35,6 +35,7 @@ func Load() (*model.Config, error) {
 
 	disableInitialScan := os.Getenv("DISABLE_INITIAL_SCAN") == "true"
 	disableCommandUnregister := os.Getenv("DISABLE_COMMAND_UNREGISTER") == "true"
+	punishReconcileAutoFix := os.Getenv("PUNISH_RECONCILE_AUTOFIX") == "true"
 
 	evidencePath := os.Getenv("EVIDENCE_PATH")
 	if evidencePath == "" {
@@ -51,6 +52,16 @@ func Load() (*model.Config, error) {
 		evidenceMaxAgeDays = 30
 	}
 
+	punishDeleteRetentionDaysStr := os.Getenv("PUNISH_DELETE_RETENTION_DAYS")
+	if punishDeleteRetentionDaysStr == "" {
+		punishDeleteRetentionDaysStr = "30"
+	}
+	punishDeleteRetentionDays, err := strconv.Atoi(punishDeleteRetentionDaysStr)
+	if err != nil || punishDeleteRetentionDays < 1 {
+		log.Printf("Warning: Invalid PUNISH_DELETE_RETENTION_DAYS value, must be at least 1, using default of 30. Error: %v", err)
+		punishDeleteRetentionDays = 30
+	}
+
 	cfg := &model.Config{
 		BotToken:                 token,
 		AppID:                    appID,
@@ -64,6 +75,8 @@ func Load() (*model.Config, error) {
 			Path:       evidencePath,
 			MaxAgeDays: evidenceMaxAgeDays,
 		},
+		PunishDeleteRetentionDays: punishDeleteRetentionDays,
+		PunishReconcileAutoFix:    punishReconcileAutoFix,
 	}
 
 	// Load task config
@@ -0,0 +1,673 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"newer_helper/grpc/client/gatewaytest"
+	punishpb "newer_helper/grpc/proto/gen/punish"
+	proto "newer_helper/grpc/proto/gen/registry"
+	"newer_helper/utils/trace"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const testTimeout = 5 * time.Second
+
+// stubPunishServer answers GetPunishStatus with the admin header echoed, GetPunishHistory and
+// ExportPunishHistory with generated records, and leaves every other method unimplemented
+type stubPunishServer struct {
+	punishpb.UnimplementedPunishServerServer
+}
+
+// stubEvidence is a large evidence string, so that history responses exceed small chunk sizes
+var stubEvidence = strings.Repeat("evidence ", 100)
+
+func stubHistoryPage(page, pageSize, totalRecords int32) *punishpb.GetPunishHistoryResponse {
+	resp := &punishpb.GetPunishHistoryResponse{
+		TotalRecords: totalRecords,
+		TotalPages:   (totalRecords + pageSize - 1) / pageSize,
+		CurrentPage:  page,
+	}
+	for id := (page-1)*pageSize + 1; id <= min(page*pageSize, totalRecords); id++ {
+		resp.Punishments = append(resp.Punishments, &punishpb.Punishment{PunishmentId: int64(id), Evidence: stubEvidence})
+	}
+	return resp
+}
+
+func (stubPunishServer) GetPunishHistory(ctx context.Context, req *punishpb.GetPunishHistoryRequest) (*punishpb.GetPunishHistoryResponse, error) {
+	return stubHistoryPage(req.Page, req.PageSize, 25), nil
+}
+
+func (stubPunishServer) ExportPunishHistory(req *punishpb.ExportPunishHistoryRequest, stream punishpb.PunishServer_ExportPunishHistoryServer) error {
+	if req.UserId == "" {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	const totalRecords = 25
+	for page := int32(1); (page-1)*req.PageSize < totalRecords; page++ {
+		if err := stream.Send(stubHistoryPage(page, req.PageSize, totalRecords)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stubPunishServer) GetPunishStatus(ctx context.Context, req *punishpb.GetPunishStatusRequest) (*punishpb.GetPunishStatusResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	admin := ""
+	if values := md.Get("x-admin-id"); len(values) > 0 {
+		admin = values[0]
+	}
+	return &punishpb.GetPunishStatusResponse{Status: "none:" + req.UserId + ":" + admin}, nil
+}
+
+// newTestClient starts a fake gateway and a client registered with the stub punish service, without connecting
+func newTestClient(t *testing.T, opts ...Option) (*Client, *gatewaytest.Gateway) {
+	t.Helper()
+	t.Setenv("GRPC_SERVER_ADDRESS", gatewaytest.Address)
+	t.Setenv("GRPC_CLIENT_NAME", "testbot")
+	t.Setenv("GRPC_TOKEN", "secret-token")
+
+	gateway := gatewaytest.New()
+	t.Cleanup(gateway.Close)
+
+	opts = append([]Option{
+		WithDialOptions(gateway.DialOptions()...),
+		WithReconnectBackoff(20*time.Millisecond, 80*time.Millisecond),
+	}, opts...)
+	c, err := NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	punishpb.RegisterPunishServerServer(c, stubPunishServer{})
+	return c, gateway
+}
+
+// connect connects the client and waits until the gateway has confirmed the connection
+func connect(t *testing.T, c *Client, gateway *gatewaytest.Gateway) *gatewaytest.Conn {
+	t.Helper()
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return waitForConnection(t, c, gateway)
+}
+
+func waitForConnection(t *testing.T, c *Client, gateway *gatewaytest.Gateway) *gatewaytest.Conn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	conn, err := gateway.WaitForConnection(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.connectionID == conn.ID
+	})
+	return conn
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func forward(t *testing.T, conn *gatewaytest.Conn, req *proto.ForwardRequest) *proto.ForwardResponse {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	resp, err := conn.Forward(ctx, req)
+	if err != nil {
+		t.Fatalf("Forward %s: %v", req.MethodPath, err)
+	}
+	return resp
+}
+
+func TestConnectRegistersServices(t *testing.T) {
+	c, gateway := newTestClient(t)
+	conn := connect(t, c, gateway)
+
+	if conn.Register.ApiKey != "secret-token" {
+		t.Errorf("registered with api key %q, want %q", conn.Register.ApiKey, "secret-token")
+	}
+	if len(conn.Register.Services) != 1 || conn.Register.Services[0] != "testbot.punish" {
+		t.Errorf("registered services %v, want [testbot.punish]", conn.Register.Services)
+	}
+}
+
+func TestForwardedRequests(t *testing.T) {
+	c, gateway := newTestClient(t)
+	conn := connect(t, c, gateway)
+
+	tests := []struct {
+		name       string
+		methodPath string
+		headers    map[string]string
+		payload    string
+		wantStatus int32
+		wantBody   string
+	}{
+		{
+			name:       "gateway path with JSON body and headers",
+			methodPath: "/testbot.punish/GetPunishStatus",
+			headers:    map[string]string{"x-admin-id": "42"},
+			payload:    `{"user_id":"123","guild_id":"456","unknown_field":true}`,
+			wantStatus: 200,
+			wantBody:   "none:123:42",
+		},
+		{
+			name:       "full gRPC path",
+			methodPath: "/punish.PunishServer/GetPunishStatus",
+			payload:    `{"userId":"123"}`,
+			wantStatus: 200,
+			wantBody:   "none:123:",
+		},
+		{
+			name:       "handler error maps to HTTP status",
+			methodPath: "/testbot.punish/GetPunishStatus",
+			payload:    `{}`,
+			wantStatus: 400,
+		},
+		{
+			name:       "malformed body",
+			methodPath: "/testbot.punish/GetPunishStatus",
+			payload:    `{"user_id":`,
+			wantStatus: 400,
+		},
+		{
+			name:       "unimplemented method",
+			methodPath: "/testbot.punish/CreatePunishment",
+			payload:    `{}`,
+			wantStatus: 501,
+		},
+		{
+			name:       "unknown method",
+			methodPath: "/testbot.punish/Nope",
+			wantStatus: 404,
+		},
+		{
+			name:       "unknown package",
+			methodPath: "/testbot.other/GetPunishStatus",
+			wantStatus: 404,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestID := "req-" + string(rune('a'+i))
+			resp := forward(t, conn, &proto.ForwardRequest{
+				RequestId:  requestID,
+				MethodPath: tt.methodPath,
+				Headers:    tt.headers,
+				Payload:    []byte(tt.payload),
+			})
+			if resp.RequestId != requestID {
+				t.Errorf("response for %q, want %q", resp.RequestId, requestID)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d (%s), want %d", resp.StatusCode, resp.ErrorMessage, tt.wantStatus)
+			}
+			if tt.wantBody == "" {
+				return
+			}
+			var body struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal(resp.Payload, &body); err != nil {
+				t.Fatalf("decoding response %s: %v", resp.Payload, err)
+			}
+			if body.Status != tt.wantBody {
+				t.Errorf("status field %q, want %q", body.Status, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTraceIDs(t *testing.T) {
+	c, gateway := newTestClient(t)
+	conn := connect(t, c, gateway)
+
+	resp := forward(t, conn, &proto.ForwardRequest{
+		RequestId:  "traced",
+		MethodPath: "/testbot.punish/GetPunishStatus",
+		Headers:    map[string]string{"X-Trace-Id": "gateway-trace-1"},
+		Payload:    []byte(`{"user_id":"1"}`),
+	})
+	if got := resp.Headers[trace.Header]; got != "gateway-trace-1" {
+		t.Errorf("trace header %q, want the caller's gateway-trace-1", got)
+	}
+
+	// Without a usable trace header the client makes up its own, on errors as well
+	for _, header := range []string{"", "not a trace id!"} {
+		resp = forward(t, conn, &proto.ForwardRequest{
+			RequestId:  "untraced",
+			MethodPath: "/testbot.punish/GetPunishStatus",
+			Headers:    map[string]string{trace.Header: header},
+			Payload:    []byte(`{}`),
+		})
+		if resp.StatusCode != 400 {
+			t.Errorf("status %d, want 400", resp.StatusCode)
+		}
+		if got := resp.Headers[trace.Header]; len(got) != 16 {
+			t.Errorf("trace header %q for header %q, want a generated ID", got, header)
+		}
+	}
+}
+
+func TestHeartbeats(t *testing.T) {
+	c, gateway := newTestClient(t, WithHeartbeatInterval(20*time.Millisecond))
+	gateway.SetHeartbeatDelay(100 * time.Millisecond)
+	conn := connect(t, c, gateway)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		hb, err := conn.NextHeartbeat(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hb.ConnectionId != conn.ID {
+			t.Errorf("heartbeat for connection %q, want %q", hb.ConnectionId, conn.ID)
+		}
+	}
+
+	// Slow heartbeat replies from the gateway must not hold up forwarded requests
+	resp := forward(t, conn, &proto.ForwardRequest{
+		RequestId:  "during-heartbeats",
+		MethodPath: "/testbot.punish/GetPunishStatus",
+		Payload:    []byte(`{"user_id":"1"}`),
+	})
+	if resp.StatusCode != 200 {
+		t.Errorf("status %d (%s), want 200", resp.StatusCode, resp.ErrorMessage)
+	}
+}
+
+func TestReconnectAfterDrop(t *testing.T) {
+	c, gateway := newTestClient(t, WithHeartbeatInterval(20*time.Millisecond))
+	first := connect(t, c, gateway)
+
+	first.Drop()
+	second := waitForConnection(t, c, gateway)
+
+	if second.ID == first.ID {
+		t.Fatalf("reconnected with the same connection ID %q", second.ID)
+	}
+	if len(second.Register.Services) != 1 || second.Register.Services[0] != "testbot.punish" {
+		t.Errorf("re-registered services %v, want [testbot.punish]", second.Register.Services)
+	}
+
+	resp := forward(t, second, &proto.ForwardRequest{
+		RequestId:  "after-reconnect",
+		MethodPath: "/testbot.punish/GetPunishStatus",
+		Payload:    []byte(`{"user_id":"1"}`),
+	})
+	if resp.StatusCode != 200 {
+		t.Errorf("status %d (%s), want 200", resp.StatusCode, resp.ErrorMessage)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	hb, err := second.NextHeartbeat(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hb.ConnectionId != second.ID {
+		t.Errorf("heartbeat for connection %q, want %q", hb.ConnectionId, second.ID)
+	}
+}
+
+func TestReconnectBacksOff(t *testing.T) {
+	c, gateway := newTestClient(t)
+	first := connect(t, c, gateway)
+
+	// Reject three attempts: they should wait 20ms, 40ms and 80ms, then the fourth waits the 80ms maximum,
+	// each reduced by up to half by the jitter
+	gateway.RejectConnections(3)
+	first.Drop()
+	waitForConnection(t, c, gateway)
+
+	attempts := gateway.Attempts()
+	if len(attempts) != 5 {
+		t.Fatalf("%d connection attempts, want 5 (initial, 3 rejected, 1 accepted)", len(attempts))
+	}
+	wantMinGaps := []time.Duration{20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}
+	for i, want := range wantMinGaps {
+		gap := attempts[i+2].Sub(attempts[i+1])
+		if gap < want {
+			t.Errorf("gap before attempt %d is %v, want at least %v", i+3, gap, want)
+		}
+	}
+
+	if got := c.Status().ReconnectAttempts; got != 0 {
+		t.Errorf("attempt count %d after a confirmed connection, want 0", got)
+	}
+}
+
+func TestReconnectNeverGivesUp(t *testing.T) {
+	c, gateway := newTestClient(t, WithReconnectBackoff(time.Millisecond, 2*time.Millisecond))
+	first := connect(t, c, gateway)
+
+	gateway.RejectConnections(30)
+	first.Drop()
+	second := waitForConnection(t, c, gateway)
+
+	if got := len(gateway.Attempts()); got != 32 {
+		t.Errorf("%d connection attempts, want 32 (initial, 30 rejected, 1 accepted)", got)
+	}
+	st := c.Status()
+	if st.State != StateConnected || st.ConnectionID != second.ID {
+		t.Errorf("status %v with connection %q, want connected with %q", st.State, st.ConnectionID, second.ID)
+	}
+	if st.LastError == "" {
+		t.Error("last error not recorded for the rejected attempts")
+	}
+}
+
+func TestFailover(t *testing.T) {
+	primary := gatewaytest.New()
+	secondary := gatewaytest.New()
+	t.Cleanup(secondary.Close)
+	primary.Close()
+
+	t.Setenv("GRPC_SERVER_ADDRESS", "passthrough:///primary, passthrough:///secondary")
+	t.Setenv("GRPC_CLIENT_NAME", "testbot")
+	t.Setenv("GRPC_TOKEN", "secret-token")
+	c, err := NewClient(
+		WithDialOptions(
+			grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+				if addr == "primary" {
+					return primary.Dial(ctx)
+				}
+				return secondary.Dial(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		),
+		WithReconnectBackoff(10*time.Millisecond, 10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	punishpb.RegisterPunishServerServer(c, stubPunishServer{})
+
+	// The primary is down, so the first attempt fails and the client keeps trying with the secondary
+	if err := c.Connect(); err == nil {
+		t.Fatal("Connect succeeded with the primary gateway down")
+	}
+	t.Cleanup(func() { c.Close() })
+	conn := waitForConnection(t, c, secondary)
+
+	if got := c.Status().Address; got != "passthrough:///secondary" {
+		t.Errorf("connected to %q, want passthrough:///secondary", got)
+	}
+	resp := forward(t, conn, &proto.ForwardRequest{
+		RequestId:  "after-failover",
+		MethodPath: "/testbot.punish/GetPunishStatus",
+		Payload:    []byte(`{"user_id":"1"}`),
+	})
+	if resp.StatusCode != 200 {
+		t.Errorf("status %d (%s), want 200", resp.StatusCode, resp.ErrorMessage)
+	}
+}
+
+func TestStatusReportsHeartbeatRTT(t *testing.T) {
+	c, gateway := newTestClient(t, WithHeartbeatInterval(10*time.Millisecond))
+	if got := c.Status().State; got != StateDisconnected {
+		t.Errorf("state %v before Connect, want disconnected", got)
+	}
+	gateway.SetHeartbeatDelay(30 * time.Millisecond)
+	conn := connect(t, c, gateway)
+
+	waitFor(t, func() bool { return !c.Status().LastHeartbeatAt.IsZero() })
+	st := c.Status()
+	if st.State != StateConnected || st.ConnectionID != conn.ID || st.ConnectedSince.IsZero() {
+		t.Errorf("status %+v, want connected with %q", st, conn.ID)
+	}
+	if st.LastHeartbeatRTT < 30*time.Millisecond {
+		t.Errorf("heartbeat RTT %v, want at least the 30ms gateway delay", st.LastHeartbeatRTT)
+	}
+
+	c.Close()
+	if got := c.Status().State; got != StateClosed {
+		t.Errorf("state %v after Close, want closed", got)
+	}
+}
+
+func TestCloseStopsClient(t *testing.T) {
+	c, gateway := newTestClient(t, WithHeartbeatInterval(10*time.Millisecond))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	conn := waitForConnection(t, c, gateway)
+
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("Close did not return")
+	}
+
+	select {
+	case <-conn.Done():
+	case <-time.After(testTimeout):
+		t.Fatal("gateway stream still open after Close")
+	}
+
+	// The client must not reconnect after being closed
+	time.Sleep(100 * time.Millisecond)
+	if got := len(gateway.Attempts()); got != 1 {
+		t.Errorf("%d connection attempts after Close, want 1", got)
+	}
+}
+
+func TestCloseWhileReconnecting(t *testing.T) {
+	c, gateway := newTestClient(t, WithReconnectBackoff(time.Hour, time.Hour))
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	conn := waitForConnection(t, c, gateway)
+
+	conn.Drop()
+	waitFor(t, func() bool { return c.Status().State == StateReconnecting })
+
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(testTimeout):
+		t.Fatal("Close did not interrupt the reconnection backoff")
+	}
+}
+
+func TestEventsBufferedWhileDisconnected(t *testing.T) {
+	c, gateway := newTestClient(t, WithReconnectBackoff(50*time.Millisecond, 50*time.Millisecond))
+	first := connect(t, c, gateway)
+
+	first.Drop()
+	waitFor(t, func() bool { return c.Status().State == StateReconnecting })
+
+	if err := c.Publish("test.event", []byte(`{"n":1}`), map[string]string{"guild_id": "1"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	second := waitForConnection(t, c, gateway)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	event, err := second.NextEvent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.EventType != "test.event" || string(event.Payload) != `{"n":1}` {
+		t.Errorf("flushed event %s %s, want test.event {\"n\":1}", event.EventType, event.Payload)
+	}
+	if event.PublisherId != second.ID {
+		t.Errorf("event publisher %q, want %q", event.PublisherId, second.ID)
+	}
+	if event.Metadata["buffered"] != "true" || event.Metadata["guild_id"] != "1" {
+		t.Errorf("event metadata %v, want buffered=true and guild_id=1", event.Metadata)
+	}
+}
+
+func TestLargeResponsesAreChunked(t *testing.T) {
+	const chunkSize = 1000
+	c, gateway := newTestClient(t, WithResponseChunkSize(chunkSize))
+	conn := connect(t, c, gateway)
+
+	req := &proto.ForwardRequest{
+		RequestId:  "large-history",
+		MethodPath: "/testbot.punish/GetPunishHistory",
+		Payload:    []byte(`{"user_id":"1","guild_id":"2","page":1,"page_size":10}`),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	chunks, err := conn.ForwardRaw(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := protojson.Marshal(stubHistoryPage(1, 10, 25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantChunks := (len(want) + chunkSize - 1) / chunkSize; len(chunks) != wantChunks {
+		t.Fatalf("%d chunks, want %d for a %d byte payload", len(chunks), wantChunks, len(want))
+	}
+	for i, chunk := range chunks {
+		info := chunk.ResponseStreamInfo
+		if chunk.RequestId != req.RequestId || chunk.StatusCode != 200 {
+			t.Errorf("chunk %d is for %q with status %d", i, chunk.RequestId, chunk.StatusCode)
+		}
+		if !info.GetIsStreamed() || info.ChunkIndex != int64(i) || info.GetTotalSize() != int64(len(want)) {
+			t.Errorf("chunk %d has stream info %v", i, info)
+		}
+		if len(chunk.Payload) > chunkSize {
+			t.Errorf("chunk %d has %d bytes, more than the chunk size", i, len(chunk.Payload))
+		}
+	}
+
+	resp := forward(t, conn, req)
+	var got punishpb.GetPunishHistoryResponse
+	if err := protojson.Unmarshal(resp.Payload, &got); err != nil {
+		t.Fatalf("reassembled payload does not decode: %v", err)
+	}
+	if len(got.Punishments) != 10 || got.Punishments[9].Evidence != stubEvidence {
+		t.Errorf("reassembled %d punishments, want 10 with full evidence", len(got.Punishments))
+	}
+
+	// Small responses are still sent as a single message without stream info
+	small := forward(t, conn, &proto.ForwardRequest{
+		RequestId:  "small",
+		MethodPath: "/testbot.punish/GetPunishStatus",
+		Payload:    []byte(`{"user_id":"1"}`),
+	})
+	if small.ResponseStreamInfo != nil {
+		t.Errorf("small response has stream info %v", small.ResponseStreamInfo)
+	}
+}
+
+func TestServerStreamingExport(t *testing.T) {
+	tests := []struct {
+		name      string
+		chunkSize int
+	}{
+		{name: "one message per page", chunkSize: defaultResponseChunkSize},
+		{name: "pages split into chunks", chunkSize: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, gateway := newTestClient(t, WithResponseChunkSize(tt.chunkSize))
+			conn := connect(t, c, gateway)
+
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			pages, final, err := conn.ForwardStream(ctx, &proto.ForwardRequest{
+				RequestId:  "export",
+				MethodPath: "/testbot.punish/ExportPunishHistory",
+				Payload:    []byte(`{"user_id":"1","guild_id":"2","page_size":10}`),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if final.StatusCode != 200 {
+				t.Fatalf("stream ended with status %d (%s)", final.StatusCode, final.ErrorMessage)
+			}
+			if final.StreamingInfo.SequenceNumber != 3 {
+				t.Errorf("end of stream has sequence number %d, want 3", final.StreamingInfo.SequenceNumber)
+			}
+			if len(pages) != 3 {
+				t.Fatalf("%d pages, want 3", len(pages))
+			}
+
+			var ids []int64
+			for i, payload := range pages {
+				var page punishpb.GetPunishHistoryResponse
+				if err := protojson.Unmarshal(payload, &page); err != nil {
+					t.Fatalf("page %d does not decode: %v", i+1, err)
+				}
+				if page.CurrentPage != int32(i+1) || page.TotalPages != 3 {
+					t.Errorf("page %d reports page %d of %d", i+1, page.CurrentPage, page.TotalPages)
+				}
+				for _, p := range page.Punishments {
+					ids = append(ids, p.PunishmentId)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(stubHistoryIDs(25)) {
+				t.Errorf("exported punishments %v, want 1..25 in order", ids)
+			}
+		})
+	}
+}
+
+func TestServerStreamingError(t *testing.T) {
+	c, gateway := newTestClient(t)
+	conn := connect(t, c, gateway)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	pages, final, err := conn.ForwardStream(ctx, &proto.ForwardRequest{
+		RequestId:  "export-invalid",
+		MethodPath: "/testbot.punish/ExportPunishHistory",
+		Payload:    []byte(`{}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 0 {
+		t.Errorf("%d pages from a failed export, want 0", len(pages))
+	}
+	if final.StatusCode != 400 || final.Headers["grpc-status"] != fmt.Sprint(int(codes.InvalidArgument)) {
+		t.Errorf("stream ended with status %d and headers %v, want 400 and InvalidArgument", final.StatusCode, final.Headers)
+	}
+}
+
+func stubHistoryIDs(n int64) []int64 {
+	ids := make([]int64, 0, n)
+	for id := int64(1); id <= n; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
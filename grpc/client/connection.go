@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	proto "newer_helper/grpc/proto/gen/registry"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// run is the connection loop. It serves the current connection until it is lost, then waits out a jittered
+// backoff and connects again, moving on to the next address after every failed attempt. It never gives up;
+// it only stops when the client is closed.
+func (c *Client) run(connected bool) {
+	defer close(c.done)
+	defer func() {
+		c.mu.Lock()
+		c.closeConnectionLocked()
+		c.mu.Unlock()
+	}()
+
+	for {
+		if connected {
+			err := c.serveConnection()
+			if c.ctx.Err() != nil {
+				return
+			}
+			log.Printf("Gateway connection lost: %v", err)
+			c.recordError(err)
+			if !c.connectionLost() {
+				// The gateway dropped the stream before confirming it, so treat it as a failed attempt
+				c.failover()
+			}
+		}
+
+		c.setState(StateReconnecting)
+		attempt := c.nextAttempt()
+		delay := c.backoffDelay(attempt)
+		log.Printf("Reconnecting to %s in %v (attempt %d)...", c.Status().Address, delay, attempt)
+		select {
+		case <-c.ctx.Done():
+			log.Println("Reconnection cancelled by user")
+			return
+		case <-time.After(delay):
+		}
+
+		err := c.connect()
+		if err != nil {
+			log.Printf("Reconnection attempt %d failed: %v", attempt, err)
+		}
+		connected = err == nil
+	}
+}
+
+// connect makes one connection attempt to the current address, failing over to the next address if it fails
+func (c *Client) connect() error {
+	address := c.Status().Address
+	conn, stream, err := c.dial(address)
+	if err != nil {
+		c.recordError(err)
+		c.failover()
+		return err
+	}
+
+	c.mu.Lock()
+	c.closeConnectionLocked()
+	c.conn = conn
+	c.stream = stream
+	c.mu.Unlock()
+
+	log.Printf("Connected to gateway at %s as %s", address, c.clientName)
+	return nil
+}
+
+// dial opens a connection stream to address and registers the services. TLS is used unless dial options were
+// given; plaintext is only tried when TLS fails and GRPC_ALLOW_INSECURE is set.
+func (c *Client) dial(address string) (*grpc.ClientConn, proto.RegistryService_EstablishConnectionClient, error) {
+	if len(c.dialOptions) > 0 {
+		return c.openStream(address, c.dialOptions...)
+	}
+
+	conn, stream, err := c.openStream(address, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{})))
+	if err == nil {
+		log.Printf("Connected using TLS")
+		return conn, stream, nil
+	}
+	if !c.allowInsecure {
+		return nil, nil, fmt.Errorf("TLS connection to %s failed: %w", address, err)
+	}
+
+	log.Printf("TLS connection to %s failed: %v, trying insecure connection as GRPC_ALLOW_INSECURE is set...", address, err)
+	conn, stream, err = c.openStream(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to %s (both TLS and insecure): %w", address, err)
+	}
+	log.Printf("Connected using insecure connection (no TLS)")
+	return conn, stream, nil
+}
+
+// openStream establishes the bidirectional stream and sends the registration message
+func (c *Client) openStream(address string, opts ...grpc.DialOption) (*grpc.ClientConn, proto.RegistryService_EstablishConnectionClient, error) {
+	conn, err := grpc.NewClient(address, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create connection: %w", err)
+	}
+
+	// Create registry service client
+	registryClient := proto.NewRegistryServiceClient(conn)
+
+	// Establish bidirectional stream
+	stream, err := registryClient.EstablishConnection(c.ctx)
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to establish connection stream: %w", err)
+	}
+
+	c.mu.Lock()
+	services := c.registeredPackages()
+	c.mu.Unlock()
+
+	// Send registration message
+	registerMsg := &proto.ConnectionMessage{
+		MessageType: &proto.ConnectionMessage_Register{
+			Register: &proto.ConnectionRegister{
+				ApiKey:   c.token,
+				Services: services,
+			},
+		},
+	}
+	if err := stream.Send(registerMsg); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to send registration: %w", err)
+	}
+
+	return conn, stream, nil
+}
+
+// serveConnection handles incoming messages and sends heartbeats on the current stream until it ends
+func (c *Client) serveConnection() error {
+	c.mu.Lock()
+	stream := c.stream
+	c.mu.Unlock()
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(c.ctx)
+	defer stopHeartbeat()
+	go c.heartbeatLoop(heartbeatCtx, stream)
+
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("gateway closed the connection")
+			}
+			return err
+		}
+		c.handleMessage(msg)
+	}
+}
+
+// connectionLost closes the lost connection and reports whether the gateway had confirmed it
+func (c *Client) connectionLost() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	confirmed := c.connectionID != ""
+	c.connectionID = ""
+	c.closeConnectionLocked()
+	return confirmed
+}
+
+// closeConnectionLocked closes the current stream and connection (must be called with lock held)
+func (c *Client) closeConnectionLocked() {
+	if c.stream != nil {
+		c.stream.CloseSend()
+		c.stream = nil
+	}
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil {
+			log.Printf("Error closing connection: %v", err)
+		}
+		c.conn = nil
+	}
+}
+
+// heartbeatLoop sends periodic heartbeat messages to the gateway until ctx is cancelled or a send fails
+func (c *Client) heartbeatLoop(ctx context.Context, stream proto.RegistryService_EstablishConnectionClient) {
+	ticker := time.NewTicker(c.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			connID := c.connectionID
+			c.mu.Unlock()
+
+			// Skip heartbeat if connection is not ready
+			if connID == "" {
+				log.Printf("Skipping heartbeat - no connection_id yet")
+				continue
+			}
+
+			heartbeat := &proto.ConnectionMessage{
+				MessageType: &proto.ConnectionMessage_Heartbeat{
+					Heartbeat: &proto.Heartbeat{
+						Timestamp:    time.Now().Unix(),
+						ConnectionId: connID, // Use connection_id from gateway
+					},
+				},
+			}
+
+			c.mu.Lock()
+			err := stream.Send(heartbeat)
+			c.mu.Unlock()
+
+			if err != nil {
+				log.Printf("Failed to send heartbeat: %v", err)
+				return
+			}
+			c.recordHeartbeatSent()
+		}
+	}
+}
+
+// backoffDelay returns the wait before a reconnection attempt: the initial delay doubled for every previous
+// attempt up to the maximum, with equal jitter so that many clients do not reconnect in lockstep
+func (c *Client) backoffDelay(attempt int) time.Duration {
+	delay := c.reconnectDelay
+	for i := 1; i < attempt && delay < c.maxReconnectDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.maxReconnectDelay {
+		delay = c.maxReconnectDelay
+	}
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
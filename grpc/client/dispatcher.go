@@ -0,0 +1,243 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	proto "newer_helper/grpc/proto/gen/registry"
+	"newer_helper/utils/trace"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// serviceMethod is a unary or server-streaming method of a registered service
+type serviceMethod struct {
+	impl          any
+	handler       grpc.MethodHandler // set for unary methods
+	streamHandler grpc.StreamHandler // set for server-streaming methods
+}
+
+// RegisterService registers a service implementation with the client so the gateway can forward its
+// requests to it. It satisfies grpc.ServiceRegistrar, so the generated RegisterXxxServer functions can be
+// used directly. Services must be registered before Connect.
+func (c *Client) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.methods == nil {
+		c.methods = make(map[string]*serviceMethod)
+	}
+	for i := range desc.Methods {
+		method := desc.Methods[i]
+		c.methods["/"+desc.ServiceName+"/"+method.MethodName] = &serviceMethod{impl: impl, handler: method.Handler}
+	}
+	streams := 0
+	for _, stream := range desc.Streams {
+		if stream.ClientStreams {
+			log.Printf("Client-streaming method %s/%s is not supported by the gateway client, skipping", desc.ServiceName, stream.StreamName)
+			continue
+		}
+		c.methods["/"+desc.ServiceName+"/"+stream.StreamName] = &serviceMethod{impl: impl, streamHandler: stream.Handler}
+		streams++
+	}
+
+	c.services = append(c.services, desc.ServiceName)
+	log.Printf("Registered gRPC service %s with %d methods and %d server-streaming methods", desc.ServiceName, len(desc.Methods), streams)
+}
+
+// registeredPackages returns the gateway service names to register, one per proto package: "{client_name}.{package}"
+func (c *Client) registeredPackages() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, serviceName := range c.services {
+		name := fmt.Sprintf("%s.%s", c.clientName, servicePackage(serviceName))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// servicePackage returns the proto package of a fully-qualified service name, e.g. "punish" for "punish.PunishServer"
+func servicePackage(serviceName string) string {
+	if idx := strings.LastIndex(serviceName, "."); idx >= 0 {
+		return serviceName[:idx]
+	}
+	return serviceName
+}
+
+// lookupMethod resolves a forwarded method path to a registered method. The gateway uses
+// "/{client_name}.{package}/{Method}", but full gRPC paths such as "/punish.PunishServer/GetPunishStatus" work too.
+func (c *Client) lookupMethod(methodPath string) *serviceMethod {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if method, ok := c.methods[methodPath]; ok {
+		return method
+	}
+
+	service, methodName, ok := strings.Cut(strings.TrimPrefix(methodPath, "/"), "/")
+	if !ok {
+		return nil
+	}
+	pkg := strings.TrimPrefix(service, c.clientName+".")
+	for _, serviceName := range c.services {
+		if servicePackage(serviceName) == pkg {
+			if method, ok := c.methods["/"+serviceName+"/"+methodName]; ok {
+				return method
+			}
+		}
+	}
+	return nil
+}
+
+// dispatch invokes the registered unary handler for a forwarded request and builds the response to send back
+func (c *Client) dispatch(req *proto.ForwardRequest, method *serviceMethod) *proto.ForwardResponse {
+	ctx, cancel := c.requestContext(req)
+	defer cancel()
+
+	if method == nil {
+		trace.Logf(ctx, "Unknown method path: %s", req.MethodPath)
+		return &proto.ForwardResponse{
+			RequestId:    req.RequestId,
+			StatusCode:   404,
+			Headers:      traceHeaders(ctx),
+			ErrorMessage: fmt.Sprintf("method not found: %s", req.MethodPath),
+		}
+	}
+	trace.Logf(ctx, "Dispatching request %s to %s", req.RequestId, req.MethodPath)
+
+	decode := func(m any) error {
+		msg, ok := m.(protobuf.Message)
+		if !ok {
+			return fmt.Errorf("request type %T is not a proto message", m)
+		}
+		return decodePayload(req.Payload, msg)
+	}
+
+	resp, err := method.handler(method.impl, ctx, decode, nil)
+	if err != nil {
+		return errorResponse(ctx, req, err)
+	}
+
+	respMsg, ok := resp.(protobuf.Message)
+	if !ok {
+		return &proto.ForwardResponse{
+			RequestId:    req.RequestId,
+			StatusCode:   500,
+			Headers:      traceHeaders(ctx),
+			ErrorMessage: fmt.Sprintf("response type %T is not a proto message", resp),
+		}
+	}
+	respBody, err := protojson.Marshal(respMsg)
+	if err != nil {
+		trace.Logf(ctx, "Failed to marshal %s response: %v", req.MethodPath, err)
+		return &proto.ForwardResponse{
+			RequestId:    req.RequestId,
+			StatusCode:   500,
+			Headers:      traceHeaders(ctx),
+			ErrorMessage: fmt.Sprintf("failed to marshal response: %v", err),
+		}
+	}
+
+	return &proto.ForwardResponse{
+		RequestId:  req.RequestId,
+		StatusCode: 200,
+		Headers:    traceHeaders(ctx),
+		Payload:    respBody,
+	}
+}
+
+// requestContext builds the handler context of a forwarded request, carrying its headers as incoming metadata,
+// its timeout and its trace ID, taken from the x-trace-id header when the gateway or caller sets one
+func (c *Client) requestContext(req *proto.ForwardRequest) (context.Context, context.CancelFunc) {
+	md := metadata.New(req.Headers)
+	ctx := metadata.NewIncomingContext(c.ctx, md)
+	traceID := ""
+	if values := md.Get(trace.Header); len(values) > 0 {
+		traceID = values[0]
+	}
+	ctx = trace.FromHeader(ctx, traceID)
+	if req.TimeoutSeconds > 0 {
+		return context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+	}
+	return context.WithCancel(ctx)
+}
+
+// traceHeaders returns the response headers reporting the trace ID of the request back to the gateway
+func traceHeaders(ctx context.Context) map[string]string {
+	return map[string]string{trace.Header: trace.ID(ctx)}
+}
+
+// errorResponse builds the response for a failed request, carrying the gRPC status code in a header
+func errorResponse(ctx context.Context, req *proto.ForwardRequest, err error) *proto.ForwardResponse {
+	st := status.Convert(err)
+	trace.Logf(ctx, "%s failed: %s: %s", req.MethodPath, st.Code(), st.Message())
+	headers := traceHeaders(ctx)
+	headers["grpc-status"] = fmt.Sprintf("%d", st.Code())
+	return &proto.ForwardResponse{
+		RequestId:    req.RequestId,
+		StatusCode:   httpStatusFromCode(st.Code()),
+		Headers:      headers,
+		ErrorMessage: st.Message(),
+	}
+}
+
+// decodePayload decodes a request body, which the gateway sends as JSON; binary protobuf is accepted as well
+func decodePayload(payload []byte, msg protobuf.Message) error {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if trimmed[0] == '{' {
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(trimmed, msg); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+		}
+		return nil
+	}
+	if err := protobuf.Unmarshal(payload, msg); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// httpStatusFromCode maps a gRPC status code to the HTTP status reported to the gateway
+func httpStatusFromCode(code codes.Code) int32 {
+	switch code {
+	case codes.OK:
+		return 200
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return 400
+	case codes.Unauthenticated:
+		return 401
+	case codes.PermissionDenied:
+		return 403
+	case codes.NotFound:
+		return 404
+	case codes.AlreadyExists, codes.Aborted:
+		return 409
+	case codes.FailedPrecondition:
+		return 412
+	case codes.ResourceExhausted:
+		return 429
+	case codes.Unimplemented:
+		return 501
+	case codes.Unavailable:
+		return 503
+	case codes.DeadlineExceeded:
+		return 504
+	default:
+		return 500
+	}
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"newer_helper/grpc/events"
+	proto "newer_helper/grpc/proto/gen/registry"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBufferedEvents caps how many events are kept while the gateway is unreachable; the oldest are dropped first
+const maxBufferedEvents = 1000
+
+// Publish sends an event to the gateway. While the client is disconnected or reconnecting the event is buffered
+// and sent once the gateway confirms the new connection.
+func (c *Client) Publish(eventType string, payload []byte, metadata map[string]string) error {
+	select {
+	case <-c.ctx.Done():
+		return fmt.Errorf("client is closed, dropping %s event", eventType)
+	default:
+	}
+
+	now := time.Now()
+	eventMetadata := map[string]string{
+		"source":           c.clientName,
+		"content_type":     "application/json",
+		"emitted_at":       now.UTC().Format(time.RFC3339Nano),
+		"delivery_attempt": "0",
+	}
+	for key, value := range metadata {
+		eventMetadata[key] = value
+	}
+
+	event := &proto.EventMessage{
+		EventId:   newEventID(),
+		EventType: eventType,
+		Payload:   payload,
+		Timestamp: now.Unix(),
+		Metadata:  eventMetadata,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil || c.connectionID == "" {
+		c.bufferEventLocked(event)
+		return nil
+	}
+	if err := c.sendEventLocked(event); err != nil {
+		log.Printf("Failed to send event %s (%s), buffering for retry: %v", event.EventId, eventType, err)
+		c.bufferEventLocked(event)
+	}
+	return nil
+}
+
+// Subscribe registers a handler for an event type and asks the gateway to deliver it.
+// Subscriptions are renewed automatically after a reconnect.
+func (c *Client) Subscribe(eventType string, handler events.Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.eventHandlers == nil {
+		c.eventHandlers = make(map[string][]events.Handler)
+	}
+	first := len(c.eventHandlers[eventType]) == 0
+	c.eventHandlers[eventType] = append(c.eventHandlers[eventType], handler)
+
+	if first && c.stream != nil && c.connectionID != "" {
+		if err := c.sendSubscriptionLocked([]string{eventType}); err != nil {
+			log.Printf("Failed to subscribe to %s, will retry after reconnect: %v", eventType, err)
+		}
+	}
+}
+
+// onConnected renews subscriptions and flushes buffered events once the gateway has assigned a connection ID
+func (c *Client) onConnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.eventHandlers) > 0 {
+		eventTypes := make([]string, 0, len(c.eventHandlers))
+		for eventType := range c.eventHandlers {
+			eventTypes = append(eventTypes, eventType)
+		}
+		if err := c.sendSubscriptionLocked(eventTypes); err != nil {
+			log.Printf("Failed to renew event subscriptions: %v", err)
+		}
+	}
+
+	if len(c.eventBuffer) == 0 {
+		return
+	}
+	log.Printf("Flushing %d buffered events", len(c.eventBuffer))
+	for i, event := range c.eventBuffer {
+		if err := c.sendEventLocked(event); err != nil {
+			log.Printf("Failed to flush buffered events, %d remain: %v", len(c.eventBuffer)-i, err)
+			c.eventBuffer = c.eventBuffer[i:]
+			return
+		}
+	}
+	c.eventBuffer = nil
+}
+
+// deliverEvent passes an event received from the gateway to every matching handler
+func (c *Client) deliverEvent(msg *proto.EventMessage) {
+	c.mu.Lock()
+	ownEvent := msg.PublisherId != "" && msg.PublisherId == c.connectionID
+	var handlers []events.Handler
+	for pattern, patternHandlers := range c.eventHandlers {
+		if eventTypeMatches(pattern, msg.EventType) {
+			handlers = append(handlers, patternHandlers...)
+		}
+	}
+	c.mu.Unlock()
+
+	if ownEvent || len(handlers) == 0 {
+		return
+	}
+
+	event := events.Event{
+		ID:          msg.EventId,
+		Type:        msg.EventType,
+		PublisherID: msg.PublisherId,
+		Payload:     msg.Payload,
+		Timestamp:   time.Unix(msg.Timestamp, 0),
+		Metadata:    msg.Metadata,
+	}
+	for _, handler := range handlers {
+		go func(handler events.Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Event handler for %s panicked: %v", event.Type, r)
+				}
+			}()
+			handler(event)
+		}(handler)
+	}
+}
+
+// sendEventLocked sends an event on the current stream, stamping delivery metadata (must be called with lock held)
+func (c *Client) sendEventLocked(event *proto.EventMessage) error {
+	attempt, _ := strconv.Atoi(event.Metadata["delivery_attempt"])
+	event.Metadata["delivery_attempt"] = strconv.Itoa(attempt + 1)
+	event.Metadata["sent_at"] = time.Now().UTC().Format(time.RFC3339Nano)
+	event.PublisherId = c.connectionID
+
+	return c.stream.Send(&proto.ConnectionMessage{
+		MessageType: &proto.ConnectionMessage_Event{Event: event},
+	})
+}
+
+// sendSubscriptionLocked asks the gateway to deliver the given event types (must be called with lock held)
+func (c *Client) sendSubscriptionLocked(eventTypes []string) error {
+	return c.stream.Send(&proto.ConnectionMessage{
+		MessageType: &proto.ConnectionMessage_Subscription{
+			Subscription: &proto.SubscriptionRequest{
+				Action:       proto.SubscriptionRequest_SUBSCRIBE,
+				EventTypes:   eventTypes,
+				SubscriberId: c.connectionID,
+			},
+		},
+	})
+}
+
+// bufferEventLocked keeps an event until the connection is back (must be called with lock held)
+func (c *Client) bufferEventLocked(event *proto.EventMessage) {
+	if len(c.eventBuffer) >= maxBufferedEvents {
+		dropped := c.eventBuffer[0]
+		log.Printf("Event buffer full, dropping oldest event %s (%s)", dropped.EventId, dropped.EventType)
+		c.eventBuffer = c.eventBuffer[1:]
+	}
+	event.Metadata["buffered"] = "true"
+	c.eventBuffer = append(c.eventBuffer, event)
+}
+
+// eventTypeMatches reports whether an event type matches a subscription pattern such as "ban.*"
+func eventTypeMatches(pattern, eventType string) bool {
+	if pattern == "*" || pattern == eventType {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return false
+}
+
+// newEventID returns a random identifier for an outgoing event
+func newEventID() string {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(idBytes)
+}
@@ -0,0 +1,415 @@
+// Package gatewaytest provides an in-process fake of the gateway's RegistryService for testing the
+// reverse-connection client without a real gateway. It serves over an in-memory bufconn listener and lets
+// tests forward requests, drop streams, delay heartbeats, reject connection attempts and inspect what the
+// client sends.
+package gatewaytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	proto "newer_helper/grpc/proto/gen/registry"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// Address is the gateway address to configure the client with; DialOptions routes it to the fake.
+const Address = "passthrough:///gatewaytest"
+
+const bufferSize = 1024 * 1024
+
+// Gateway is a fake gateway serving RegistryService.EstablishConnection
+type Gateway struct {
+	proto.UnimplementedRegistryServiceServer
+
+	listener *bufconn.Listener
+	server   *grpc.Server
+	conns    chan *Conn
+
+	mu             sync.Mutex
+	nextID         int
+	attempts       []time.Time
+	rejectNext     int
+	heartbeatDelay time.Duration
+}
+
+// New starts a fake gateway. Stop it with Close.
+func New() *Gateway {
+	g := &Gateway{
+		listener: bufconn.Listen(bufferSize),
+		server:   grpc.NewServer(),
+		conns:    make(chan *Conn, 16),
+	}
+	proto.RegisterRegistryServiceServer(g.server, g)
+	go g.server.Serve(g.listener)
+	return g
+}
+
+// DialOptions returns the dial options that connect a client to the fake
+func (g *Gateway) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return g.Dial(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+// Dial opens an in-memory connection to the fake, for tests that route several addresses to different fakes.
+// It fails once the fake is closed.
+func (g *Gateway) Dial(ctx context.Context) (net.Conn, error) {
+	return g.listener.DialContext(ctx)
+}
+
+// Close stops the fake, ending every open stream
+func (g *Gateway) Close() {
+	g.server.Stop()
+}
+
+// RejectConnections makes the next n connection attempts fail with Unavailable
+func (g *Gateway) RejectConnections(n int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rejectNext = n
+}
+
+// SetHeartbeatDelay delays the gateway's reply to every client heartbeat
+func (g *Gateway) SetHeartbeatDelay(delay time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.heartbeatDelay = delay
+}
+
+// Attempts returns the times of every connection attempt, including rejected ones
+func (g *Gateway) Attempts() []time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]time.Time(nil), g.attempts...)
+}
+
+// WaitForConnection returns the next accepted connection
+func (g *Gateway) WaitForConnection(ctx context.Context) (*Conn, error) {
+	select {
+	case conn := <-g.conns:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no connection: %w", ctx.Err())
+	}
+}
+
+// EstablishConnection accepts a client registration and serves the connection until the client leaves
+// or the test drops it
+func (g *Gateway) EstablishConnection(stream proto.RegistryService_EstablishConnectionServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	register := first.GetRegister()
+	if register == nil {
+		return status.Error(codes.InvalidArgument, "first message must be a registration")
+	}
+
+	g.mu.Lock()
+	g.attempts = append(g.attempts, time.Now())
+	if g.rejectNext > 0 {
+		g.rejectNext--
+		g.mu.Unlock()
+		return status.Error(codes.Unavailable, "gateway is rejecting connections")
+	}
+	g.nextID++
+	conn := newConn(g, fmt.Sprintf("conn-%d", g.nextID), register, stream)
+	g.mu.Unlock()
+
+	if err := conn.Send(&proto.ConnectionMessage{
+		MessageType: &proto.ConnectionMessage_Status{
+			Status: &proto.ConnectionStatus{
+				ConnectionId: conn.ID,
+				Status:       proto.ConnectionStatus_CONNECTED,
+				Message:      "connected",
+			},
+		},
+	}); err != nil {
+		return err
+	}
+	g.conns <- conn
+
+	return conn.serve()
+}
+
+// Conn is a client connection accepted by the fake
+type Conn struct {
+	ID       string
+	Register *proto.ConnectionRegister
+
+	gateway *Gateway
+	stream  proto.RegistryService_EstablishConnectionServer
+	sendMu  sync.Mutex
+
+	mu      sync.Mutex
+	pending map[string]chan *proto.ForwardResponse
+
+	heartbeats    chan *proto.Heartbeat
+	events        chan *proto.EventMessage
+	subscriptions chan *proto.SubscriptionRequest
+
+	drop     chan struct{}
+	dropOnce sync.Once
+	done     chan struct{}
+}
+
+func newConn(g *Gateway, id string, register *proto.ConnectionRegister, stream proto.RegistryService_EstablishConnectionServer) *Conn {
+	return &Conn{
+		ID:            id,
+		Register:      register,
+		gateway:       g,
+		stream:        stream,
+		pending:       make(map[string]chan *proto.ForwardResponse),
+		heartbeats:    make(chan *proto.Heartbeat, 64),
+		events:        make(chan *proto.EventMessage, 64),
+		subscriptions: make(chan *proto.SubscriptionRequest, 64),
+		drop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// serve reads client messages until the stream ends or the connection is dropped
+func (c *Conn) serve() error {
+	defer close(c.done)
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := c.stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			c.handle(msg)
+		}
+	}()
+
+	select {
+	case err := <-recvErr:
+		return err
+	case <-c.drop:
+		return status.Error(codes.Unavailable, "connection dropped by gateway")
+	}
+}
+
+func (c *Conn) handle(msg *proto.ConnectionMessage) {
+	switch m := msg.MessageType.(type) {
+	case *proto.ConnectionMessage_Response:
+		c.mu.Lock()
+		ch, ok := c.pending[m.Response.RequestId]
+		if isLastResponse(m.Response) {
+			delete(c.pending, m.Response.RequestId)
+		}
+		c.mu.Unlock()
+		if ok {
+			ch <- m.Response
+		}
+
+	case *proto.ConnectionMessage_Heartbeat:
+		c.heartbeats <- m.Heartbeat
+		c.gateway.mu.Lock()
+		delay := c.gateway.heartbeatDelay
+		c.gateway.mu.Unlock()
+		go func() {
+			time.Sleep(delay)
+			c.Send(&proto.ConnectionMessage{
+				MessageType: &proto.ConnectionMessage_Heartbeat{
+					Heartbeat: &proto.Heartbeat{Timestamp: time.Now().Unix(), ConnectionId: c.ID},
+				},
+			})
+		}()
+
+	case *proto.ConnectionMessage_Event:
+		c.events <- m.Event
+
+	case *proto.ConnectionMessage_Subscription:
+		c.subscriptions <- m.Subscription
+	}
+}
+
+// Send sends a message to the client
+func (c *Conn) Send(msg *proto.ConnectionMessage) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	return c.stream.Send(msg)
+}
+
+// Forward sends a unary request to the client and waits for its response, reassembling a chunked payload
+func (c *Conn) Forward(ctx context.Context, req *proto.ForwardRequest) (*proto.ForwardResponse, error) {
+	messages, err := c.ForwardRaw(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 1 {
+		return messages[0], nil
+	}
+	return joinChunks(messages)
+}
+
+// ForwardStream sends a server-streaming request to the client and waits for the end of the stream.
+// It returns the payload of every stream message, with chunks reassembled, and the final message that
+// carries the status of the call.
+func (c *Conn) ForwardStream(ctx context.Context, req *proto.ForwardRequest) ([][]byte, *proto.ForwardResponse, error) {
+	messages, err := c.ForwardRaw(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var payloads [][]byte
+	var chunks []*proto.ForwardResponse
+	for i, msg := range messages {
+		if msg.StreamingInfo == nil || msg.StreamingInfo.StreamType != proto.StreamingInfo_SERVER_STREAMING {
+			return nil, nil, fmt.Errorf("message %d is not part of a server stream", i)
+		}
+		if msg.StreamingInfo.IsStreamEnd {
+			return payloads, msg, nil
+		}
+		chunks = append(chunks, msg)
+		if msg.ResponseStreamInfo.GetIsStreamed() && !msg.ResponseStreamInfo.IsFinalChunk {
+			continue
+		}
+		joined, err := joinChunks(chunks)
+		if err != nil {
+			return nil, nil, err
+		}
+		if want := int64(len(payloads)); msg.StreamingInfo.SequenceNumber != want {
+			return nil, nil, fmt.Errorf("stream message has sequence number %d, want %d", msg.StreamingInfo.SequenceNumber, want)
+		}
+		payloads = append(payloads, joined.Payload)
+		chunks = nil
+	}
+	return nil, nil, errors.New("stream ended without an end-of-stream message")
+}
+
+// ForwardRaw sends a request to the client and returns every response message it sent back, up to the
+// final chunk of a unary response or the end of a stream
+func (c *Conn) ForwardRaw(ctx context.Context, req *proto.ForwardRequest) ([]*proto.ForwardResponse, error) {
+	ch := make(chan *proto.ForwardResponse, 16)
+	c.mu.Lock()
+	c.pending[req.RequestId] = ch
+	c.mu.Unlock()
+
+	if err := c.Send(&proto.ConnectionMessage{
+		MessageType: &proto.ConnectionMessage_Request{Request: req},
+	}); err != nil {
+		c.mu.Lock()
+		delete(c.pending, req.RequestId)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	var messages []*proto.ForwardResponse
+	for {
+		select {
+		case resp := <-ch:
+			messages = append(messages, resp)
+			if isLastResponse(resp) {
+				return messages, nil
+			}
+		case <-c.done:
+			return nil, errors.New("connection closed before the response arrived")
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no response to %s: %w", req.RequestId, ctx.Err())
+		}
+	}
+}
+
+// isLastResponse reports whether a response message is the last one for its request
+func isLastResponse(resp *proto.ForwardResponse) bool {
+	if resp.StreamingInfo != nil && resp.StreamingInfo.StreamType == proto.StreamingInfo_SERVER_STREAMING {
+		return resp.StreamingInfo.IsStreamEnd
+	}
+	if resp.ResponseStreamInfo.GetIsStreamed() {
+		return resp.ResponseStreamInfo.IsFinalChunk
+	}
+	return true
+}
+
+// joinChunks reassembles a chunked payload, checking the chunk order and sizes against ResponseStreamInfo
+func joinChunks(chunks []*proto.ForwardResponse) (*proto.ForwardResponse, error) {
+	if len(chunks) == 1 && !chunks[0].ResponseStreamInfo.GetIsStreamed() {
+		return chunks[0], nil
+	}
+
+	var payload []byte
+	for i, chunk := range chunks {
+		info := chunk.ResponseStreamInfo
+		if !info.GetIsStreamed() {
+			return nil, fmt.Errorf("chunk %d has no stream info", i)
+		}
+		if info.ChunkIndex != int64(i) {
+			return nil, fmt.Errorf("chunk %d has index %d", i, info.ChunkIndex)
+		}
+		if int(info.ChunkSize) != len(chunk.Payload) {
+			return nil, fmt.Errorf("chunk %d has size %d but %d bytes", i, info.ChunkSize, len(chunk.Payload))
+		}
+		if info.IsFinalChunk != (i == len(chunks)-1) {
+			return nil, fmt.Errorf("chunk %d of %d has final flag %t", i, len(chunks), info.IsFinalChunk)
+		}
+		payload = append(payload, chunk.Payload...)
+	}
+
+	last := chunks[len(chunks)-1]
+	if total := last.ResponseStreamInfo.TotalSize; total != nil && *total != int64(len(payload)) {
+		return nil, fmt.Errorf("reassembled %d bytes, want total size %d", len(payload), *total)
+	}
+
+	joined := protobuf.Clone(last).(*proto.ForwardResponse)
+	joined.Payload = payload
+	joined.ResponseStreamInfo = nil
+	return joined, nil
+}
+
+// Drop ends the stream with an Unavailable error, as a gateway restart would
+func (c *Conn) Drop() {
+	c.dropOnce.Do(func() {
+		close(c.drop)
+	})
+}
+
+// Done is closed once the connection has ended
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// NextHeartbeat waits for the next heartbeat from the client
+func (c *Conn) NextHeartbeat(ctx context.Context) (*proto.Heartbeat, error) {
+	select {
+	case hb := <-c.heartbeats:
+		return hb, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no heartbeat: %w", ctx.Err())
+	}
+}
+
+// NextEvent waits for the next event published by the client
+func (c *Conn) NextEvent(ctx context.Context) (*proto.EventMessage, error) {
+	select {
+	case event := <-c.events:
+		return event, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no event: %w", ctx.Err())
+	}
+}
+
+// NextSubscription waits for the next subscription request from the client
+func (c *Conn) NextSubscription(ctx context.Context) (*proto.SubscriptionRequest, error) {
+	select {
+	case sub := <-c.subscriptions:
+		return sub, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no subscription: %w", ctx.Err())
+	}
+}
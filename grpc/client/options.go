@@ -0,0 +1,42 @@
+package client
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Option customizes a Client created by NewClient
+type Option func(*Client)
+
+// WithDialOptions sets the options used to dial the gateway, replacing the default TLS dialing.
+// Tests use it to connect over an in-memory listener.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *Client) {
+		c.dialOptions = opts
+	}
+}
+
+// WithHeartbeatInterval sets how often a heartbeat is sent to the gateway (default 30s)
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.heartbeatInterval = interval
+	}
+}
+
+// WithReconnectBackoff sets the exponential backoff between reconnection attempts, before jitter
+// (default starting at 2s and capped at 60s)
+func WithReconnectBackoff(initialDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		c.reconnectDelay = initialDelay
+		c.maxReconnectDelay = maxDelay
+	}
+}
+
+// WithResponseChunkSize sets the largest payload sent in one response message; larger responses are split
+// into chunks (default 1 MiB, or GRPC_RESPONSE_CHUNK_SIZE)
+func WithResponseChunkSize(bytes int) Option {
+	return func(c *Client) {
+		c.responseChunkSize = bytes
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// ConnectionState is the state of the gateway connection
+type ConnectionState int
+
+const (
+	StateDisconnected ConnectionState = iota // Connect has not been called
+	StateConnecting                          // first attempt, or waiting for the gateway to confirm
+	StateConnected                           // the gateway confirmed the connection
+	StateReconnecting                        // the connection was lost or an attempt failed, retrying with backoff
+	StateClosed                              // Close was called
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// Status is a snapshot of the gateway connection
+type Status struct {
+	State             ConnectionState
+	Address           string // gateway address in use, or tried next while reconnecting
+	ConnectionID      string
+	ConnectedSince    time.Time
+	ReconnectAttempts int // attempts since the gateway last confirmed a connection
+	LastError         string
+	LastErrorAt       time.Time
+	LastHeartbeatRTT  time.Duration // time from a heartbeat to the gateway's next heartbeat
+	LastHeartbeatAt   time.Time
+}
+
+// Status returns the current state of the gateway connection
+func (c *Client) Status() Status {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	return c.status
+}
+
+func (c *Client) setState(state ConnectionState) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.State = state
+	if state != StateConnected {
+		c.status.ConnectionID = ""
+		c.status.ConnectedSince = time.Time{}
+		c.heartbeatSentAt = time.Time{}
+	}
+}
+
+// markConnected records that the gateway confirmed the connection, which resets the backoff
+func (c *Client) markConnected(connectionID string) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.State = StateConnected
+	c.status.ConnectionID = connectionID
+	c.status.ConnectedSince = time.Now()
+	c.status.ReconnectAttempts = 0
+}
+
+// nextAttempt counts a reconnection attempt and returns its number
+func (c *Client) nextAttempt() int {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.ReconnectAttempts++
+	return c.status.ReconnectAttempts
+}
+
+func (c *Client) recordError(err error) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.status.LastError = err.Error()
+	c.status.LastErrorAt = time.Now()
+}
+
+// failover moves on to the next gateway address
+func (c *Client) failover() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if len(c.addresses) < 2 {
+		return
+	}
+	for i, address := range c.addresses {
+		if address == c.status.Address {
+			c.status.Address = c.addresses[(i+1)%len(c.addresses)]
+			return
+		}
+	}
+	c.status.Address = c.addresses[0]
+}
+
+// recordHeartbeatSent starts timing a heartbeat round trip, unless an earlier heartbeat is still unanswered
+func (c *Client) recordHeartbeatSent() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if c.heartbeatSentAt.IsZero() {
+		c.heartbeatSentAt = time.Now()
+	}
+}
+
+// recordHeartbeatReply completes the heartbeat round trip when the gateway's heartbeat arrives
+func (c *Client) recordHeartbeatReply() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	now := time.Now()
+	if !c.heartbeatSentAt.IsZero() {
+		c.status.LastHeartbeatRTT = now.Sub(c.heartbeatSentAt)
+		c.heartbeatSentAt = time.Time{}
+	}
+	c.status.LastHeartbeatAt = now
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient *Client
+)
+
+// SetDefault makes c the client reported by DefaultStatus, e.g. in /system-info
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = c
+}
+
+// DefaultStatus returns the connection status of the default client, or false if the gateway is not configured
+func DefaultStatus() (Status, bool) {
+	defaultMu.RLock()
+	c := defaultClient
+	defaultMu.RUnlock()
+	if c == nil {
+		return Status{}, false
+	}
+	return c.Status(), true
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	proto "newer_helper/grpc/proto/gen/registry"
+	"newer_helper/utils/trace"
+	"sync"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// defaultResponseChunkSize is the largest payload sent in a single response message, well below the
+// 4 MiB default message limit of gRPC
+const defaultResponseChunkSize = 1 << 20
+
+// sendResponse sends a response to the gateway. Payloads larger than the chunk size are split into several
+// messages with the same request ID, numbered by ResponseStreamInfo.ChunkIndex; the last one has IsFinalChunk set.
+// For a stream message, IsStreamEnd is only set on its final chunk.
+func (c *Client) sendResponse(resp *proto.ForwardResponse) error {
+	chunkSize := c.responseChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultResponseChunkSize
+	}
+	if len(resp.Payload) <= chunkSize {
+		return c.sendResponseMessage(resp)
+	}
+
+	payload := resp.Payload
+	totalSize := int64(len(payload))
+	for index, start := 0, 0; start < len(payload); index, start = index+1, start+chunkSize {
+		end := min(start+chunkSize, len(payload))
+		final := end == len(payload)
+
+		chunk := &proto.ForwardResponse{
+			RequestId:    resp.RequestId,
+			StatusCode:   resp.StatusCode,
+			Headers:      resp.Headers,
+			Payload:      payload[start:end],
+			ErrorMessage: resp.ErrorMessage,
+			ResponseStreamInfo: &proto.ResponseStreamInfo{
+				IsStreamed:   true,
+				ChunkIndex:   int64(index),
+				IsFinalChunk: final,
+				ChunkSize:    int32(end - start),
+				TotalSize:    &totalSize,
+			},
+		}
+		if resp.StreamingInfo != nil {
+			streamingInfo := protobuf.Clone(resp.StreamingInfo).(*proto.StreamingInfo)
+			streamingInfo.IsStreamEnd = resp.StreamingInfo.IsStreamEnd && final
+			streamingInfo.ChunkSize = int32(end - start)
+			chunk.StreamingInfo = streamingInfo
+		}
+
+		if err := c.sendResponseMessage(chunk); err != nil {
+			return fmt.Errorf("failed to send chunk %d: %w", index, err)
+		}
+	}
+	return nil
+}
+
+// sendResponseMessage sends a single response message on the current stream
+func (c *Client) sendResponseMessage(resp *proto.ForwardResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stream == nil {
+		return errors.New("not connected to the gateway")
+	}
+	return c.stream.Send(&proto.ConnectionMessage{
+		MessageType: &proto.ConnectionMessage_Response{Response: resp},
+	})
+}
+
+// dispatchStream runs a server-streaming handler for a forwarded request. Every message the handler sends is
+// forwarded as a response with StreamingInfo and an increasing sequence number; a final message with
+// IsStreamEnd set carries the outcome of the call.
+func (c *Client) dispatchStream(req *proto.ForwardRequest, method *serviceMethod) {
+	ctx, cancel := c.requestContext(req)
+	defer cancel()
+
+	stream := &forwardServerStream{ctx: ctx, client: c, req: req}
+	err := method.streamHandler(method.impl, stream)
+
+	var final *proto.ForwardResponse
+	if err != nil {
+		final = errorResponse(ctx, req, err)
+	} else {
+		final = &proto.ForwardResponse{RequestId: req.RequestId, StatusCode: 200, Headers: traceHeaders(ctx)}
+	}
+	final.StreamingInfo = &proto.StreamingInfo{
+		StreamType:     proto.StreamingInfo_SERVER_STREAMING,
+		IsStreamEnd:    true,
+		SequenceNumber: stream.nextSequence(),
+	}
+	if err := c.sendResponse(final); err != nil {
+		trace.Logf(ctx, "Failed to send end of stream for request %s: %v", req.RequestId, err)
+	}
+}
+
+// forwardServerStream adapts a forwarded request to grpc.ServerStream for server-streaming handlers
+type forwardServerStream struct {
+	ctx    context.Context
+	client *Client
+	req    *proto.ForwardRequest
+
+	mu       sync.Mutex
+	received bool  // the request message has been read
+	sequence int64 // sequence number of the next message
+}
+
+func (s *forwardServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg forwards one stream message to the gateway
+func (s *forwardServerStream) SendMsg(m any) error {
+	msg, ok := m.(protobuf.Message)
+	if !ok {
+		return fmt.Errorf("stream message type %T is not a proto message", m)
+	}
+	payload, err := protojson.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream message: %w", err)
+	}
+
+	return s.client.sendResponse(&proto.ForwardResponse{
+		RequestId:  s.req.RequestId,
+		StatusCode: 200,
+		Payload:    payload,
+		StreamingInfo: &proto.StreamingInfo{
+			StreamType:     proto.StreamingInfo_SERVER_STREAMING,
+			SequenceNumber: s.nextSequence(),
+		},
+	})
+}
+
+// RecvMsg decodes the request message; a server-streaming call has exactly one
+func (s *forwardServerStream) RecvMsg(m any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.received {
+		return io.EOF
+	}
+	s.received = true
+
+	msg, ok := m.(protobuf.Message)
+	if !ok {
+		return fmt.Errorf("request type %T is not a proto message", m)
+	}
+	return decodePayload(s.req.Payload, msg)
+}
+
+func (s *forwardServerStream) nextSequence() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sequence := s.sequence
+	s.sequence++
+	return sequence
+}
+
+// Headers and trailers are not forwarded by the gateway
+func (s *forwardServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *forwardServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *forwardServerStream) SetTrailer(metadata.MD)       {}
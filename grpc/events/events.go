@@ -0,0 +1,125 @@
+// Package events lets the bot publish events to, and react to events from, other services connected to the gateway.
+// The gateway client installs itself as the Bus on startup; until then, or without a gateway, publishing is a no-op.
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"newer_helper/model"
+	"sync"
+	"time"
+)
+
+// Event types published by the bot.
+const (
+	PunishCreated = "punish.created"
+	PunishRevoked = "punish.revoked"
+	PostCreated   = "post.created"
+)
+
+// Event is an event received from the gateway.
+type Event struct {
+	ID          string
+	Type        string
+	PublisherID string // gateway connection ID of the publishing service
+	Payload     []byte
+	Timestamp   time.Time
+	Metadata    map[string]string
+}
+
+// Decode unmarshals the JSON payload of the event into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler reacts to a received event.
+type Handler func(Event)
+
+// Bus delivers events to and from the gateway.
+type Bus interface {
+	Publish(eventType string, payload []byte, metadata map[string]string) error
+	Subscribe(eventType string, handler Handler)
+}
+
+// PunishmentEvent is the payload of punish.created and punish.revoked.
+type PunishmentEvent struct {
+	PunishmentID int64  `json:"punishment_id"`
+	GuildID      string `json:"guild_id"`
+	UserID       string `json:"user_id"`
+	AdminID      string `json:"admin_id"` // admin who created or revoked the punishment
+	ActionType   string `json:"action_type"`
+	Reason       string `json:"reason"`
+	Status       string `json:"punishment_status"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+// NewPunishmentEvent builds the event payload for a punishment record.
+func NewPunishmentEvent(record *model.PunishmentRecord, adminID string) PunishmentEvent {
+	return PunishmentEvent{
+		PunishmentID: record.PunishmentID,
+		GuildID:      record.GuildID,
+		UserID:       record.UserID,
+		AdminID:      adminID,
+		ActionType:   record.ActionType,
+		Reason:       record.Reason,
+		Status:       record.PunishmentStatus,
+		Timestamp:    record.Timestamp,
+	}
+}
+
+var (
+	mu      sync.RWMutex
+	bus     Bus
+	pending []subscription // subscriptions made before a bus was installed
+)
+
+type subscription struct {
+	eventType string
+	handler   Handler
+}
+
+// SetBus installs the bus used to publish and subscribe, replaying any earlier subscriptions on it.
+func SetBus(b Bus) {
+	mu.Lock()
+	bus = b
+	subs := pending
+	pending = nil
+	mu.Unlock()
+
+	for _, sub := range subs {
+		b.Subscribe(sub.eventType, sub.handler)
+	}
+}
+
+// Publish emits an event with a JSON-encoded payload. Failures are logged and never block the caller's work.
+func Publish(eventType string, payload any, metadata map[string]string) {
+	mu.RLock()
+	b := bus
+	mu.RUnlock()
+	if b == nil {
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", eventType, err)
+		return
+	}
+	if err := b.Publish(eventType, data, metadata); err != nil {
+		log.Printf("Failed to publish %s event: %v", eventType, err)
+	}
+}
+
+// Subscribe registers a handler for an event type. A type ending in ".*" matches every event with that prefix.
+func Subscribe(eventType string, handler Handler) {
+	mu.Lock()
+	b := bus
+	if b == nil {
+		pending = append(pending, subscription{eventType: eventType, handler: handler})
+	}
+	mu.Unlock()
+
+	if b != nil {
+		b.Subscribe(eventType, handler)
+	}
+}
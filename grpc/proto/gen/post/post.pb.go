@@ -0,0 +1,925 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.0
+// source: post.proto
+
+package post
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Post 代表一条论坛帖子。
+// 它的字段与 model.Post 中的字段一一对应。
+type Post struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	GuildId       string                 `protobuf:"bytes,2,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	TableName     string                 `protobuf:"bytes,3,opt,name=table_name,json=tableName,proto3" json:"table_name,omitempty"` // 帖子所在的数据表，随机查询时为空
+	Title         string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Author        string                 `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	AuthorId      string                 `protobuf:"bytes,6,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Content       string                 `protobuf:"bytes,7,opt,name=content,proto3" json:"content,omitempty"`
+	Tags          string                 `protobuf:"bytes,8,opt,name=tags,proto3" json:"tags,omitempty"` // 标签 ID 列表
+	MessageCount  int32                  `protobuf:"varint,9,opt,name=message_count,json=messageCount,proto3" json:"message_count,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	CoverImageUrl string                 `protobuf:"bytes,11,opt,name=cover_image_url,json=coverImageUrl,proto3" json:"cover_image_url,omitempty"`
+	Url           string                 `protobuf:"bytes,12,opt,name=url,proto3" json:"url,omitempty"` // 帖子的 Discord 链接
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Post) Reset() {
+	*x = Post{}
+	mi := &file_post_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Post) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Post) ProtoMessage() {}
+
+func (x *Post) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Post.ProtoReflect.Descriptor instead.
+func (*Post) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Post) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Post) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *Post) GetTableName() string {
+	if x != nil {
+		return x.TableName
+	}
+	return ""
+}
+
+func (x *Post) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Post) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *Post) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *Post) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Post) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
+func (x *Post) GetMessageCount() int32 {
+	if x != nil {
+		return x.MessageCount
+	}
+	return 0
+}
+
+func (x *Post) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Post) GetCoverImageUrl() string {
+	if x != nil {
+		return x.CoverImageUrl
+	}
+	return ""
+}
+
+func (x *Post) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// ListLatestPostsRequest 请求获取最新的帖子，支持分页。
+type ListLatestPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	TableNames    []string               `protobuf:"bytes,2,rep,name=table_names,json=tableNames,proto3" json:"table_names,omitempty"`
+	Page          int32                  `protobuf:"varint,3,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLatestPostsRequest) Reset() {
+	*x = ListLatestPostsRequest{}
+	mi := &file_post_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLatestPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLatestPostsRequest) ProtoMessage() {}
+
+func (x *ListLatestPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLatestPostsRequest.ProtoReflect.Descriptor instead.
+func (*ListLatestPostsRequest) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListLatestPostsRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *ListLatestPostsRequest) GetTableNames() []string {
+	if x != nil {
+		return x.TableNames
+	}
+	return nil
+}
+
+func (x *ListLatestPostsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListLatestPostsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// GetRandomPostsRequest 请求随机获取帖子。
+type GetRandomPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	TableNames    []string               `protobuf:"bytes,2,rep,name=table_names,json=tableNames,proto3" json:"table_names,omitempty"`
+	Count         int32                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	TagId         string                 `protobuf:"bytes,4,opt,name=tag_id,json=tagId,proto3" json:"tag_id,omitempty"`                   // 可选，只返回带有该标签的帖子
+	ExcludeTags   []string               `protobuf:"bytes,5,rep,name=exclude_tags,json=excludeTags,proto3" json:"exclude_tags,omitempty"` // 可选，排除带有这些标签的帖子
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRandomPostsRequest) Reset() {
+	*x = GetRandomPostsRequest{}
+	mi := &file_post_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRandomPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRandomPostsRequest) ProtoMessage() {}
+
+func (x *GetRandomPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRandomPostsRequest.ProtoReflect.Descriptor instead.
+func (*GetRandomPostsRequest) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRandomPostsRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *GetRandomPostsRequest) GetTableNames() []string {
+	if x != nil {
+		return x.TableNames
+	}
+	return nil
+}
+
+func (x *GetRandomPostsRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GetRandomPostsRequest) GetTagId() string {
+	if x != nil {
+		return x.TagId
+	}
+	return ""
+}
+
+func (x *GetRandomPostsRequest) GetExcludeTags() []string {
+	if x != nil {
+		return x.ExcludeTags
+	}
+	return nil
+}
+
+// GetRandomPostsResponse 返回随机获取的帖子。
+type GetRandomPostsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Posts         []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRandomPostsResponse) Reset() {
+	*x = GetRandomPostsResponse{}
+	mi := &file_post_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRandomPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRandomPostsResponse) ProtoMessage() {}
+
+func (x *GetRandomPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRandomPostsResponse.ProtoReflect.Descriptor instead.
+func (*GetRandomPostsResponse) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetRandomPostsResponse) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+// GetPostsByAuthorRequest 请求获取指定作者的帖子，支持分页。
+type GetPostsByAuthorRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	AuthorId      string                 `protobuf:"bytes,2,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	TableNames    []string               `protobuf:"bytes,3,rep,name=table_names,json=tableNames,proto3" json:"table_names,omitempty"`
+	Page          int32                  `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostsByAuthorRequest) Reset() {
+	*x = GetPostsByAuthorRequest{}
+	mi := &file_post_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostsByAuthorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostsByAuthorRequest) ProtoMessage() {}
+
+func (x *GetPostsByAuthorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostsByAuthorRequest.ProtoReflect.Descriptor instead.
+func (*GetPostsByAuthorRequest) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetPostsByAuthorRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *GetPostsByAuthorRequest) GetAuthorId() string {
+	if x != nil {
+		return x.AuthorId
+	}
+	return ""
+}
+
+func (x *GetPostsByAuthorRequest) GetTableNames() []string {
+	if x != nil {
+		return x.TableNames
+	}
+	return nil
+}
+
+func (x *GetPostsByAuthorRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *GetPostsByAuthorRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// SearchPostsRequest 请求搜索帖子，支持分页。
+type SearchPostsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	Query         string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	TableNames    []string               `protobuf:"bytes,3,rep,name=table_names,json=tableNames,proto3" json:"table_names,omitempty"`
+	Page          int32                  `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPostsRequest) Reset() {
+	*x = SearchPostsRequest{}
+	mi := &file_post_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPostsRequest) ProtoMessage() {}
+
+func (x *SearchPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPostsRequest.ProtoReflect.Descriptor instead.
+func (*SearchPostsRequest) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SearchPostsRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *SearchPostsRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchPostsRequest) GetTableNames() []string {
+	if x != nil {
+		return x.TableNames
+	}
+	return nil
+}
+
+func (x *SearchPostsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *SearchPostsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// ListPostsResponse 返回分页后的帖子列表。
+type ListPostsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Posts         []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	TotalRecords  int32                  `protobuf:"varint,2,opt,name=total_records,json=totalRecords,proto3" json:"total_records,omitempty"`
+	TotalPages    int32                  `protobuf:"varint,3,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	CurrentPage   int32                  `protobuf:"varint,4,opt,name=current_page,json=currentPage,proto3" json:"current_page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPostsResponse) Reset() {
+	*x = ListPostsResponse{}
+	mi := &file_post_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPostsResponse) ProtoMessage() {}
+
+func (x *ListPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPostsResponse.ProtoReflect.Descriptor instead.
+func (*ListPostsResponse) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListPostsResponse) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+func (x *ListPostsResponse) GetTotalRecords() int32 {
+	if x != nil {
+		return x.TotalRecords
+	}
+	return 0
+}
+
+func (x *ListPostsResponse) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+func (x *ListPostsResponse) GetCurrentPage() int32 {
+	if x != nil {
+		return x.CurrentPage
+	}
+	return 0
+}
+
+// GetGuildStatsRequest 请求获取服务器的帖子统计数据。
+type GetGuildStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGuildStatsRequest) Reset() {
+	*x = GetGuildStatsRequest{}
+	mi := &file_post_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGuildStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGuildStatsRequest) ProtoMessage() {}
+
+func (x *GetGuildStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGuildStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetGuildStatsRequest) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetGuildStatsRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+// TableStats 代表单个帖子表的统计数据。
+type TableStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TableName     string                 `protobuf:"bytes,1,opt,name=table_name,json=tableName,proto3" json:"table_name,omitempty"`
+	ChannelId     string                 `protobuf:"bytes,2,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"` // 该表对应的论坛频道，未配置时为空
+	TotalPosts    int32                  `protobuf:"varint,3,opt,name=total_posts,json=totalPosts,proto3" json:"total_posts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TableStats) Reset() {
+	*x = TableStats{}
+	mi := &file_post_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TableStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TableStats) ProtoMessage() {}
+
+func (x *TableStats) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TableStats.ProtoReflect.Descriptor instead.
+func (*TableStats) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TableStats) GetTableName() string {
+	if x != nil {
+		return x.TableName
+	}
+	return ""
+}
+
+func (x *TableStats) GetChannelId() string {
+	if x != nil {
+		return x.ChannelId
+	}
+	return ""
+}
+
+func (x *TableStats) GetTotalPosts() int32 {
+	if x != nil {
+		return x.TotalPosts
+	}
+	return 0
+}
+
+// GetGuildStatsResponse 返回服务器的帖子统计数据。
+// 时间段以每天凌晨 4 点为分界。
+type GetGuildStatsResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	GuildId            string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	TotalPosts         int32                  `protobuf:"varint,2,opt,name=total_posts,json=totalPosts,proto3" json:"total_posts,omitempty"`
+	TodayPosts         int32                  `protobuf:"varint,3,opt,name=today_posts,json=todayPosts,proto3" json:"today_posts,omitempty"`
+	YesterdayPosts     int32                  `protobuf:"varint,4,opt,name=yesterday_posts,json=yesterdayPosts,proto3" json:"yesterday_posts,omitempty"`
+	LastThreeDaysPosts int32                  `protobuf:"varint,5,opt,name=last_three_days_posts,json=lastThreeDaysPosts,proto3" json:"last_three_days_posts,omitempty"`
+	LastSevenDaysPosts int32                  `protobuf:"varint,6,opt,name=last_seven_days_posts,json=lastSevenDaysPosts,proto3" json:"last_seven_days_posts,omitempty"`
+	Tables             []*TableStats          `protobuf:"bytes,7,rep,name=tables,proto3" json:"tables,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *GetGuildStatsResponse) Reset() {
+	*x = GetGuildStatsResponse{}
+	mi := &file_post_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGuildStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGuildStatsResponse) ProtoMessage() {}
+
+func (x *GetGuildStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_post_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGuildStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetGuildStatsResponse) Descriptor() ([]byte, []int) {
+	return file_post_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetGuildStatsResponse) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *GetGuildStatsResponse) GetTotalPosts() int32 {
+	if x != nil {
+		return x.TotalPosts
+	}
+	return 0
+}
+
+func (x *GetGuildStatsResponse) GetTodayPosts() int32 {
+	if x != nil {
+		return x.TodayPosts
+	}
+	return 0
+}
+
+func (x *GetGuildStatsResponse) GetYesterdayPosts() int32 {
+	if x != nil {
+		return x.YesterdayPosts
+	}
+	return 0
+}
+
+func (x *GetGuildStatsResponse) GetLastThreeDaysPosts() int32 {
+	if x != nil {
+		return x.LastThreeDaysPosts
+	}
+	return 0
+}
+
+func (x *GetGuildStatsResponse) GetLastSevenDaysPosts() int32 {
+	if x != nil {
+		return x.LastSevenDaysPosts
+	}
+	return 0
+}
+
+func (x *GetGuildStatsResponse) GetTables() []*TableStats {
+	if x != nil {
+		return x.Tables
+	}
+	return nil
+}
+
+var File_post_proto protoreflect.FileDescriptor
+
+const file_post_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"post.proto\x12\x04post\"\xc6\x02\n" +
+	"\x04Post\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bguild_id\x18\x02 \x01(\tR\aguildId\x12\x1d\n" +
+	"\n" +
+	"table_name\x18\x03 \x01(\tR\ttableName\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12\x1b\n" +
+	"\tauthor_id\x18\x06 \x01(\tR\bauthorId\x12\x18\n" +
+	"\acontent\x18\a \x01(\tR\acontent\x12\x12\n" +
+	"\x04tags\x18\b \x01(\tR\x04tags\x12#\n" +
+	"\rmessage_count\x18\t \x01(\x05R\fmessageCount\x12\x1c\n" +
+	"\ttimestamp\x18\n" +
+	" \x01(\x03R\ttimestamp\x12&\n" +
+	"\x0fcover_image_url\x18\v \x01(\tR\rcoverImageUrl\x12\x10\n" +
+	"\x03url\x18\f \x01(\tR\x03url\"\x85\x01\n" +
+	"\x16ListLatestPostsRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1f\n" +
+	"\vtable_names\x18\x02 \x03(\tR\n" +
+	"tableNames\x12\x12\n" +
+	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\"\xa3\x01\n" +
+	"\x15GetRandomPostsRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1f\n" +
+	"\vtable_names\x18\x02 \x03(\tR\n" +
+	"tableNames\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12\x15\n" +
+	"\x06tag_id\x18\x04 \x01(\tR\x05tagId\x12!\n" +
+	"\fexclude_tags\x18\x05 \x03(\tR\vexcludeTags\":\n" +
+	"\x16GetRandomPostsResponse\x12 \n" +
+	"\x05posts\x18\x01 \x03(\v2\n" +
+	".post.PostR\x05posts\"\xa3\x01\n" +
+	"\x17GetPostsByAuthorRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1b\n" +
+	"\tauthor_id\x18\x02 \x01(\tR\bauthorId\x12\x1f\n" +
+	"\vtable_names\x18\x03 \x03(\tR\n" +
+	"tableNames\x12\x12\n" +
+	"\x04page\x18\x04 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\"\x97\x01\n" +
+	"\x12SearchPostsRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x1f\n" +
+	"\vtable_names\x18\x03 \x03(\tR\n" +
+	"tableNames\x12\x12\n" +
+	"\x04page\x18\x04 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\"\x9e\x01\n" +
+	"\x11ListPostsResponse\x12 \n" +
+	"\x05posts\x18\x01 \x03(\v2\n" +
+	".post.PostR\x05posts\x12#\n" +
+	"\rtotal_records\x18\x02 \x01(\x05R\ftotalRecords\x12\x1f\n" +
+	"\vtotal_pages\x18\x03 \x01(\x05R\n" +
+	"totalPages\x12!\n" +
+	"\fcurrent_page\x18\x04 \x01(\x05R\vcurrentPage\"1\n" +
+	"\x14GetGuildStatsRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\"k\n" +
+	"\n" +
+	"TableStats\x12\x1d\n" +
+	"\n" +
+	"table_name\x18\x01 \x01(\tR\ttableName\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\x02 \x01(\tR\tchannelId\x12\x1f\n" +
+	"\vtotal_posts\x18\x03 \x01(\x05R\n" +
+	"totalPosts\"\xad\x02\n" +
+	"\x15GetGuildStatsResponse\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x1f\n" +
+	"\vtotal_posts\x18\x02 \x01(\x05R\n" +
+	"totalPosts\x12\x1f\n" +
+	"\vtoday_posts\x18\x03 \x01(\x05R\n" +
+	"todayPosts\x12'\n" +
+	"\x0fyesterday_posts\x18\x04 \x01(\x05R\x0eyesterdayPosts\x121\n" +
+	"\x15last_three_days_posts\x18\x05 \x01(\x05R\x12lastThreeDaysPosts\x121\n" +
+	"\x15last_seven_days_posts\x18\x06 \x01(\x05R\x12lastSevenDaysPosts\x12(\n" +
+	"\x06tables\x18\a \x03(\v2\x10.post.TableStatsR\x06tables2\x86\x03\n" +
+	"\vPostService\x12J\n" +
+	"\x0fListLatestPosts\x12\x1c.post.ListLatestPostsRequest\x1a\x17.post.ListPostsResponse\"\x00\x12M\n" +
+	"\x0eGetRandomPosts\x12\x1b.post.GetRandomPostsRequest\x1a\x1c.post.GetRandomPostsResponse\"\x00\x12L\n" +
+	"\x10GetPostsByAuthor\x12\x1d.post.GetPostsByAuthorRequest\x1a\x17.post.ListPostsResponse\"\x00\x12B\n" +
+	"\vSearchPosts\x12\x18.post.SearchPostsRequest\x1a\x17.post.ListPostsResponse\"\x00\x12J\n" +
+	"\rGetGuildStats\x12\x1a.post.GetGuildStatsRequest\x1a\x1b.post.GetGuildStatsResponse\"\x00B!Z\x1fgithub.com/go-micro-protos/postb\x06proto3"
+
+var (
+	file_post_proto_rawDescOnce sync.Once
+	file_post_proto_rawDescData []byte
+)
+
+func file_post_proto_rawDescGZIP() []byte {
+	file_post_proto_rawDescOnce.Do(func() {
+		file_post_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_post_proto_rawDesc), len(file_post_proto_rawDesc)))
+	})
+	return file_post_proto_rawDescData
+}
+
+var file_post_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_post_proto_goTypes = []any{
+	(*Post)(nil),                    // 0: post.Post
+	(*ListLatestPostsRequest)(nil),  // 1: post.ListLatestPostsRequest
+	(*GetRandomPostsRequest)(nil),   // 2: post.GetRandomPostsRequest
+	(*GetRandomPostsResponse)(nil),  // 3: post.GetRandomPostsResponse
+	(*GetPostsByAuthorRequest)(nil), // 4: post.GetPostsByAuthorRequest
+	(*SearchPostsRequest)(nil),      // 5: post.SearchPostsRequest
+	(*ListPostsResponse)(nil),       // 6: post.ListPostsResponse
+	(*GetGuildStatsRequest)(nil),    // 7: post.GetGuildStatsRequest
+	(*TableStats)(nil),              // 8: post.TableStats
+	(*GetGuildStatsResponse)(nil),   // 9: post.GetGuildStatsResponse
+}
+var file_post_proto_depIdxs = []int32{
+	0, // 0: post.GetRandomPostsResponse.posts:type_name -> post.Post
+	0, // 1: post.ListPostsResponse.posts:type_name -> post.Post
+	8, // 2: post.GetGuildStatsResponse.tables:type_name -> post.TableStats
+	1, // 3: post.PostService.ListLatestPosts:input_type -> post.ListLatestPostsRequest
+	2, // 4: post.PostService.GetRandomPosts:input_type -> post.GetRandomPostsRequest
+	4, // 5: post.PostService.GetPostsByAuthor:input_type -> post.GetPostsByAuthorRequest
+	5, // 6: post.PostService.SearchPosts:input_type -> post.SearchPostsRequest
+	7, // 7: post.PostService.GetGuildStats:input_type -> post.GetGuildStatsRequest
+	6, // 8: post.PostService.ListLatestPosts:output_type -> post.ListPostsResponse
+	3, // 9: post.PostService.GetRandomPosts:output_type -> post.GetRandomPostsResponse
+	6, // 10: post.PostService.GetPostsByAuthor:output_type -> post.ListPostsResponse
+	6, // 11: post.PostService.SearchPosts:output_type -> post.ListPostsResponse
+	9, // 12: post.PostService.GetGuildStats:output_type -> post.GetGuildStatsResponse
+	8, // [8:13] is the sub-list for method output_type
+	3, // [3:8] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_post_proto_init() }
+func file_post_proto_init() {
+	if File_post_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_post_proto_rawDesc), len(file_post_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_post_proto_goTypes,
+		DependencyIndexes: file_post_proto_depIdxs,
+		MessageInfos:      file_post_proto_msgTypes,
+	}.Build()
+	File_post_proto = out.File
+	file_post_proto_goTypes = nil
+	file_post_proto_depIdxs = nil
+}
@@ -0,0 +1,289 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v6.33.0
+// source: post.proto
+
+package post
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PostService_ListLatestPosts_FullMethodName  = "/post.PostService/ListLatestPosts"
+	PostService_GetRandomPosts_FullMethodName   = "/post.PostService/GetRandomPosts"
+	PostService_GetPostsByAuthor_FullMethodName = "/post.PostService/GetPostsByAuthor"
+	PostService_SearchPosts_FullMethodName      = "/post.PostService/SearchPosts"
+	PostService_GetGuildStats_FullMethodName    = "/post.PostService/GetGuildStats"
+)
+
+// PostServiceClient is the client API for PostService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PostService 提供论坛帖子数据库的只读查询。
+// 未指定 table_names 时查询该服务器数据库中的所有帖子表。
+type PostServiceClient interface {
+	// ListLatestPosts 按发布时间倒序列出帖子，支持分页。
+	ListLatestPosts(ctx context.Context, in *ListLatestPostsRequest, opts ...grpc.CallOption) (*ListPostsResponse, error)
+	// GetRandomPosts 随机获取帖子，可按标签筛选和排除。
+	GetRandomPosts(ctx context.Context, in *GetRandomPostsRequest, opts ...grpc.CallOption) (*GetRandomPostsResponse, error)
+	// GetPostsByAuthor 按发布时间倒序列出指定作者的帖子，支持分页。
+	GetPostsByAuthor(ctx context.Context, in *GetPostsByAuthorRequest, opts ...grpc.CallOption) (*ListPostsResponse, error)
+	// SearchPosts 在帖子标题和内容中搜索关键词，支持分页。
+	SearchPosts(ctx context.Context, in *SearchPostsRequest, opts ...grpc.CallOption) (*ListPostsResponse, error)
+	// GetGuildStats 获取服务器的帖子统计数据。
+	GetGuildStats(ctx context.Context, in *GetGuildStatsRequest, opts ...grpc.CallOption) (*GetGuildStatsResponse, error)
+}
+
+type postServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPostServiceClient(cc grpc.ClientConnInterface) PostServiceClient {
+	return &postServiceClient{cc}
+}
+
+func (c *postServiceClient) ListLatestPosts(ctx context.Context, in *ListLatestPostsRequest, opts ...grpc.CallOption) (*ListPostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPostsResponse)
+	err := c.cc.Invoke(ctx, PostService_ListLatestPosts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postServiceClient) GetRandomPosts(ctx context.Context, in *GetRandomPostsRequest, opts ...grpc.CallOption) (*GetRandomPostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRandomPostsResponse)
+	err := c.cc.Invoke(ctx, PostService_GetRandomPosts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postServiceClient) GetPostsByAuthor(ctx context.Context, in *GetPostsByAuthorRequest, opts ...grpc.CallOption) (*ListPostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPostsResponse)
+	err := c.cc.Invoke(ctx, PostService_GetPostsByAuthor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postServiceClient) SearchPosts(ctx context.Context, in *SearchPostsRequest, opts ...grpc.CallOption) (*ListPostsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPostsResponse)
+	err := c.cc.Invoke(ctx, PostService_SearchPosts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *postServiceClient) GetGuildStats(ctx context.Context, in *GetGuildStatsRequest, opts ...grpc.CallOption) (*GetGuildStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetGuildStatsResponse)
+	err := c.cc.Invoke(ctx, PostService_GetGuildStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PostServiceServer is the server API for PostService service.
+// All implementations must embed UnimplementedPostServiceServer
+// for forward compatibility.
+//
+// PostService 提供论坛帖子数据库的只读查询。
+// 未指定 table_names 时查询该服务器数据库中的所有帖子表。
+type PostServiceServer interface {
+	// ListLatestPosts 按发布时间倒序列出帖子，支持分页。
+	ListLatestPosts(context.Context, *ListLatestPostsRequest) (*ListPostsResponse, error)
+	// GetRandomPosts 随机获取帖子，可按标签筛选和排除。
+	GetRandomPosts(context.Context, *GetRandomPostsRequest) (*GetRandomPostsResponse, error)
+	// GetPostsByAuthor 按发布时间倒序列出指定作者的帖子，支持分页。
+	GetPostsByAuthor(context.Context, *GetPostsByAuthorRequest) (*ListPostsResponse, error)
+	// SearchPosts 在帖子标题和内容中搜索关键词，支持分页。
+	SearchPosts(context.Context, *SearchPostsRequest) (*ListPostsResponse, error)
+	// GetGuildStats 获取服务器的帖子统计数据。
+	GetGuildStats(context.Context, *GetGuildStatsRequest) (*GetGuildStatsResponse, error)
+	mustEmbedUnimplementedPostServiceServer()
+}
+
+// UnimplementedPostServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPostServiceServer struct{}
+
+func (UnimplementedPostServiceServer) ListLatestPosts(context.Context, *ListLatestPostsRequest) (*ListPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLatestPosts not implemented")
+}
+func (UnimplementedPostServiceServer) GetRandomPosts(context.Context, *GetRandomPostsRequest) (*GetRandomPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRandomPosts not implemented")
+}
+func (UnimplementedPostServiceServer) GetPostsByAuthor(context.Context, *GetPostsByAuthorRequest) (*ListPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPostsByAuthor not implemented")
+}
+func (UnimplementedPostServiceServer) SearchPosts(context.Context, *SearchPostsRequest) (*ListPostsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SearchPosts not implemented")
+}
+func (UnimplementedPostServiceServer) GetGuildStats(context.Context, *GetGuildStatsRequest) (*GetGuildStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGuildStats not implemented")
+}
+func (UnimplementedPostServiceServer) mustEmbedUnimplementedPostServiceServer() {}
+func (UnimplementedPostServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafePostServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PostServiceServer will
+// result in compilation errors.
+type UnsafePostServiceServer interface {
+	mustEmbedUnimplementedPostServiceServer()
+}
+
+func RegisterPostServiceServer(s grpc.ServiceRegistrar, srv PostServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPostServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PostService_ServiceDesc, srv)
+}
+
+func _PostService_ListLatestPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLatestPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).ListLatestPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_ListLatestPosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).ListLatestPosts(ctx, req.(*ListLatestPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PostService_GetRandomPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRandomPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).GetRandomPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_GetRandomPosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).GetRandomPosts(ctx, req.(*GetRandomPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PostService_GetPostsByAuthor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPostsByAuthorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).GetPostsByAuthor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_GetPostsByAuthor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).GetPostsByAuthor(ctx, req.(*GetPostsByAuthorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PostService_SearchPosts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchPostsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).SearchPosts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_SearchPosts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).SearchPosts(ctx, req.(*SearchPostsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PostService_GetGuildStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGuildStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PostServiceServer).GetGuildStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PostService_GetGuildStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PostServiceServer).GetGuildStats(ctx, req.(*GetGuildStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PostService_ServiceDesc is the grpc.ServiceDesc for PostService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PostService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "post.PostService",
+	HandlerType: (*PostServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListLatestPosts",
+			Handler:    _PostService_ListLatestPosts_Handler,
+		},
+		{
+			MethodName: "GetRandomPosts",
+			Handler:    _PostService_GetRandomPosts_Handler,
+		},
+		{
+			MethodName: "GetPostsByAuthor",
+			Handler:    _PostService_GetPostsByAuthor_Handler,
+		},
+		{
+			MethodName: "SearchPosts",
+			Handler:    _PostService_SearchPosts_Handler,
+		},
+		{
+			MethodName: "GetGuildStats",
+			Handler:    _PostService_GetGuildStats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "post.proto",
+}
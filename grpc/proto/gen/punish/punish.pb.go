@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.36.8
+// 	protoc-gen-go v1.36.10
 // 	protoc        v6.33.0
 // source: punish.proto
 
@@ -409,6 +409,434 @@ func (x *GetPunishHistoryResponse) GetCurrentPage() int32 {
 	return 0
 }
 
+// ExportPunishHistoryRequest 请求导出用户的全部处罚历史记录。
+type ExportPunishHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	GuildId       string                 `protobuf:"bytes,2,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // 每条流消息包含的记录数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportPunishHistoryRequest) Reset() {
+	*x = ExportPunishHistoryRequest{}
+	mi := &file_punish_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportPunishHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportPunishHistoryRequest) ProtoMessage() {}
+
+func (x *ExportPunishHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportPunishHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ExportPunishHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExportPunishHistoryRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ExportPunishHistoryRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *ExportPunishHistoryRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// CreatePunishmentRequest 请求对用户执行处罚。
+type CreatePunishmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	ActionType    string                 `protobuf:"bytes,3,opt,name=action_type,json=actionType,proto3" json:"action_type,omitempty"` // 处罚类型，对应 punish_config 中的键
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	EvidenceLinks string                 `protobuf:"bytes,5,opt,name=evidence_links,json=evidenceLinks,proto3" json:"evidence_links,omitempty"` // 可选，证据消息链接，多个链接以空格分隔
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreatePunishmentRequest) Reset() {
+	*x = CreatePunishmentRequest{}
+	mi := &file_punish_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePunishmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePunishmentRequest) ProtoMessage() {}
+
+func (x *CreatePunishmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePunishmentRequest.ProtoReflect.Descriptor instead.
+func (*CreatePunishmentRequest) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreatePunishmentRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *CreatePunishmentRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *CreatePunishmentRequest) GetActionType() string {
+	if x != nil {
+		return x.ActionType
+	}
+	return ""
+}
+
+func (x *CreatePunishmentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *CreatePunishmentRequest) GetEvidenceLinks() string {
+	if x != nil {
+		return x.EvidenceLinks
+	}
+	return ""
+}
+
+// CreatePunishmentResponse 返回新建的处罚记录。
+type CreatePunishmentResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Punishment *Punishment            `protobuf:"bytes,1,opt,name=punishment,proto3" json:"punishment,omitempty"`
+	// 该处罚等级需要另一位管理员审批，处罚尚未执行。
+	PendingApproval bool `protobuf:"varint,2,opt,name=pending_approval,json=pendingApproval,proto3" json:"pending_approval,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CreatePunishmentResponse) Reset() {
+	*x = CreatePunishmentResponse{}
+	mi := &file_punish_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreatePunishmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreatePunishmentResponse) ProtoMessage() {}
+
+func (x *CreatePunishmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreatePunishmentResponse.ProtoReflect.Descriptor instead.
+func (*CreatePunishmentResponse) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreatePunishmentResponse) GetPunishment() *Punishment {
+	if x != nil {
+		return x.Punishment
+	}
+	return nil
+}
+
+func (x *CreatePunishmentResponse) GetPendingApproval() bool {
+	if x != nil {
+		return x.PendingApproval
+	}
+	return false
+}
+
+// RevokePunishmentRequest 请求撤销一条处罚。
+type RevokePunishmentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	PunishmentId  int64                  `protobuf:"varint,2,opt,name=punishment_id,json=punishmentId,proto3" json:"punishment_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePunishmentRequest) Reset() {
+	*x = RevokePunishmentRequest{}
+	mi := &file_punish_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePunishmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePunishmentRequest) ProtoMessage() {}
+
+func (x *RevokePunishmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePunishmentRequest.ProtoReflect.Descriptor instead.
+func (*RevokePunishmentRequest) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RevokePunishmentRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *RevokePunishmentRequest) GetPunishmentId() int64 {
+	if x != nil {
+		return x.PunishmentId
+	}
+	return 0
+}
+
+func (x *RevokePunishmentRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// RevokePunishmentResponse 返回被撤销的处罚记录。
+type RevokePunishmentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Punishment    *Punishment            `protobuf:"bytes,1,opt,name=punishment,proto3" json:"punishment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokePunishmentResponse) Reset() {
+	*x = RevokePunishmentResponse{}
+	mi := &file_punish_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokePunishmentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokePunishmentResponse) ProtoMessage() {}
+
+func (x *RevokePunishmentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokePunishmentResponse.ProtoReflect.Descriptor instead.
+func (*RevokePunishmentResponse) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RevokePunishmentResponse) GetPunishment() *Punishment {
+	if x != nil {
+		return x.Punishment
+	}
+	return nil
+}
+
+// ListActivePunishmentsRequest 请求获取服务器中生效的处罚，支持分页。
+type ListActivePunishmentsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	GuildId       string                 `protobuf:"bytes,1,opt,name=guild_id,json=guildId,proto3" json:"guild_id,omitempty"`
+	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListActivePunishmentsRequest) Reset() {
+	*x = ListActivePunishmentsRequest{}
+	mi := &file_punish_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListActivePunishmentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListActivePunishmentsRequest) ProtoMessage() {}
+
+func (x *ListActivePunishmentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListActivePunishmentsRequest.ProtoReflect.Descriptor instead.
+func (*ListActivePunishmentsRequest) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListActivePunishmentsRequest) GetGuildId() string {
+	if x != nil {
+		return x.GuildId
+	}
+	return ""
+}
+
+func (x *ListActivePunishmentsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListActivePunishmentsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// ListActivePunishmentsResponse 返回分页后的生效处罚列表。
+type ListActivePunishmentsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Punishments   []*Punishment          `protobuf:"bytes,1,rep,name=punishments,proto3" json:"punishments,omitempty"`
+	TotalRecords  int32                  `protobuf:"varint,2,opt,name=total_records,json=totalRecords,proto3" json:"total_records,omitempty"`
+	TotalPages    int32                  `protobuf:"varint,3,opt,name=total_pages,json=totalPages,proto3" json:"total_pages,omitempty"`
+	CurrentPage   int32                  `protobuf:"varint,4,opt,name=current_page,json=currentPage,proto3" json:"current_page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListActivePunishmentsResponse) Reset() {
+	*x = ListActivePunishmentsResponse{}
+	mi := &file_punish_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListActivePunishmentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListActivePunishmentsResponse) ProtoMessage() {}
+
+func (x *ListActivePunishmentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_punish_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListActivePunishmentsResponse.ProtoReflect.Descriptor instead.
+func (*ListActivePunishmentsResponse) Descriptor() ([]byte, []int) {
+	return file_punish_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListActivePunishmentsResponse) GetPunishments() []*Punishment {
+	if x != nil {
+		return x.Punishments
+	}
+	return nil
+}
+
+func (x *ListActivePunishmentsResponse) GetTotalRecords() int32 {
+	if x != nil {
+		return x.TotalRecords
+	}
+	return 0
+}
+
+func (x *ListActivePunishmentsResponse) GetTotalPages() int32 {
+	if x != nil {
+		return x.TotalPages
+	}
+	return 0
+}
+
+func (x *ListActivePunishmentsResponse) GetCurrentPage() int32 {
+	if x != nil {
+		return x.CurrentPage
+	}
+	return 0
+}
+
 var File_punish_proto protoreflect.FileDescriptor
 
 const file_punish_proto_rawDesc = "" +
@@ -448,10 +876,48 @@ const file_punish_proto_rawDesc = "" +
 	"\rtotal_records\x18\x02 \x01(\x05R\ftotalRecords\x12\x1f\n" +
 	"\vtotal_pages\x18\x03 \x01(\x05R\n" +
 	"totalPages\x12!\n" +
-	"\fcurrent_page\x18\x04 \x01(\x05R\vcurrentPage2\xbd\x01\n" +
+	"\fcurrent_page\x18\x04 \x01(\x05R\vcurrentPage\"m\n" +
+	"\x1aExportPunishHistoryRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x19\n" +
+	"\bguild_id\x18\x02 \x01(\tR\aguildId\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"\xad\x01\n" +
+	"\x17CreatePunishmentRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x1f\n" +
+	"\vaction_type\x18\x03 \x01(\tR\n" +
+	"actionType\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\x12%\n" +
+	"\x0eevidence_links\x18\x05 \x01(\tR\revidenceLinks\"y\n" +
+	"\x18CreatePunishmentResponse\x122\n" +
+	"\n" +
+	"punishment\x18\x01 \x01(\v2\x12.punish.PunishmentR\n" +
+	"punishment\x12)\n" +
+	"\x10pending_approval\x18\x02 \x01(\bR\x0fpendingApproval\"q\n" +
+	"\x17RevokePunishmentRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12#\n" +
+	"\rpunishment_id\x18\x02 \x01(\x03R\fpunishmentId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"N\n" +
+	"\x18RevokePunishmentResponse\x122\n" +
+	"\n" +
+	"punishment\x18\x01 \x01(\v2\x12.punish.PunishmentR\n" +
+	"punishment\"j\n" +
+	"\x1cListActivePunishmentsRequest\x12\x19\n" +
+	"\bguild_id\x18\x01 \x01(\tR\aguildId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"\xbe\x01\n" +
+	"\x1dListActivePunishmentsResponse\x124\n" +
+	"\vpunishments\x18\x01 \x03(\v2\x12.punish.PunishmentR\vpunishments\x12#\n" +
+	"\rtotal_records\x18\x02 \x01(\x05R\ftotalRecords\x12\x1f\n" +
+	"\vtotal_pages\x18\x03 \x01(\x05R\n" +
+	"totalPages\x12!\n" +
+	"\fcurrent_page\x18\x04 \x01(\x05R\vcurrentPage2\xb8\x04\n" +
 	"\fPunishServer\x12T\n" +
 	"\x0fGetPunishStatus\x12\x1e.punish.GetPunishStatusRequest\x1a\x1f.punish.GetPunishStatusResponse\"\x00\x12W\n" +
-	"\x10GetPunishHistory\x12\x1f.punish.GetPunishHistoryRequest\x1a .punish.GetPunishHistoryResponse\"\x00B#Z!github.com/go-micro-protos/punishb\x06proto3"
+	"\x10GetPunishHistory\x12\x1f.punish.GetPunishHistoryRequest\x1a .punish.GetPunishHistoryResponse\"\x00\x12_\n" +
+	"\x13ExportPunishHistory\x12\".punish.ExportPunishHistoryRequest\x1a .punish.GetPunishHistoryResponse\"\x000\x01\x12W\n" +
+	"\x10CreatePunishment\x12\x1f.punish.CreatePunishmentRequest\x1a .punish.CreatePunishmentResponse\"\x00\x12W\n" +
+	"\x10RevokePunishment\x12\x1f.punish.RevokePunishmentRequest\x1a .punish.RevokePunishmentResponse\"\x00\x12f\n" +
+	"\x15ListActivePunishments\x12$.punish.ListActivePunishmentsRequest\x1a%.punish.ListActivePunishmentsResponse\"\x00B#Z!github.com/go-micro-protos/punishb\x06proto3"
 
 var (
 	file_punish_proto_rawDescOnce sync.Once
@@ -465,26 +931,44 @@ func file_punish_proto_rawDescGZIP() []byte {
 	return file_punish_proto_rawDescData
 }
 
-var file_punish_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_punish_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_punish_proto_goTypes = []any{
-	(*Punishment)(nil),               // 0: punish.Punishment
-	(*GetPunishStatusRequest)(nil),   // 1: punish.GetPunishStatusRequest
-	(*GetPunishStatusResponse)(nil),  // 2: punish.GetPunishStatusResponse
-	(*GetPunishHistoryRequest)(nil),  // 3: punish.GetPunishHistoryRequest
-	(*GetPunishHistoryResponse)(nil), // 4: punish.GetPunishHistoryResponse
+	(*Punishment)(nil),                    // 0: punish.Punishment
+	(*GetPunishStatusRequest)(nil),        // 1: punish.GetPunishStatusRequest
+	(*GetPunishStatusResponse)(nil),       // 2: punish.GetPunishStatusResponse
+	(*GetPunishHistoryRequest)(nil),       // 3: punish.GetPunishHistoryRequest
+	(*GetPunishHistoryResponse)(nil),      // 4: punish.GetPunishHistoryResponse
+	(*ExportPunishHistoryRequest)(nil),    // 5: punish.ExportPunishHistoryRequest
+	(*CreatePunishmentRequest)(nil),       // 6: punish.CreatePunishmentRequest
+	(*CreatePunishmentResponse)(nil),      // 7: punish.CreatePunishmentResponse
+	(*RevokePunishmentRequest)(nil),       // 8: punish.RevokePunishmentRequest
+	(*RevokePunishmentResponse)(nil),      // 9: punish.RevokePunishmentResponse
+	(*ListActivePunishmentsRequest)(nil),  // 10: punish.ListActivePunishmentsRequest
+	(*ListActivePunishmentsResponse)(nil), // 11: punish.ListActivePunishmentsResponse
 }
 var file_punish_proto_depIdxs = []int32{
-	0, // 0: punish.GetPunishStatusResponse.active_punishments:type_name -> punish.Punishment
-	0, // 1: punish.GetPunishHistoryResponse.punishments:type_name -> punish.Punishment
-	1, // 2: punish.PunishServer.GetPunishStatus:input_type -> punish.GetPunishStatusRequest
-	3, // 3: punish.PunishServer.GetPunishHistory:input_type -> punish.GetPunishHistoryRequest
-	2, // 4: punish.PunishServer.GetPunishStatus:output_type -> punish.GetPunishStatusResponse
-	4, // 5: punish.PunishServer.GetPunishHistory:output_type -> punish.GetPunishHistoryResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: punish.GetPunishStatusResponse.active_punishments:type_name -> punish.Punishment
+	0,  // 1: punish.GetPunishHistoryResponse.punishments:type_name -> punish.Punishment
+	0,  // 2: punish.CreatePunishmentResponse.punishment:type_name -> punish.Punishment
+	0,  // 3: punish.RevokePunishmentResponse.punishment:type_name -> punish.Punishment
+	0,  // 4: punish.ListActivePunishmentsResponse.punishments:type_name -> punish.Punishment
+	1,  // 5: punish.PunishServer.GetPunishStatus:input_type -> punish.GetPunishStatusRequest
+	3,  // 6: punish.PunishServer.GetPunishHistory:input_type -> punish.GetPunishHistoryRequest
+	5,  // 7: punish.PunishServer.ExportPunishHistory:input_type -> punish.ExportPunishHistoryRequest
+	6,  // 8: punish.PunishServer.CreatePunishment:input_type -> punish.CreatePunishmentRequest
+	8,  // 9: punish.PunishServer.RevokePunishment:input_type -> punish.RevokePunishmentRequest
+	10, // 10: punish.PunishServer.ListActivePunishments:input_type -> punish.ListActivePunishmentsRequest
+	2,  // 11: punish.PunishServer.GetPunishStatus:output_type -> punish.GetPunishStatusResponse
+	4,  // 12: punish.PunishServer.GetPunishHistory:output_type -> punish.GetPunishHistoryResponse
+	4,  // 13: punish.PunishServer.ExportPunishHistory:output_type -> punish.GetPunishHistoryResponse
+	7,  // 14: punish.PunishServer.CreatePunishment:output_type -> punish.CreatePunishmentResponse
+	9,  // 15: punish.PunishServer.RevokePunishment:output_type -> punish.RevokePunishmentResponse
+	11, // 16: punish.PunishServer.ListActivePunishments:output_type -> punish.ListActivePunishmentsResponse
+	11, // [11:17] is the sub-list for method output_type
+	5,  // [5:11] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_punish_proto_init() }
@@ -498,7 +982,7 @@ func file_punish_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_punish_proto_rawDesc), len(file_punish_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,8 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PunishServer_GetPunishStatus_FullMethodName  = "/punish.PunishServer/GetPunishStatus"
-	PunishServer_GetPunishHistory_FullMethodName = "/punish.PunishServer/GetPunishHistory"
+	PunishServer_GetPunishStatus_FullMethodName       = "/punish.PunishServer/GetPunishStatus"
+	PunishServer_GetPunishHistory_FullMethodName      = "/punish.PunishServer/GetPunishHistory"
+	PunishServer_ExportPunishHistory_FullMethodName   = "/punish.PunishServer/ExportPunishHistory"
+	PunishServer_CreatePunishment_FullMethodName      = "/punish.PunishServer/CreatePunishment"
+	PunishServer_RevokePunishment_FullMethodName      = "/punish.PunishServer/RevokePunishment"
+	PunishServer_ListActivePunishments_FullMethodName = "/punish.PunishServer/ListActivePunishments"
 )
 
 // PunishServerClient is the client API for PunishServer service.
@@ -31,6 +35,15 @@ type PunishServerClient interface {
 	GetPunishStatus(ctx context.Context, in *GetPunishStatusRequest, opts ...grpc.CallOption) (*GetPunishStatusResponse, error)
 	// GetPunishHistory 获取用户的所有历史处罚记录。
 	GetPunishHistory(ctx context.Context, in *GetPunishHistoryRequest, opts ...grpc.CallOption) (*GetPunishHistoryResponse, error)
+	// ExportPunishHistory 以流的形式逐页导出用户的全部处罚历史记录，每条消息为一页。
+	// 适用于记录较多或证据较大、单次响应过大的用户。
+	ExportPunishHistory(ctx context.Context, in *ExportPunishHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetPunishHistoryResponse], error)
+	// CreatePunishment 对用户执行处罚，与 /punish 命令使用相同的升级、身份组和日志逻辑。
+	CreatePunishment(ctx context.Context, in *CreatePunishmentRequest, opts ...grpc.CallOption) (*CreatePunishmentResponse, error)
+	// RevokePunishment 撤销一条处罚，恢复用户的身份组并软删除记录。
+	RevokePunishment(ctx context.Context, in *RevokePunishmentRequest, opts ...grpc.CallOption) (*RevokePunishmentResponse, error)
+	// ListActivePunishments 列出服务器中当前生效的处罚，支持分页。
+	ListActivePunishments(ctx context.Context, in *ListActivePunishmentsRequest, opts ...grpc.CallOption) (*ListActivePunishmentsResponse, error)
 }
 
 type punishServerClient struct {
@@ -61,6 +74,55 @@ func (c *punishServerClient) GetPunishHistory(ctx context.Context, in *GetPunish
 	return out, nil
 }
 
+func (c *punishServerClient) ExportPunishHistory(ctx context.Context, in *ExportPunishHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetPunishHistoryResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PunishServer_ServiceDesc.Streams[0], PunishServer_ExportPunishHistory_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportPunishHistoryRequest, GetPunishHistoryResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PunishServer_ExportPunishHistoryClient = grpc.ServerStreamingClient[GetPunishHistoryResponse]
+
+func (c *punishServerClient) CreatePunishment(ctx context.Context, in *CreatePunishmentRequest, opts ...grpc.CallOption) (*CreatePunishmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreatePunishmentResponse)
+	err := c.cc.Invoke(ctx, PunishServer_CreatePunishment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *punishServerClient) RevokePunishment(ctx context.Context, in *RevokePunishmentRequest, opts ...grpc.CallOption) (*RevokePunishmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokePunishmentResponse)
+	err := c.cc.Invoke(ctx, PunishServer_RevokePunishment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *punishServerClient) ListActivePunishments(ctx context.Context, in *ListActivePunishmentsRequest, opts ...grpc.CallOption) (*ListActivePunishmentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListActivePunishmentsResponse)
+	err := c.cc.Invoke(ctx, PunishServer_ListActivePunishments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PunishServerServer is the server API for PunishServer service.
 // All implementations must embed UnimplementedPunishServerServer
 // for forward compatibility.
@@ -69,6 +131,15 @@ type PunishServerServer interface {
 	GetPunishStatus(context.Context, *GetPunishStatusRequest) (*GetPunishStatusResponse, error)
 	// GetPunishHistory 获取用户的所有历史处罚记录。
 	GetPunishHistory(context.Context, *GetPunishHistoryRequest) (*GetPunishHistoryResponse, error)
+	// ExportPunishHistory 以流的形式逐页导出用户的全部处罚历史记录，每条消息为一页。
+	// 适用于记录较多或证据较大、单次响应过大的用户。
+	ExportPunishHistory(*ExportPunishHistoryRequest, grpc.ServerStreamingServer[GetPunishHistoryResponse]) error
+	// CreatePunishment 对用户执行处罚，与 /punish 命令使用相同的升级、身份组和日志逻辑。
+	CreatePunishment(context.Context, *CreatePunishmentRequest) (*CreatePunishmentResponse, error)
+	// RevokePunishment 撤销一条处罚，恢复用户的身份组并软删除记录。
+	RevokePunishment(context.Context, *RevokePunishmentRequest) (*RevokePunishmentResponse, error)
+	// ListActivePunishments 列出服务器中当前生效的处罚，支持分页。
+	ListActivePunishments(context.Context, *ListActivePunishmentsRequest) (*ListActivePunishmentsResponse, error)
 	mustEmbedUnimplementedPunishServerServer()
 }
 
@@ -85,6 +156,18 @@ func (UnimplementedPunishServerServer) GetPunishStatus(context.Context, *GetPuni
 func (UnimplementedPunishServerServer) GetPunishHistory(context.Context, *GetPunishHistoryRequest) (*GetPunishHistoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPunishHistory not implemented")
 }
+func (UnimplementedPunishServerServer) ExportPunishHistory(*ExportPunishHistoryRequest, grpc.ServerStreamingServer[GetPunishHistoryResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportPunishHistory not implemented")
+}
+func (UnimplementedPunishServerServer) CreatePunishment(context.Context, *CreatePunishmentRequest) (*CreatePunishmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePunishment not implemented")
+}
+func (UnimplementedPunishServerServer) RevokePunishment(context.Context, *RevokePunishmentRequest) (*RevokePunishmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokePunishment not implemented")
+}
+func (UnimplementedPunishServerServer) ListActivePunishments(context.Context, *ListActivePunishmentsRequest) (*ListActivePunishmentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListActivePunishments not implemented")
+}
 func (UnimplementedPunishServerServer) mustEmbedUnimplementedPunishServerServer() {}
 func (UnimplementedPunishServerServer) testEmbeddedByValue()                      {}
 
@@ -142,6 +225,71 @@ func _PunishServer_GetPunishHistory_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PunishServer_ExportPunishHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportPunishHistoryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PunishServerServer).ExportPunishHistory(m, &grpc.GenericServerStream[ExportPunishHistoryRequest, GetPunishHistoryResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PunishServer_ExportPunishHistoryServer = grpc.ServerStreamingServer[GetPunishHistoryResponse]
+
+func _PunishServer_CreatePunishment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePunishmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PunishServerServer).CreatePunishment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PunishServer_CreatePunishment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PunishServerServer).CreatePunishment(ctx, req.(*CreatePunishmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PunishServer_RevokePunishment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokePunishmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PunishServerServer).RevokePunishment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PunishServer_RevokePunishment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PunishServerServer).RevokePunishment(ctx, req.(*RevokePunishmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PunishServer_ListActivePunishments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListActivePunishmentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PunishServerServer).ListActivePunishments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PunishServer_ListActivePunishments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PunishServerServer).ListActivePunishments(ctx, req.(*ListActivePunishmentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PunishServer_ServiceDesc is the grpc.ServiceDesc for PunishServer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,7 +305,25 @@ var PunishServer_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPunishHistory",
 			Handler:    _PunishServer_GetPunishHistory_Handler,
 		},
+		{
+			MethodName: "CreatePunishment",
+			Handler:    _PunishServer_CreatePunishment_Handler,
+		},
+		{
+			MethodName: "RevokePunishment",
+			Handler:    _PunishServer_RevokePunishment_Handler,
+		},
+		{
+			MethodName: "ListActivePunishments",
+			Handler:    _PunishServer_ListActivePunishments_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportPunishHistory",
+			Handler:       _PunishServer_ExportPunishHistory_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "punish.proto",
 }
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	postpb "newer_helper/grpc/proto/gen/post"
+	punishpb "newer_helper/grpc/proto/gen/punish"
+	"newer_helper/utils/trace"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadataKey is the request metadata key carrying the API key on the standalone listener.
+// "authorization: Bearer <key>" is accepted as well.
+const APIKeyMetadataKey = "x-api-key"
+
+// APIKeyScope is what an API key is allowed to do
+type APIKeyScope string
+
+const (
+	ScopeRead  APIKeyScope = "read"  // only the methods in readMethods
+	ScopeWrite APIKeyScope = "write" // all methods
+)
+
+// APIKey is a named key accepted by the standalone listener
+type APIKey struct {
+	Name  string // shown in logs instead of the key itself
+	Key   string
+	Scope APIKeyScope
+}
+
+// readMethods lists the methods a read-scoped key may call. Every other method, including ones added later,
+// requires a write-scoped key.
+var readMethods = map[string]bool{
+	punishpb.PunishServer_GetPunishStatus_FullMethodName:       true,
+	punishpb.PunishServer_GetPunishHistory_FullMethodName:      true,
+	punishpb.PunishServer_ExportPunishHistory_FullMethodName:   true,
+	punishpb.PunishServer_ListActivePunishments_FullMethodName: true,
+	postpb.PostService_ListLatestPosts_FullMethodName:          true,
+	postpb.PostService_GetRandomPosts_FullMethodName:           true,
+	postpb.PostService_GetPostsByAuthor_FullMethodName:         true,
+	postpb.PostService_SearchPosts_FullMethodName:              true,
+	postpb.PostService_GetGuildStats_FullMethodName:            true,
+}
+
+// apiKeyNameContextKey stores the name of the authenticated key in the request context for logging
+type apiKeyNameContextKey struct{}
+
+// ParseAPIKeys parses a comma-separated list of "name:key:scope" entries
+func ParseAPIKeys(value string) ([]APIKey, error) {
+	var keys []APIKey
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("entry %q is not in the form name:key:scope", entry)
+		}
+		scope := APIKeyScope(parts[2])
+		if scope != ScopeRead && scope != ScopeWrite {
+			return nil, fmt.Errorf("key %s has unknown scope %q, expected %q or %q", parts[0], parts[2], ScopeRead, ScopeWrite)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("duplicate key name %s", parts[0])
+		}
+		seen[parts[0]] = true
+		keys = append(keys, APIKey{Name: parts[0], Key: parts[1], Scope: scope})
+	}
+	return keys, nil
+}
+
+// apiKeyAuth checks API keys and their scopes on every request
+type apiKeyAuth struct {
+	keys []APIKey
+}
+
+func newAPIKeyAuth(keys []APIKey) *apiKeyAuth {
+	return &apiKeyAuth{keys: keys}
+}
+
+func (a *apiKeyAuth) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *apiKeyAuth) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorize finds the key presented in the request and checks its scope against the method
+func (a *apiKeyAuth) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	presented := apiKeyFromContext(ctx)
+	if presented == "" {
+		return ctx, status.Errorf(codes.Unauthenticated, "%s metadata is required", APIKeyMetadataKey)
+	}
+
+	var matched *APIKey
+	for i := range a.keys {
+		// Compare every key in constant time so the response time does not reveal which keys exist
+		if subtle.ConstantTimeCompare([]byte(a.keys[i].Key), []byte(presented)) == 1 {
+			matched = &a.keys[i]
+		}
+	}
+	if matched == nil {
+		return ctx, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	if keyName, ok := ctx.Value(apiKeyNameContextKey{}).(*string); ok {
+		*keyName = matched.Name
+	}
+
+	if matched.Scope != ScopeWrite && !readMethods[fullMethod] {
+		return ctx, status.Errorf(codes.PermissionDenied, "API key %s is read-only", matched.Name)
+	}
+	return ctx, nil
+}
+
+// apiKeyFromContext returns the API key from x-api-key or an "authorization: Bearer" header
+func apiKeyFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(APIKeyMetadataKey); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if key, ok := strings.CutPrefix(values[0], "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return ""
+}
+
+// loggingUnaryInterceptor logs every request with the caller, the key used, the outcome and the duration.
+// It also assigns the request its trace ID, taken from the x-trace-id metadata when present, and returns it
+// in the response header.
+func loggingUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	keyName := "-"
+	ctx = traceContext(ctx)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(trace.Header, trace.ID(ctx))); err != nil {
+		trace.Logf(ctx, "[gRPC] Failed to set trace header: %v", err)
+	}
+	resp, err := handler(context.WithValue(ctx, apiKeyNameContextKey{}, &keyName), req)
+	logRequest(ctx, info.FullMethod, keyName, start, err)
+	return resp, err
+}
+
+// loggingStreamInterceptor is loggingUnaryInterceptor for streaming methods
+func loggingStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	keyName := "-"
+	traceCtx := traceContext(ss.Context())
+	if err := ss.SetHeader(metadata.Pairs(trace.Header, trace.ID(traceCtx))); err != nil {
+		trace.Logf(traceCtx, "[gRPC] Failed to set trace header: %v", err)
+	}
+	ctx := context.WithValue(traceCtx, apiKeyNameContextKey{}, &keyName)
+	err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+	logRequest(traceCtx, info.FullMethod, keyName, start, err)
+	return err
+}
+
+// traceContext gives a request the trace ID from its x-trace-id metadata, or a new one
+func traceContext(ctx context.Context) context.Context {
+	value := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(trace.Header); len(values) > 0 {
+			value = values[0]
+		}
+	}
+	return trace.FromHeader(ctx, value)
+}
+
+func logRequest(ctx context.Context, fullMethod, keyName string, start time.Time, err error) {
+	remote := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+	trace.Logf(ctx, "[gRPC] %s from %s key=%s code=%s duration=%s", fullMethod, remote, keyName, status.Code(err), time.Since(start).Round(time.Millisecond))
+}
+
+// contextServerStream overrides the context of a server stream
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
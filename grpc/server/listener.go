@@ -0,0 +1,156 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// ListenerConfig configures the standalone gRPC listener, which serves the services directly instead of
+// through the gateway tunnel.
+type ListenerConfig struct {
+	Address      string   // address to listen on, e.g. ":50051"
+	CertFile     string   // server certificate, enables TLS together with KeyFile
+	KeyFile      string   // server private key
+	ClientCAFile string   // CA bundle for client certificates, enables mTLS
+	APIKeys      []APIKey // keys accepted by the auth interceptor
+}
+
+// LoadListenerConfig reads the listener configuration from the environment.
+// It returns nil if GRPC_LISTEN_ADDRESS is not set, meaning the listener is disabled.
+//
+//	GRPC_LISTEN_ADDRESS      address to listen on, e.g. ":50051"
+//	GRPC_TLS_CERT_FILE       server certificate (PEM)
+//	GRPC_TLS_KEY_FILE        server private key (PEM)
+//	GRPC_TLS_CLIENT_CA_FILE  optional, require client certificates signed by this CA (mTLS)
+//	GRPC_API_KEYS            comma-separated "name:key:scope" entries, scope is "read" or "write"
+func LoadListenerConfig() (*ListenerConfig, error) {
+	address := os.Getenv("GRPC_LISTEN_ADDRESS")
+	if address == "" {
+		return nil, nil
+	}
+
+	apiKeys, err := ParseAPIKeys(os.Getenv("GRPC_API_KEYS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GRPC_API_KEYS: %w", err)
+	}
+	if len(apiKeys) == 0 {
+		return nil, fmt.Errorf("GRPC_API_KEYS must contain at least one key when GRPC_LISTEN_ADDRESS is set")
+	}
+
+	cfg := &ListenerConfig{
+		Address:      address,
+		CertFile:     os.Getenv("GRPC_TLS_CERT_FILE"),
+		KeyFile:      os.Getenv("GRPC_TLS_KEY_FILE"),
+		ClientCAFile: os.Getenv("GRPC_TLS_CLIENT_CA_FILE"),
+		APIKeys:      apiKeys,
+	}
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, fmt.Errorf("GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE must be set together")
+	}
+	if cfg.ClientCAFile != "" && cfg.CertFile == "" {
+		return nil, fmt.Errorf("GRPC_TLS_CLIENT_CA_FILE requires GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+	}
+	return cfg, nil
+}
+
+// Listener serves the registered services on a local port. It embeds *grpc.Server, so the generated
+// RegisterXxxServer functions can be used on it directly, as with the gateway client.
+type Listener struct {
+	*grpc.Server
+	address string
+	tls     bool
+}
+
+// NewListener creates a listener with TLS, API-key authentication and request logging configured.
+// Services must be registered before Start.
+func NewListener(cfg *ListenerConfig) (*Listener, error) {
+	auth := newAPIKeyAuth(cfg.APIKeys)
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(loggingUnaryInterceptor, auth.unaryInterceptor),
+		grpc.ChainStreamInterceptor(loggingStreamInterceptor, auth.streamInterceptor),
+	}
+
+	if cfg.CertFile != "" {
+		tlsConfig, err := buildServerTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	return &Listener{
+		Server:  grpc.NewServer(opts...),
+		address: cfg.Address,
+		tls:     cfg.CertFile != "",
+	}, nil
+}
+
+// Start begins serving in the background
+func (l *Listener) Start() error {
+	lis, err := net.Listen("tcp", l.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", l.address, err)
+	}
+	if !l.tls {
+		log.Printf("Warning: gRPC listener on %s is running without TLS, API keys are sent in plain text. Set GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE to enable it.", l.address)
+	}
+
+	go func() {
+		if err := l.Serve(lis); err != nil {
+			log.Printf("gRPC listener on %s stopped: %v", l.address, err)
+		}
+	}()
+	return nil
+}
+
+// Stop waits for in-flight requests to finish, then closes the listener
+func (l *Listener) Stop() {
+	l.GracefulStop()
+}
+
+// buildServerTLSConfig loads the server certificate and, for mTLS, the client CA pool
+func buildServerTLSConfig(cfg *ListenerConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", cfg.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// TLSMode describes the transport security of a listener configuration for logging
+func (cfg *ListenerConfig) TLSMode() string {
+	switch {
+	case cfg.ClientCAFile != "":
+		return "mTLS"
+	case cfg.CertFile != "":
+		return "TLS"
+	default:
+		return "plaintext"
+	}
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"newer_helper/bot"
+	pb "newer_helper/grpc/proto/gen/post"
+	"newer_helper/model"
+	"newer_helper/utils/database"
+	"newer_helper/utils/trace"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// databaseMappingPath is the guild to post database mapping generated from task_config.json on startup
+const databaseMappingPath = "data/databaseMapping.json"
+
+// errGuildNotFound is returned when a guild has no post database configured
+var errGuildNotFound = errors.New("guild has no post database")
+
+// PostServer implements the PostService gRPC service over the forum post databases
+type PostServer struct {
+	pb.UnimplementedPostServiceServer
+	bot *bot.Bot // provides the current config, which is reloaded at runtime
+}
+
+// NewPostServer creates a new PostServer instance
+func NewPostServer(b *bot.Bot) *PostServer {
+	return &PostServer{bot: b}
+}
+
+// ListLatestPosts lists the newest posts of a guild with pagination
+func (s *PostServer) ListLatestPosts(ctx context.Context, req *pb.ListLatestPostsRequest) (*pb.ListPostsResponse, error) {
+	trace.Logf(ctx, "[PostServer] ListLatestPosts called for guild_id=%s, page=%d, page_size=%d", req.GuildId, req.Page, req.PageSize)
+
+	if req.GuildId == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id is required")
+	}
+
+	db, tableNames, err := s.openGuildDB(req.GuildId, req.TableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	posts, total, err := database.GetLatestPostsPage(db, tableNames, int(pageSize), int((page-1)*pageSize))
+	if err != nil {
+		trace.Logf(ctx, "[PostServer] Error querying latest posts: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to query posts: %v", err)
+	}
+
+	return buildListPostsResponse(req.GuildId, posts, total, page, pageSize), nil
+}
+
+// GetRandomPosts returns random posts of a guild, optionally filtered by tag
+func (s *PostServer) GetRandomPosts(ctx context.Context, req *pb.GetRandomPostsRequest) (*pb.GetRandomPostsResponse, error) {
+	trace.Logf(ctx, "[PostServer] GetRandomPosts called for guild_id=%s, count=%d, tag_id=%s", req.GuildId, req.Count, req.TagId)
+
+	if req.GuildId == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id is required")
+	}
+
+	count := req.Count
+	if count <= 0 {
+		count = 1
+	}
+	if count > 100 {
+		count = 100 // same cap as the max page size
+	}
+
+	db, tableNames, err := s.openGuildDB(req.GuildId, req.TableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var posts []model.Post
+	if req.TagId != "" || len(req.ExcludeTags) > 0 {
+		posts, err = database.GetRandomPostsByTagFromMultipleTables(db, tableNames, req.TagId, int(count), req.ExcludeTags)
+	} else {
+		posts, err = database.GetRandomPostsFromMultipleTables(db, tableNames, int(count))
+	}
+	if err != nil {
+		trace.Logf(ctx, "[PostServer] Error querying random posts: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to query posts: %v", err)
+	}
+
+	return &pb.GetRandomPostsResponse{
+		Posts: convertToProtoPosts(req.GuildId, posts),
+	}, nil
+}
+
+// GetPostsByAuthor lists the posts of an author in a guild with pagination
+func (s *PostServer) GetPostsByAuthor(ctx context.Context, req *pb.GetPostsByAuthorRequest) (*pb.ListPostsResponse, error) {
+	trace.Logf(ctx, "[PostServer] GetPostsByAuthor called for guild_id=%s, author_id=%s, page=%d, page_size=%d", req.GuildId, req.AuthorId, req.Page, req.PageSize)
+
+	if req.GuildId == "" || req.AuthorId == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id and author_id are required")
+	}
+
+	db, tableNames, err := s.openGuildDB(req.GuildId, req.TableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	posts, total, err := database.GetPostsByAuthorPage(db, tableNames, req.AuthorId, int(pageSize), int((page-1)*pageSize))
+	if err != nil {
+		trace.Logf(ctx, "[PostServer] Error querying posts by author: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to query posts: %v", err)
+	}
+
+	return buildListPostsResponse(req.GuildId, posts, total, page, pageSize), nil
+}
+
+// SearchPosts searches post titles and content of a guild with pagination
+func (s *PostServer) SearchPosts(ctx context.Context, req *pb.SearchPostsRequest) (*pb.ListPostsResponse, error) {
+	trace.Logf(ctx, "[PostServer] SearchPosts called for guild_id=%s, query=%q, page=%d, page_size=%d", req.GuildId, req.Query, req.Page, req.PageSize)
+
+	query := strings.TrimSpace(req.Query)
+	if req.GuildId == "" || query == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id and query are required")
+	}
+
+	db, tableNames, err := s.openGuildDB(req.GuildId, req.TableNames)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	page, pageSize := normalizePage(req.Page, req.PageSize)
+	posts, total, err := database.SearchPostsPage(db, tableNames, query, int(pageSize), int((page-1)*pageSize))
+	if err != nil {
+		trace.Logf(ctx, "[PostServer] Error searching posts: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to search posts: %v", err)
+	}
+
+	return buildListPostsResponse(req.GuildId, posts, total, page, pageSize), nil
+}
+
+// GetGuildStats returns the post counts of a guild, overall and per table
+func (s *PostServer) GetGuildStats(ctx context.Context, req *pb.GetGuildStatsRequest) (*pb.GetGuildStatsResponse, error) {
+	trace.Logf(ctx, "[PostServer] GetGuildStats called for guild_id=%s", req.GuildId)
+
+	if req.GuildId == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id is required")
+	}
+
+	mapping, err := s.loadGuildMapping(req.GuildId)
+	if err != nil {
+		if errors.Is(err, errGuildNotFound) {
+			return nil, status.Errorf(codes.NotFound, "guild %s has no post database", req.GuildId)
+		}
+		trace.Logf(ctx, "[PostServer] Error loading database mapping: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to load database mapping: %v", err)
+	}
+
+	stats, err := database.GetServerStats(req.GuildId, map[string]model.GuildMapping{req.GuildId: mapping}, s.bot.GetConfig().ThreadConfig)
+	if err != nil {
+		trace.Logf(ctx, "[PostServer] Error getting stats for guild %s: %v", req.GuildId, err)
+		return nil, status.Errorf(codes.Internal, "failed to get guild stats: %v", err)
+	}
+
+	db, err := database.InitDB(stats.DatabasePath)
+	if err != nil {
+		trace.Logf(ctx, "[PostServer] Error connecting to post DB %s: %v", stats.DatabasePath, err)
+		return nil, status.Errorf(codes.Internal, "failed to connect to the post database: %v", err)
+	}
+	defer db.Close()
+
+	tables := make([]*pb.TableStats, 0, len(stats.TableNames))
+	for _, tableName := range stats.TableNames {
+		count, err := database.GetTotalPostCountFromTables(db, []string{tableName})
+		if err != nil {
+			trace.Logf(ctx, "[PostServer] Error counting posts in table %s: %v", tableName, err)
+			continue
+		}
+		tables = append(tables, &pb.TableStats{
+			TableName:  tableName,
+			ChannelId:  s.tableChannelID(req.GuildId, tableName),
+			TotalPosts: int32(count),
+		})
+	}
+
+	return &pb.GetGuildStatsResponse{
+		GuildId:            req.GuildId,
+		TotalPosts:         int32(stats.TotalPosts),
+		TodayPosts:         int32(stats.TodayPosts),
+		YesterdayPosts:     int32(stats.YesterdayPosts),
+		LastThreeDaysPosts: int32(stats.Last3DaysPosts),
+		LastSevenDaysPosts: int32(stats.Last7DaysPosts),
+		Tables:             tables,
+	}, nil
+}
+
+// loadGuildMapping returns the post database of a guild from databaseMapping.json, falling back to thread_config
+func (s *PostServer) loadGuildMapping(guildID string) (model.GuildMapping, error) {
+	// databaseMapping.json uses a different key for the table mapping than model.GuildMapping
+	var fileMapping map[string]struct {
+		Database                 string            `json:"database"`
+		DataBaseTableNameMapping map[string]string `json:"dataBaseTableNameMapping"`
+	}
+	data, err := os.ReadFile(databaseMappingPath)
+	if err != nil && !os.IsNotExist(err) {
+		return model.GuildMapping{}, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &fileMapping); err != nil {
+			return model.GuildMapping{}, err
+		}
+	}
+
+	if entry, ok := fileMapping[guildID]; ok && entry.Database != "" {
+		return model.GuildMapping{
+			GuildsID:                 guildID,
+			Database:                 entry.Database,
+			DataBaseTableNameMapping: entry.DataBaseTableNameMapping,
+		}, nil
+	}
+	if threadConfig, ok := s.bot.GetConfig().ThreadConfig[guildID]; ok && threadConfig.Database != "" {
+		mapping := model.GuildMapping{GuildsID: guildID, Database: threadConfig.Database}
+		if threadConfig.TableName != "" && threadConfig.TableName != "all_posts" {
+			mapping.DataBaseTableNameMapping = map[string]string{threadConfig.TableName: threadConfig.TableName}
+		}
+		return mapping, nil
+	}
+	return model.GuildMapping{}, errGuildNotFound
+}
+
+// openGuildDB opens the post database of a guild and resolves the tables to query.
+// Requested table names must exist in the database, since table names cannot be bound as query parameters.
+// Without a request, the tables from the mapping are used, or every table if the mapping lists none.
+func (s *PostServer) openGuildDB(guildID string, requested []string) (*sql.DB, []string, error) {
+	mapping, err := s.loadGuildMapping(guildID)
+	if err != nil {
+		if errors.Is(err, errGuildNotFound) {
+			return nil, nil, status.Errorf(codes.NotFound, "guild %s has no post database", guildID)
+		}
+		log.Printf("[PostServer] Error loading database mapping: %v", err)
+		return nil, nil, status.Errorf(codes.Internal, "failed to load database mapping: %v", err)
+	}
+
+	db, err := database.InitDB(mapping.Database)
+	if err != nil {
+		log.Printf("[PostServer] Error connecting to post DB %s: %v", mapping.Database, err)
+		return nil, nil, status.Errorf(codes.Internal, "failed to connect to the post database: %v", err)
+	}
+
+	existingTables, err := database.GetAllTableNames(db)
+	if err != nil {
+		db.Close()
+		log.Printf("[PostServer] Error listing tables in %s: %v", mapping.Database, err)
+		return nil, nil, status.Errorf(codes.Internal, "failed to list post tables: %v", err)
+	}
+
+	var tableNames []string
+	if len(requested) > 0 {
+		for _, tableName := range requested {
+			if !database.Contains(existingTables, tableName) {
+				db.Close()
+				return nil, nil, status.Errorf(codes.InvalidArgument, "unknown table %q", tableName)
+			}
+			tableNames = append(tableNames, tableName)
+		}
+		return db, tableNames, nil
+	}
+
+	for tableName := range mapping.DataBaseTableNameMapping {
+		if database.Contains(existingTables, tableName) {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+	if len(tableNames) == 0 {
+		tableNames = existingTables
+	}
+	return db, tableNames, nil
+}
+
+// tableChannelID returns the forum channel a post table was scraped from, based on task_config.json
+func (s *PostServer) tableChannelID(guildID, tableName string) string {
+	guildTasks, ok := s.bot.GetConfig().TaskConfig[guildID]
+	if !ok {
+		return ""
+	}
+	for name, channelTask := range guildTasks.Data {
+		if len(channelTask.ChannelID) >= 4 && name+"_"+channelTask.ChannelID[len(channelTask.ChannelID)-4:] == tableName {
+			return channelTask.ChannelID
+		}
+	}
+	return ""
+}
+
+// normalizePage applies the default and maximum page size and the first page
+func normalizePage(page, pageSize int32) (int32, int32) {
+	if pageSize <= 0 {
+		pageSize = 10 // default page size
+	}
+	if pageSize > 100 {
+		pageSize = 100 // max page size
+	}
+	if page <= 0 {
+		page = 1
+	}
+	return page, pageSize
+}
+
+// buildListPostsResponse wraps a page of posts with its pagination info
+func buildListPostsResponse(guildID string, posts []model.Post, total int, page, pageSize int32) *pb.ListPostsResponse {
+	return &pb.ListPostsResponse{
+		Posts:        convertToProtoPosts(guildID, posts),
+		TotalRecords: int32(total),
+		TotalPages:   int32(math.Ceil(float64(total) / float64(pageSize))),
+		CurrentPage:  page,
+	}
+}
+
+// convertToProtoPosts converts model.Post values to protobuf Posts
+func convertToProtoPosts(guildID string, posts []model.Post) []*pb.Post {
+	protoPosts := make([]*pb.Post, 0, len(posts))
+	for _, post := range posts {
+		protoPosts = append(protoPosts, &pb.Post{
+			Id:            post.ID,
+			GuildId:       guildID,
+			TableName:     post.TableName,
+			Title:         post.Title,
+			Author:        post.Author,
+			AuthorId:      post.AuthorID,
+			Content:       post.Content,
+			Tags:          post.Tags,
+			MessageCount:  int32(post.MessageCount),
+			Timestamp:     post.Timestamp,
+			CoverImageUrl: post.CoverImageURL,
+			Url:           post.URL(guildID),
+		})
+	}
+	return protoPosts
+}
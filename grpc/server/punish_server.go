@@ -2,11 +2,12 @@ package server
 
 import (
 	"context"
-	"log"
 	"math"
+	"newer_helper/bot"
 	pb "newer_helper/grpc/proto/gen/punish"
 	"newer_helper/model"
 	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
 
 	"github.com/jmoiron/sqlx"
 	"google.golang.org/grpc/codes"
@@ -17,18 +18,20 @@ import (
 type PunishServer struct {
 	pb.UnimplementedPunishServerServer
 	punishDB *sqlx.DB
+	bot      *bot.Bot // used by write operations to act in Discord
 }
 
 // NewPunishServer creates a new PunishServer instance
-func NewPunishServer(punishDB *sqlx.DB) *PunishServer {
+func NewPunishServer(punishDB *sqlx.DB, b *bot.Bot) *PunishServer {
 	return &PunishServer{
 		punishDB: punishDB,
+		bot:      b,
 	}
 }
 
 // GetPunishStatus retrieves the current punishment status for a user
 func (s *PunishServer) GetPunishStatus(ctx context.Context, req *pb.GetPunishStatusRequest) (*pb.GetPunishStatusResponse, error) {
-	log.Printf("[PunishServer] GetPunishStatus called for user_id=%s, guild_id=%s", req.UserId, req.GuildId)
+	trace.Logf(ctx, "[PunishServer] GetPunishStatus called for user_id=%s, guild_id=%s", req.UserId, req.GuildId)
 
 	if req.UserId == "" || req.GuildId == "" {
 		return nil, status.Error(codes.InvalidArgument, "user_id and guild_id are required")
@@ -38,19 +41,20 @@ func (s *PunishServer) GetPunishStatus(ctx context.Context, req *pb.GetPunishSta
 	query := `SELECT * FROM punishments
 			  WHERE user_id = ?
 			  AND guild_id = ?
-			  AND punishment_status = 'active'
+			  AND punishment_status IN ('active', 'appealed', 'appeal_rejected')
+			  AND deleted_at = 0
 			  ORDER BY timestamp DESC`
 
 	var records []model.PunishmentRecord
 	err := s.punishDB.Select(&records, query, req.UserId, req.GuildId)
 	if err != nil {
-		log.Printf("[PunishServer] Error querying active punishments: %v", err)
+		trace.Logf(ctx, "[PunishServer] Error querying active punishments: %v", err)
 		return nil, status.Errorf(codes.Internal, "failed to query punishments: %v", err)
 	}
 
 	// Handle case where user has no punishments
 	if len(records) == 0 {
-		log.Printf("[PunishServer] No active punishments found for user %s in guild %s", req.UserId, req.GuildId)
+		trace.Logf(ctx, "[PunishServer] No active punishments found for user %s in guild %s", req.UserId, req.GuildId)
 		return &pb.GetPunishStatusResponse{
 			Status:            "none",
 			ActivePunishments: []*pb.Punishment{},
@@ -63,7 +67,7 @@ func (s *PunishServer) GetPunishStatus(ctx context.Context, req *pb.GetPunishSta
 		protoPunishments = append(protoPunishments, convertToProtoPunishment(&record))
 	}
 
-	log.Printf("[PunishServer] Found %d active punishments for user %s", len(records), req.UserId)
+	trace.Logf(ctx, "[PunishServer] Found %d active punishments for user %s", len(records), req.UserId)
 
 	return &pb.GetPunishStatusResponse{
 		Status:            "active",
@@ -73,7 +77,7 @@ func (s *PunishServer) GetPunishStatus(ctx context.Context, req *pb.GetPunishSta
 
 // GetPunishHistory retrieves the punishment history for a user with pagination
 func (s *PunishServer) GetPunishHistory(ctx context.Context, req *pb.GetPunishHistoryRequest) (*pb.GetPunishHistoryResponse, error) {
-	log.Printf("[PunishServer] GetPunishHistory called for user_id=%s, guild_id=%s, page=%d, page_size=%d",
+	trace.Logf(ctx, "[PunishServer] GetPunishHistory called for user_id=%s, guild_id=%s, page=%d, page_size=%d",
 		req.UserId, req.GuildId, req.Page, req.PageSize)
 
 	if req.UserId == "" || req.GuildId == "" {
@@ -96,25 +100,17 @@ func (s *PunishServer) GetPunishHistory(ctx context.Context, req *pb.GetPunishHi
 	}
 
 	// Get all records for the user in this guild
-	allRecords, err := punishments_db.GetPunishmentRecordsByUserID(s.punishDB, req.UserId, nil)
+	guildRecords, err := s.guildPunishmentHistory(req.UserId, req.GuildId)
 	if err != nil {
-		log.Printf("[PunishServer] Error querying punishment history: %v", err)
+		trace.Logf(ctx, "[PunishServer] Error querying punishment history: %v", err)
 		return nil, status.Errorf(codes.Internal, "failed to query punishment history: %v", err)
 	}
 
-	// Filter by guild_id
-	var guildRecords []model.PunishmentRecord
-	for _, record := range allRecords {
-		if record.GuildID == req.GuildId {
-			guildRecords = append(guildRecords, record)
-		}
-	}
-
 	totalRecords := len(guildRecords)
 
 	// Handle case where user has no punishment history
 	if totalRecords == 0 {
-		log.Printf("[PunishServer] No punishment history found for user %s in guild %s", req.UserId, req.GuildId)
+		trace.Logf(ctx, "[PunishServer] No punishment history found for user %s in guild %s", req.UserId, req.GuildId)
 		return &pb.GetPunishHistoryResponse{
 			Punishments:  []*pb.Punishment{},
 			TotalRecords: 0,
@@ -129,7 +125,7 @@ func (s *PunishServer) GetPunishHistory(ctx context.Context, req *pb.GetPunishHi
 
 	// Handle case where page is out of range
 	if offset >= int32(totalRecords) {
-		log.Printf("[PunishServer] Page %d is out of range (total pages: %d)", page, totalPages)
+		trace.Logf(ctx, "[PunishServer] Page %d is out of range (total pages: %d)", page, totalPages)
 		return &pb.GetPunishHistoryResponse{
 			Punishments:  []*pb.Punishment{},
 			TotalRecords: int32(totalRecords),
@@ -151,7 +147,7 @@ func (s *PunishServer) GetPunishHistory(ctx context.Context, req *pb.GetPunishHi
 		protoPunishments = append(protoPunishments, convertToProtoPunishment(&record))
 	}
 
-	log.Printf("[PunishServer] Returning page %d/%d with %d records (total: %d)",
+	trace.Logf(ctx, "[PunishServer] Returning page %d/%d with %d records (total: %d)",
 		page, totalPages, len(pageRecords), totalRecords)
 
 	return &pb.GetPunishHistoryResponse{
@@ -162,6 +158,79 @@ func (s *PunishServer) GetPunishHistory(ctx context.Context, req *pb.GetPunishHi
 	}, nil
 }
 
+// ExportPunishHistory streams the full punishment history of a user, one page per message
+func (s *PunishServer) ExportPunishHistory(req *pb.ExportPunishHistoryRequest, stream pb.PunishServer_ExportPunishHistoryServer) error {
+	ctx := stream.Context()
+	trace.Logf(ctx, "[PunishServer] ExportPunishHistory called for user_id=%s, guild_id=%s, page_size=%d", req.UserId, req.GuildId, req.PageSize)
+
+	if req.UserId == "" || req.GuildId == "" {
+		return status.Error(codes.InvalidArgument, "user_id and guild_id are required")
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10 // default page size
+	}
+	if pageSize > 100 {
+		pageSize = 100 // max page size
+	}
+
+	guildRecords, err := s.guildPunishmentHistory(req.UserId, req.GuildId)
+	if err != nil {
+		trace.Logf(ctx, "[PunishServer] Error querying punishment history: %v", err)
+		return status.Errorf(codes.Internal, "failed to query punishment history: %v", err)
+	}
+
+	totalRecords := int32(len(guildRecords))
+	totalPages := int32(math.Ceil(float64(totalRecords) / float64(pageSize)))
+	for page := int32(1); page <= totalPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		end := page * pageSize
+		if end > totalRecords {
+			end = totalRecords
+		}
+		pageRecords := guildRecords[(page-1)*pageSize : end]
+
+		protoPunishments := make([]*pb.Punishment, 0, len(pageRecords))
+		for _, record := range pageRecords {
+			protoPunishments = append(protoPunishments, convertToProtoPunishment(&record))
+		}
+
+		if err := stream.Send(&pb.GetPunishHistoryResponse{
+			Punishments:  protoPunishments,
+			TotalRecords: totalRecords,
+			TotalPages:   totalPages,
+			CurrentPage:  page,
+		}); err != nil {
+			trace.Logf(ctx, "[PunishServer] Error sending history page %d/%d: %v", page, totalPages, err)
+			return err
+		}
+	}
+
+	trace.Logf(ctx, "[PunishServer] Exported %d records in %d pages for user %s", totalRecords, totalPages, req.UserId)
+	return nil
+}
+
+// guildPunishmentHistory returns every punishment record of a user in a guild
+func (s *PunishServer) guildPunishmentHistory(userID, guildID string) ([]model.PunishmentRecord, error) {
+	allRecords, err := punishments_db.GetPunishmentRecordsByUserID(s.punishDB, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter by guild_id
+	var guildRecords []model.PunishmentRecord
+	for _, record := range allRecords {
+		if record.GuildID == guildID {
+			guildRecords = append(guildRecords, record)
+		}
+	}
+	return guildRecords, nil
+}
+
 // convertToProtoPunishment converts a model.PunishmentRecord to a proto Punishment
 func convertToProtoPunishment(record *model.PunishmentRecord) *pb.Punishment {
 	return &pb.Punishment{
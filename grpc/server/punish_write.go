@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	pb "newer_helper/grpc/proto/gen/punish"
+	"newer_helper/handlers/punish"
+	punish_admin "newer_helper/handlers/punish/admin"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AdminIDMetadataKey is the request metadata key carrying the Discord ID of the admin performing a write operation.
+const AdminIDMetadataKey = "x-admin-id"
+
+// CreatePunishment punishes a user through the same logic as the /punish command
+func (s *PunishServer) CreatePunishment(ctx context.Context, req *pb.CreatePunishmentRequest) (*pb.CreatePunishmentResponse, error) {
+	trace.Logf(ctx, "[PunishServer] CreatePunishment called for user_id=%s, guild_id=%s, action_type=%s", req.UserId, req.GuildId, req.ActionType)
+
+	if req.UserId == "" || req.GuildId == "" || req.ActionType == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id, guild_id and action_type are required")
+	}
+
+	admin, err := s.authorizeAdmin(ctx, req.GuildId)
+	if err != nil {
+		return nil, err
+	}
+
+	session := s.bot.GetSession()
+	targetUser, err := session.User(req.UserId)
+	if err != nil {
+		trace.Logf(ctx, "[PunishServer] Error fetching target user %s: %v", req.UserId, err)
+		return nil, status.Errorf(codes.NotFound, "user %s not found", req.UserId)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	result, err := punish.ExecutePunishment(ctx, session, s.bot, punish.PunishmentRequest{
+		GuildID:       req.GuildId,
+		AdminID:       admin.User.ID,
+		AdminUsername: admin.User.Username,
+		TargetUser:    targetUser,
+		Action:        req.ActionType,
+		Reason:        reason,
+		EvidenceLinks: req.EvidenceLinks,
+	})
+	if err != nil {
+		return nil, punishErrorStatus(err)
+	}
+
+	record, err := punishments_db.GetPunishmentRecordByID(s.punishDB, result.PunishmentID)
+	if err != nil {
+		trace.Logf(ctx, "[PunishServer] Error loading created punishment %d: %v", result.PunishmentID, err)
+		return nil, status.Errorf(codes.Internal, "punishment %d was created but could not be loaded: %v", result.PunishmentID, err)
+	}
+
+	trace.Logf(ctx, "[PunishServer] Admin %s created punishment %d (%s) for user %s", admin.User.ID, result.PunishmentID, result.Status, req.UserId)
+
+	return &pb.CreatePunishmentResponse{
+		Punishment:      convertToProtoPunishment(record),
+		PendingApproval: result.Status == model.PunishmentStatusPendingApproval,
+	}, nil
+}
+
+// RevokePunishment revokes a punishment through the same logic as the /punish_revoke command
+func (s *PunishServer) RevokePunishment(ctx context.Context, req *pb.RevokePunishmentRequest) (*pb.RevokePunishmentResponse, error) {
+	trace.Logf(ctx, "[PunishServer] RevokePunishment called for punishment_id=%d, guild_id=%s", req.PunishmentId, req.GuildId)
+
+	if req.PunishmentId <= 0 || req.GuildId == "" {
+		return nil, status.Error(codes.InvalidArgument, "punishment_id and guild_id are required")
+	}
+
+	admin, err := s.authorizeAdmin(ctx, req.GuildId)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := punishments_db.GetPunishmentRecordByID(s.punishDB, req.PunishmentId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Errorf(codes.NotFound, "punishment %d not found", req.PunishmentId)
+		}
+		trace.Logf(ctx, "[PunishServer] Error querying punishment %d: %v", req.PunishmentId, err)
+		return nil, status.Errorf(codes.Internal, "failed to query punishment: %v", err)
+	}
+	// Admins can only revoke punishments of the guild they were authorized for
+	if record.GuildID != req.GuildId {
+		return nil, status.Errorf(codes.NotFound, "punishment %d not found", req.PunishmentId)
+	}
+
+	if err := punish_admin.RevokePunishment(ctx, s.bot.GetSession(), s.punishDB, record, admin.User.ID, admin.User.Username, req.Reason); err != nil {
+		trace.Logf(ctx, "[PunishServer] Error revoking punishment %d: %v", req.PunishmentId, err)
+		return nil, status.Errorf(codes.Internal, "failed to revoke punishment: %v", err)
+	}
+
+	revoked, err := punishments_db.GetDeletedPunishmentRecordByID(s.punishDB, req.PunishmentId)
+	if err != nil {
+		revoked = record
+	}
+
+	trace.Logf(ctx, "[PunishServer] Admin %s revoked punishment %d", admin.User.ID, req.PunishmentId)
+
+	return &pb.RevokePunishmentResponse{
+		Punishment: convertToProtoPunishment(revoked),
+	}, nil
+}
+
+// ListActivePunishments lists the punishments currently in effect in a guild with pagination
+func (s *PunishServer) ListActivePunishments(ctx context.Context, req *pb.ListActivePunishmentsRequest) (*pb.ListActivePunishmentsResponse, error) {
+	trace.Logf(ctx, "[PunishServer] ListActivePunishments called for guild_id=%s, page=%d, page_size=%d", req.GuildId, req.Page, req.PageSize)
+
+	if req.GuildId == "" {
+		return nil, status.Error(codes.InvalidArgument, "guild_id is required")
+	}
+
+	if _, err := s.authorizeAdmin(ctx, req.GuildId); err != nil {
+		return nil, err
+	}
+
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10 // default page size
+	}
+	if pageSize > 100 {
+		pageSize = 100 // max page size
+	}
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	records, total, err := punishments_db.GetInEffectPunishmentsByGuild(s.punishDB, req.GuildId, int(pageSize), int((page-1)*pageSize))
+	if err != nil {
+		trace.Logf(ctx, "[PunishServer] Error querying active punishments: %v", err)
+		return nil, status.Errorf(codes.Internal, "failed to query active punishments: %v", err)
+	}
+
+	protoPunishments := make([]*pb.Punishment, 0, len(records))
+	for _, record := range records {
+		protoPunishments = append(protoPunishments, convertToProtoPunishment(&record))
+	}
+
+	return &pb.ListActivePunishmentsResponse{
+		Punishments:  protoPunishments,
+		TotalRecords: int32(total),
+		TotalPages:   int32(math.Ceil(float64(total) / float64(pageSize))),
+		CurrentPage:  page,
+	}, nil
+}
+
+// authorizeAdmin checks that the admin named in the request metadata holds an admin role in the guild
+func (s *PunishServer) authorizeAdmin(ctx context.Context, guildID string) (*discordgo.Member, error) {
+	if s.bot == nil {
+		return nil, status.Error(codes.Unavailable, "admin operations are not available without a Discord session")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(AdminIDMetadataKey)
+	if len(values) == 0 || values[0] == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "%s metadata is required", AdminIDMetadataKey)
+	}
+	adminID := values[0]
+
+	cfg := s.bot.GetConfig()
+	serverConfig, ok := cfg.ServerConfigs[guildID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "guild %s is not configured", guildID)
+	}
+
+	member, err := s.bot.GetSession().GuildMember(guildID, adminID)
+	if err != nil {
+		trace.Logf(ctx, "[PunishServer] Error fetching admin %s in guild %s: %v", adminID, guildID, err)
+		return nil, status.Errorf(codes.PermissionDenied, "admin %s is not a member of guild %s", adminID, guildID)
+	}
+
+	permissionLevel := utils.CheckPermission(member.Roles, adminID, serverConfig.AdminRoleIDs, nil, cfg.DeveloperUserIDs, cfg.SuperAdminRoleIDs)
+	if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+		return nil, status.Errorf(codes.PermissionDenied, "user %s is not an admin of guild %s", adminID, guildID)
+	}
+
+	return member, nil
+}
+
+// punishErrorStatus maps a punishment failure to a gRPC status
+func punishErrorStatus(err error) error {
+	var punishErr *punish.PunishError
+	if !errors.As(err, &punishErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	switch punishErr.Kind {
+	case punish.PunishErrInvalid:
+		return status.Error(codes.InvalidArgument, punishErr.Message)
+	case punish.PunishErrNotFound:
+		return status.Error(codes.NotFound, punishErr.Message)
+	case punish.PunishErrDenied:
+		return status.Error(codes.FailedPrecondition, punishErr.Message)
+	case punish.PunishErrRateLimited:
+		return status.Error(codes.ResourceExhausted, punishErr.Message)
+	default:
+		return status.Error(codes.Internal, punishErr.Message)
+	}
+}
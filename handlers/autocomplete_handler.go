@@ -25,7 +25,7 @@ func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, co
 	}
 
 	switch data.Name {
-	case "punish":
+	case "punish", "punish_config", "punish_search":
 		var focusedOption *discordgo.ApplicationCommandInteractionDataOption
 		for _, opt := range data.Options {
 			if opt.Focused {
@@ -44,7 +44,8 @@ func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, co
 			guildActions, ok := punishConfig.PunishConfig[i.GuildID]
 			if ok {
 				inputValue := focusedOption.StringValue()
-				for actionKey, actionConfig := range guildActions {
+				for _, actionKey := range utils.OrderedPunishActions(guildActions) {
+					actionConfig := guildActions[actionKey]
 					// Fuzzy match against action key or name
 					matchKey := strings.Contains(strings.ToLower(actionKey), strings.ToLower(inputValue))
 					matchName := strings.Contains(strings.ToLower(actionConfig.Name), strings.ToLower(inputValue))
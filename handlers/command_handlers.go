@@ -19,9 +19,9 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
-func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	return map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
-		"punish": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func commandHandlers(b *bot.Bot) map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	return map[string]func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate){
+		"punish": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -32,48 +32,87 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
 				return
 			}
-			punish.HandlePunishCommand(s, i, b)
+			punish.HandlePunishCommand(ctx, s, i, b)
 		},
-		"punish_search": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"punish_search": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
 				return
 			}
 			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
-			if permissionLevel < utils.AdminPermission {
+			if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
 				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
 				return
 			}
-			punish_admin.HandlePunishSearchCommand(s, i)
+			punish_admin.HandlePunishSearchCommand(s, i, b)
 		},
-		"punish_revoke": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"punish_revoke": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
 				return
 			}
 			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
-			if permissionLevel < utils.AdminPermission {
+			if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
 				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
 				return
 			}
-			punish_admin.HandlePunishRevokeCommand(s, i)
+			punish_admin.HandlePunishRevokeCommand(ctx, s, i)
 		},
-		"punish_delete": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"punish_delete": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
 				return
 			}
 			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
-			if permissionLevel < utils.AdminPermission {
+			if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
 				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
 				return
 			}
-			punish_admin.HandlePunishDeleteCommand(s, i)
+			punish_admin.HandlePunishDeleteCommand(ctx, s, i)
 		},
-		"punish_print_evidence": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"punish_reconcile": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+			if !ok {
+				log.Printf("Could not find server config for guild: %s", i.GuildID)
+				return
+			}
+			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+			if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
+				return
+			}
+			punish_admin.HandlePunishReconcileCommand(s, i)
+		},
+		"punish_config": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+			if !ok {
+				log.Printf("Could not find server config for guild: %s", i.GuildID)
+				return
+			}
+			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+			if permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
+				return
+			}
+			punish_admin.HandlePunishConfigCommand(s, i, b.GetConfig())
+		},
+		"punish_restore": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+			if !ok {
+				log.Printf("Could not find server config for guild: %s", i.GuildID)
+				return
+			}
+			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+			if permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
+				return
+			}
+			punish_admin.HandlePunishRestoreCommand(ctx, s, i)
+		},
+		"punish_print_evidence": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -86,7 +125,33 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			punish_admin.HandlePunishPrintEvidenceCommand(s, i)
 		},
-		"reset_punish_cooldown": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"punish_audit": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+			if !ok {
+				log.Printf("Could not find server config for guild: %s", i.GuildID)
+				return
+			}
+			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+			if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
+				return
+			}
+			punish_admin.HandlePunishAuditCommand(s, i)
+		},
+		"punish_note": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+			if !ok {
+				log.Printf("Could not find server config for guild: %s", i.GuildID)
+				return
+			}
+			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+			if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
+				return
+			}
+			punish_admin.HandlePunishNoteCommand(ctx, s, i, b)
+		},
+		"reset_punish_cooldown": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -99,10 +164,10 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			punish.HandleResetPunishCooldownCommand(s, i, b)
 		},
-		"new-cards": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"new-cards": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			leaderboard.HandleNewCardsInteraction(s, i, b)
 		},
-		"ads_board_admin": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"ads_board_admin": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			config := b.GetConfig()
 			serverConfig, ok := config.ServerConfigs[i.GuildID]
 			if !ok {
@@ -117,13 +182,13 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			leaderboard.HandleAdsBoardAdminCommand(s, i, b)
 		},
-		"rollcard": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"rollcard": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			rollcard.HandleRollCardInteraction(s, i, b)
 		},
-		"preset-message": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"preset-message": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			preset.HandlePresetMessageInteraction(s, i, b)
 		},
-		"preset-message_upd": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"preset-message_upd": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -136,7 +201,7 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			preset.HandlePresetMessageUpdateInteraction(s, i, b)
 		},
-		"preset-message_admin": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"preset-message_admin": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -149,7 +214,7 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			preset.HandlePresetMessageAdminInteraction(s, i, b)
 		},
-		"quick-preset": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"quick-preset": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -162,7 +227,7 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			preset.HandleQuickPresetInteraction(s, i, b)
 		},
-		"start-scan": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"start-scan": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, nil, nil, b.GetConfig().DeveloperUserIDs, nil)
 			if permissionLevel != utils.DeveloperPermission {
 				utils.SendEphemeralResponse(s, i, "You do not have permission to use this command.")
@@ -195,7 +260,7 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 
 			go scanner.Scan(s, b.GetConfig().LogChannelID, scanMode, targetGuildID, context.Background())
 		},
-		"setup-roll-panel": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"setup-roll-panel": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -208,16 +273,16 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			rollcard.HandleSetupRollPanel(s, i, b)
 		},
-		"system-info": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"system-info": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			SystemInfoHandler(s, i)
 		},
-		"reload-config": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"reload-config": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			admin.HandleReloadConfig(s, i, b)
 		},
-		"new-post-push_admin": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"new-post-push_admin": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			admin.HandleNewPostPushAdminCommand(s, i, b)
 		},
-		"register-top-channel": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"register-top-channel": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -230,7 +295,7 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			HandleRegisterTopChannel(s, i, b)
 		},
-		"daily_punishment_stats": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"daily_punishment_stats": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
 			if !ok {
 				log.Printf("Could not find server config for guild: %s", i.GuildID)
@@ -243,13 +308,13 @@ func commandHandlers(b *bot.Bot) map[string]func(s *discordgo.Session, i *discor
 			}
 			punish.HandlePunishmentStatsCommand(s, i, b)
 		},
-		"manage-auto-trigger": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"manage-auto-trigger": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			auto_trigger.HandleManageAutoTriggerCommand(s, i, b)
 		},
-		"guilds_admin": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"guilds_admin": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			admin.HandleGuildsAdminCommand(s, i, b.GetDB(), b.GetConfig())
 		},
-		"personal-nav": func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		"personal-nav": func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 			personalnav.HandlePersonalNavCommand(s, i, b.GetConfig())
 		},
 	}
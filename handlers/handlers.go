@@ -3,6 +3,7 @@ package handlers
 import (
 	"log"
 	"newer_helper/bot"
+	"newer_helper/handlers/punish"
 	"newer_helper/handlers/rollcard"
 
 	"github.com/bwmarrin/discordgo"
@@ -31,6 +32,10 @@ func addHandlers(b *bot.Bot) {
 		HandleThreadDelete(s, t, b.GetConfig())
 	})
 
+	b.Session.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+		punish.HandleGuildMemberAdd(s, m)
+	})
+
 	b.Session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
 		rollcard.HandlePersistentPanelRefresh(s, m, b)
 		HandleMessageCreate(s, m, b)
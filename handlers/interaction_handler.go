@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"log"
 	"newer_helper/bot"
 	"newer_helper/handlers/personalnav"
@@ -8,12 +9,17 @@ import (
 	"newer_helper/handlers/punish"
 	punish_admin "newer_helper/handlers/punish/admin"
 	"newer_helper/handlers/rollcard"
+	"newer_helper/utils/trace"
 	"strings"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 func handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	// Every interaction gets its own trace ID, which follows it into the logs, the audit log and log-channel embeds
+	ctx := trace.New(context.Background())
+	trace.Logf(ctx, "Interaction %s (type %d) in guild %s from %s", i.ID, i.Type, i.GuildID, interactionUserID(i))
+
 	switch i.Type {
 	case discordgo.InteractionApplicationCommand:
 		switch i.ApplicationCommandData().Name {
@@ -25,7 +31,7 @@ func handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreat
 			preset.HandleQuickPresetReplyCommand(s, i, b)
 		default:
 			if h, ok := b.CommandHandlers[i.ApplicationCommandData().Name]; ok {
-				h(s, i)
+				h(ctx, s, i)
 			}
 		}
 	case discordgo.InteractionMessageComponent:
@@ -41,9 +47,23 @@ func handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreat
 		} else if strings.HasPrefix(customID, "search_preset_again_") {
 			preset.HandleSearchPresetAgain(s, i, b)
 		} else if strings.HasPrefix(customID, "punish_page_v2:") {
-			punish_admin.HandlePunishPaginationV2(s, i)
+			punish_admin.HandlePunishPaginationV2(s, i, b)
+		} else if strings.HasPrefix(customID, "punish_audit_page:") {
+			punish_admin.HandlePunishAuditPagination(s, i)
+		} else if strings.HasPrefix(customID, "punish_note_page:") {
+			punish_admin.HandlePunishNotePagination(s, i)
+		} else if strings.HasPrefix(customID, "punish_config_history:") {
+			punish_admin.HandlePunishConfigHistoryPagination(s, i)
+		} else if strings.HasPrefix(customID, "punish_preview_") {
+			punish.HandlePunishPreviewDecision(ctx, s, i, b)
 		} else if strings.HasPrefix(customID, "punish_action_") {
-			punish.HandlePunishActionSelection(s, i, b)
+			punish.HandlePunishActionSelection(ctx, s, i, b)
+		} else if strings.HasPrefix(customID, "punish_approval_") {
+			punish.HandlePunishApprovalDecision(ctx, s, i, b)
+		} else if strings.HasPrefix(customID, "punish_appeal_approve_") || strings.HasPrefix(customID, "punish_appeal_reject_") {
+			punish.HandleAppealReview(ctx, s, i, b)
+		} else if strings.HasPrefix(customID, "punish_appeal_") {
+			punish.HandleAppealButton(s, i, b)
 		} else if strings.HasPrefix(customID, "roll_again:") {
 			rollcard.HandleRollCardComponent(s, i, b, customID)
 		} else if strings.HasPrefix(customID, "persistent_roll:") {
@@ -65,6 +85,10 @@ func handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreat
 		customID := i.ModalSubmitData().CustomID
 		if strings.HasPrefix(customID, "punish_modal_") {
 			punish.HandlePunishModalSubmit(s, i, b)
+		} else if strings.HasPrefix(customID, "punish_config_action_modal:") || strings.HasPrefix(customID, "punish_config_level_modal:") {
+			punish_admin.HandlePunishConfigModalSubmit(s, i)
+		} else if strings.HasPrefix(customID, "punish_appeal_modal_") {
+			punish.HandleAppealModalSubmit(ctx, s, i, b)
 		} else if strings.HasPrefix(customID, "search_preset_modal_") {
 			preset.HandleSearchPresetModal(s, i, b)
 		}
@@ -76,3 +100,14 @@ func handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreat
 		}
 	}
 }
+
+// interactionUserID returns the ID of the user who triggered the interaction, in a guild or in DMs
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
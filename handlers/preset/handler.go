@@ -143,18 +143,18 @@ func sendPreset(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot
 		}
 		s.InteractionResponseDelete(i.Interaction)
 	} else {
-		pendingPresets := b.GetPendingPresets()
-		pendingPresetsMutex := b.GetPendingPresetsMutex()
-		pendingPresetsMutex.Lock()
-
 		interactionID := i.Interaction.ID
-		pendingPresets[interactionID] = &bot.PendingPreset{
+		err := b.SavePendingPreset(interactionID, &bot.PendingPreset{
 			MessageSend: messageSend,
 			PresetName:  selectedPreset.Name,
 			UserID:      i.Member.User.ID,
 			Timestamp:   time.Now(),
+		})
+		if err != nil {
+			log.Printf("Failed to save pending preset: %v", err)
+			utils.SendFollowUpError(s, i.Interaction, "创建确认请求失败。")
+			return
 		}
-		pendingPresetsMutex.Unlock()
 
 		webhookParams := &discordgo.WebhookParams{
 			Content: messageSend.Content,
@@ -189,12 +189,12 @@ func sendPreset(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot
 			webhookParams.Content = "请预览并确认发送以下消息："
 		}
 
-		_, err := s.FollowupMessageCreate(i.Interaction, true, webhookParams)
+		_, err = s.FollowupMessageCreate(i.Interaction, true, webhookParams)
 		if err != nil {
 			log.Printf("Failed to send confirmation message: %v", err)
-			pendingPresetsMutex.Lock()
-			delete(pendingPresets, interactionID)
-			pendingPresetsMutex.Unlock()
+			if err := b.DeletePendingPreset(interactionID); err != nil {
+				log.Printf("Failed to discard pending preset: %v", err)
+			}
 		}
 	}
 }
@@ -261,14 +261,12 @@ func HandlePresetConfirmationInteraction(s *discordgo.Session, i *discordgo.Inte
 		interactionID = parts[2]
 	}
 
-	pendingPresets := b.GetPendingPresets()
-	pendingPresetsMutex := b.GetPendingPresetsMutex()
-	pendingPresetsMutex.Lock()
-	defer pendingPresetsMutex.Unlock()
-
-	pending, ok := pendingPresets[interactionID]
-	if !ok {
-		utils.SendEphemeralResponse(s, i, "This confirmation has expired or is invalid.")
+	pending, err := b.GetPendingPreset(interactionID)
+	if err != nil {
+		if !bot.IsPendingExpired(err) {
+			log.Printf("Failed to load pending preset %s: %v", interactionID, err)
+		}
+		utils.SendEphemeralResponse(s, i, "⏰ 此确认请求已过期（超过5分钟未操作），请重新发送预设消息。")
 		return
 	}
 
@@ -278,7 +276,11 @@ func HandlePresetConfirmationInteraction(s *discordgo.Session, i *discordgo.Inte
 		return
 	}
 
-	delete(pendingPresets, interactionID) // The request is handled, remove it from pending
+	// The request is handled, remove it from pending. Taking it guards against a concurrent click.
+	if _, err := b.TakePendingPreset(interactionID); err != nil {
+		utils.SendEphemeralResponse(s, i, "此确认请求已被处理。")
+		return
+	}
 
 	switch action {
 	case "cancel":
@@ -0,0 +1,274 @@
+package punish_admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const auditEntriesPerPage = 8
+
+// auditDateLayout 是 /punish_audit 日期参数的格式
+const auditDateLayout = "2006-01-02"
+
+// auditActionNames 审计操作的显示名称
+var auditActionNames = map[string]string{
+	model.AuditActionCreate:       "创建",
+	model.AuditActionRevoke:       "撤销",
+	model.AuditActionDelete:       "删除",
+	model.AuditActionStatusChange: "状态变更",
+	model.AuditActionUpdate:       "更新",
+	model.AuditActionRestore:      "恢复",
+	model.AuditActionPurge:        "永久清除",
+}
+
+// HandlePunishAuditCommand 处理 /punish_audit 命令
+func HandlePunishAuditCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("无法延迟交互: %v", err)
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	var punishmentID, adminID, since, until, traceID string
+	if opt, ok := optionMap["punishment_id"]; ok {
+		punishmentID = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := optionMap["admin"]; ok {
+		adminID = opt.UserValue(nil).ID
+	}
+	if opt, ok := optionMap["since"]; ok {
+		since = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := optionMap["until"]; ok {
+		until = strings.TrimSpace(opt.StringValue())
+	}
+	if opt, ok := optionMap["trace_id"]; ok {
+		traceID = strings.TrimSpace(opt.StringValue())
+	}
+
+	displayAuditEntries(s, i.Interaction, i.GuildID, punishmentID, adminID, since, until, traceID, 1)
+}
+
+// HandlePunishAuditPagination 处理审计日志的翻页按钮
+func HandlePunishAuditPagination(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Failed to defer audit pagination interaction: %v", err)
+		return
+	}
+
+	customID := i.MessageComponentData().CustomID
+	parts := strings.Split(customID, ":")
+	if len(parts) != 6 && len(parts) != 7 {
+		log.Printf("Invalid custom ID for audit pagination: %s", customID)
+		return
+	}
+
+	// Buttons sent before the trace filter existed have no trace ID part
+	traceID := ""
+	if len(parts) == 7 {
+		traceID = parts[6]
+	}
+
+	page, _ := strconv.Atoi(parts[1])
+	displayAuditEntries(s, i.Interaction, i.GuildID, parts[2], parts[3], parts[4], parts[5], traceID, page)
+}
+
+// parseAuditFilter 将命令参数转换为数据库查询条件
+func parseAuditFilter(punishmentID, adminID, since, until, traceID string) (punishments_db.AuditFilter, error) {
+	filter := punishments_db.AuditFilter{ActorID: adminID, TraceID: traceID}
+
+	if punishmentID != "" {
+		id, err := strconv.ParseInt(punishmentID, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("无效的惩罚ID。")
+		}
+		filter.PunishmentID = id
+	}
+	if since != "" {
+		t, err := time.ParseInLocation(auditDateLayout, since, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("无效的开始日期，请使用 YYYY-MM-DD 格式。")
+		}
+		filter.Since = t.Unix()
+	}
+	if until != "" {
+		t, err := time.ParseInLocation(auditDateLayout, until, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("无效的结束日期，请使用 YYYY-MM-DD 格式。")
+		}
+		// 结束日期包含当天
+		filter.Until = t.AddDate(0, 0, 1).Unix()
+	}
+	if filter.Since != 0 && filter.Until != 0 && filter.Since >= filter.Until {
+		return filter, fmt.Errorf("开始日期必须早于结束日期。")
+	}
+
+	return filter, nil
+}
+
+// displayAuditEntries 显示一页审计记录，仅包含本服务器的记录
+func displayAuditEntries(s *discordgo.Session, i *discordgo.Interaction, guildID, punishmentID, adminID, since, until, traceID string, page int) {
+	filter, err := parseAuditFilter(punishmentID, adminID, since, until, traceID)
+	if err != nil {
+		utils.SendFollowUpError(s, i, err.Error())
+		return
+	}
+	filter.GuildID = guildID
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		utils.SendFollowUpError(s, i, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "连接惩罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	if page < 1 {
+		page = 1
+	}
+	entries, total, err := punishments_db.QueryAuditEntries(db, filter, auditEntriesPerPage, (page-1)*auditEntriesPerPage)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "检索审计日志失败。")
+		log.Printf("获取审计日志时出错: %v", err)
+		return
+	}
+
+	if total == 0 {
+		utils.SendFollowUp(s, i, "未找到审计记录。")
+		return
+	}
+
+	totalPages := (total + auditEntriesPerPage - 1) / auditEntriesPerPage
+	if page > totalPages {
+		page = totalPages
+		entries, _, err = punishments_db.QueryAuditEntries(db, filter, auditEntriesPerPage, (page-1)*auditEntriesPerPage)
+		if err != nil {
+			utils.SendFollowUpError(s, i, "检索审计日志失败。")
+			log.Printf("获取审计日志时出错: %v", err)
+			return
+		}
+	}
+
+	var filters []string
+	if punishmentID != "" {
+		filters = append(filters, fmt.Sprintf("处罚ID: `%s`", punishmentID))
+	}
+	if adminID != "" {
+		filters = append(filters, fmt.Sprintf("操作人: <@%s>", adminID))
+	}
+	if since != "" || until != "" {
+		filters = append(filters, fmt.Sprintf("日期: `%s` ~ `%s`", since, until))
+	}
+	if traceID != "" {
+		filters = append(filters, fmt.Sprintf("追踪ID: `%s`", traceID))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "处罚审计日志",
+		Description: strings.Join(filters, "\n"),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("第 %d 页，共 %d 页 | 共 %d 条记录", page, totalPages, total),
+		},
+	}
+
+	for _, entry := range entries {
+		actionName, ok := auditActionNames[entry.Action]
+		if !ok {
+			actionName = entry.Action
+		}
+
+		actor := fmt.Sprintf("<@%s>", entry.ActorID)
+		if entry.ActorID == punishments_db.AuditActorSystem {
+			actor = "系统"
+		}
+
+		value := fmt.Sprintf("操作人: %s\n时间: <t:%d:f>", actor, entry.CreatedAt)
+		if change := describeAuditChange(entry); change != "" {
+			value += "\n" + change
+		}
+		if entry.Reason != "" {
+			value += "\n原因: " + utils.TruncateString(entry.Reason, 200)
+		}
+		if entry.TraceID != "" {
+			value += fmt.Sprintf("\n追踪ID: `%s`", entry.TraceID)
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("#%d · 处罚 %d · %s", entry.AuditID, entry.PunishmentID, actionName),
+			Value: value,
+		})
+	}
+
+	components := utils.CreatePaginationComponents(page, totalPages, "punish_audit_page", punishmentID, adminID, since, until, traceID)
+
+	s.InteractionResponseEdit(i, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
+
+// describeAuditChange 概括审计条目前后快照的差异
+func describeAuditChange(entry model.PunishmentAuditEntry) string {
+	var before, after *model.PunishmentRecord
+	if entry.BeforeJSON != "" {
+		before = &model.PunishmentRecord{}
+		if err := json.Unmarshal([]byte(entry.BeforeJSON), before); err != nil {
+			before = nil
+		}
+	}
+	if entry.AfterJSON != "" {
+		after = &model.PunishmentRecord{}
+		if err := json.Unmarshal([]byte(entry.AfterJSON), after); err != nil {
+			after = nil
+		}
+	}
+
+	switch {
+	case before == nil && after != nil:
+		return fmt.Sprintf("用户: <@%s> · 类型: `%s` · 状态: `%s`", after.UserID, after.ActionType, after.PunishmentStatus)
+	case before != nil && after == nil:
+		return fmt.Sprintf("用户: <@%s> · 类型: `%s` · 原状态: `%s`", before.UserID, before.ActionType, before.PunishmentStatus)
+	case before != nil && after != nil:
+		if before.DeletedAt == 0 && after.DeletedAt != 0 {
+			return fmt.Sprintf("用户: <@%s> · 类型: `%s` · 已移入回收站", before.UserID, before.ActionType)
+		}
+		if before.DeletedAt != 0 && after.DeletedAt == 0 {
+			return fmt.Sprintf("用户: <@%s> · 类型: `%s` · 已从回收站恢复", after.UserID, after.ActionType)
+		}
+		if before.PunishmentStatus != after.PunishmentStatus {
+			return fmt.Sprintf("状态: `%s` → `%s`", before.PunishmentStatus, after.PunishmentStatus)
+		}
+		if before.RolesRemoveAt != after.RolesRemoveAt {
+			return "临时身份组移除计划已更新"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,575 @@
+package punish_admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jmoiron/sqlx"
+)
+
+const punishConfigPath = "config/config_file/punish_config.json"
+
+const configVersionsPerPage = 10
+
+// HandlePunishConfigCommand 处理 /punish_config 命令
+func HandlePunishConfigCommand(s *discordgo.Session, i *discordgo.InteractionCreate, cfg *model.Config) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	operation := optionMap["operation"].StringValue()
+	actionKey := ""
+	if opt, ok := optionMap["action"]; ok {
+		actionKey = strings.TrimSpace(opt.StringValue())
+	}
+	level := -1
+	if opt, ok := optionMap["level"]; ok {
+		level = int(opt.IntValue())
+	}
+
+	// 编辑操作需要直接弹出模态框，不能先延迟响应
+	switch operation {
+	case "action_edit":
+		showActionEditModal(s, i, actionKey)
+		return
+	case "level_edit":
+		showLevelEditModal(s, i, actionKey, level)
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("无法延迟交互: %v", err)
+		return
+	}
+
+	punishConfig, db, err := openPunishConfig()
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, err.Error())
+		return
+	}
+	defer db.Close()
+
+	actorID := i.Member.User.ID
+	actions := punishConfig.PunishConfig[i.GuildID]
+	if actions == nil {
+		actions = make(map[string]model.ActionConfig)
+	}
+
+	switch operation {
+	case "list":
+		displayPunishConfig(s, i.Interaction, actions, actionKey)
+	case "import":
+		importPunishConfigFile(s, i.Interaction, db, actorID)
+	case "action_remove":
+		if _, ok := actions[actionKey]; !ok {
+			utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("找不到处罚类型 `%s`。", actionKey))
+			return
+		}
+		delete(actions, actionKey)
+		savePunishConfigChange(s, i.Interaction, db, actions, actorID, fmt.Sprintf("删除处罚类型 %s", actionKey))
+	case "level_remove":
+		action, ok := actions[actionKey]
+		if !ok {
+			utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("找不到处罚类型 `%s`。", actionKey))
+			return
+		}
+		order := sortedLevelKeys(action.Data)
+		if level < 0 || level >= len(order) {
+			utils.SendFollowUpError(s, i.Interaction, "无效的等级。")
+			return
+		}
+		order = append(order[:level], order[level+1:]...)
+		actions[actionKey] = renumberLevels(action, order)
+		savePunishConfigChange(s, i.Interaction, db, actions, actorID, fmt.Sprintf("删除 %s 的等级 %d", actionKey, level))
+	case "reorder":
+		action, ok := actions[actionKey]
+		if !ok {
+			utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("找不到处罚类型 `%s`。", actionKey))
+			return
+		}
+		position := -1
+		if opt, ok := optionMap["position"]; ok {
+			position = int(opt.IntValue())
+		}
+		order := sortedLevelKeys(action.Data)
+		if level < 0 || level >= len(order) || position < 0 || position >= len(order) {
+			utils.SendFollowUpError(s, i.Interaction, "无效的等级或目标位置。")
+			return
+		}
+		moved := order[level]
+		order = append(order[:level], order[level+1:]...)
+		order = append(order[:position], append([]string{moved}, order[position:]...)...)
+		actions[actionKey] = renumberLevels(action, order)
+		savePunishConfigChange(s, i.Interaction, db, actions, actorID, fmt.Sprintf("将 %s 的等级 %d 移动到 %d", actionKey, level, position))
+	case "history":
+		displayPunishConfigHistory(s, i.Interaction, 1)
+	case "validate":
+		issues := utils.ValidatePunishConfig(s, cfg, punishConfig, i.GuildID, utils.LoadRawPunishActions(punishConfigPath, i.GuildID))
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Embeds: &[]*discordgo.MessageEmbed{utils.BuildPunishConfigReportEmbed(i.GuildID, issues)},
+		})
+	case "restore":
+		opt, ok := optionMap["version"]
+		if !ok {
+			utils.SendFollowUpError(s, i.Interaction, "请提供要恢复的版本号。")
+			return
+		}
+		restorePunishConfigVersion(s, i.Interaction, db, opt.IntValue(), actorID)
+	default:
+		utils.SendFollowUpError(s, i.Interaction, "未知的操作。")
+	}
+}
+
+// HandlePunishConfigModalSubmit 处理处罚类型和等级编辑模态框的提交
+func HandlePunishConfigModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ModalSubmitData()
+	parts := strings.Split(data.CustomID, ":")
+
+	var input string
+	for _, row := range data.Components {
+		if actionsRow, ok := row.(*discordgo.ActionsRow); ok {
+			for _, comp := range actionsRow.Components {
+				if textInput, ok := comp.(*discordgo.TextInput); ok && textInput.CustomID == "config_json" {
+					input = textInput.Value
+				}
+			}
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("无法延迟交互: %v", err)
+		return
+	}
+
+	punishConfig, db, err := openPunishConfig()
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, err.Error())
+		return
+	}
+	defer db.Close()
+
+	actions := punishConfig.PunishConfig[i.GuildID]
+	if actions == nil {
+		actions = make(map[string]model.ActionConfig)
+	}
+
+	switch {
+	case parts[0] == "punish_config_action_modal" && len(parts) == 2:
+		actionKey := parts[1]
+		var action model.ActionConfig
+		if err := json.Unmarshal([]byte(input), &action); err != nil {
+			utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("JSON 格式错误: %v", err))
+			return
+		}
+		// 等级通过 level_edit 单独管理，未提供时保留现有等级
+		existing, exists := actions[actionKey]
+		if len(action.Data) == 0 {
+			action.Data = existing.Data
+		}
+		if action.GuildID == "" {
+			action.GuildID = i.GuildID
+		}
+		actions[actionKey] = action
+
+		comment := fmt.Sprintf("修改处罚类型 %s", actionKey)
+		if !exists {
+			comment = fmt.Sprintf("新增处罚类型 %s", actionKey)
+		}
+		savePunishConfigChange(s, i.Interaction, db, actions, i.Member.User.ID, comment)
+	case parts[0] == "punish_config_level_modal" && len(parts) == 3:
+		actionKey := parts[1]
+		level, err := strconv.Atoi(parts[2])
+		if err != nil {
+			utils.SendFollowUpError(s, i.Interaction, "无效的等级。")
+			return
+		}
+		action, ok := actions[actionKey]
+		if !ok {
+			utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("找不到处罚类型 `%s`。", actionKey))
+			return
+		}
+		var punishLevel model.PunishLevel
+		if err := json.Unmarshal([]byte(input), &punishLevel); err != nil {
+			utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("JSON 格式错误: %v", err))
+			return
+		}
+		order := sortedLevelKeys(action.Data)
+		if level > len(order) {
+			utils.SendFollowUpError(s, i.Interaction, "等级必须连续，请先添加前面的等级。")
+			return
+		}
+		if action.Data == nil {
+			action.Data = make(map[string]model.PunishLevel)
+		}
+		comment := fmt.Sprintf("修改 %s 的等级 %d", actionKey, level)
+		if level == len(order) {
+			comment = fmt.Sprintf("为 %s 新增等级 %d", actionKey, level)
+			// 使用临时键，避免与编号不连续的旧键冲突，随后统一重新编号
+			action.Data["new"] = punishLevel
+			order = append(order, "new")
+		} else {
+			action.Data[order[level]] = punishLevel
+		}
+		actions[actionKey] = renumberLevels(action, order)
+		savePunishConfigChange(s, i.Interaction, db, actions, i.Member.User.ID, comment)
+	default:
+		log.Printf("Invalid custom ID for punish config modal: %s", data.CustomID)
+		utils.SendFollowUpError(s, i.Interaction, "无效的请求。")
+	}
+}
+
+// HandlePunishConfigHistoryPagination 处理配置版本历史的翻页按钮
+func HandlePunishConfigHistoryPagination(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Failed to defer punish config history pagination: %v", err)
+		return
+	}
+
+	parts := strings.Split(i.MessageComponentData().CustomID, ":")
+	if len(parts) != 2 {
+		log.Printf("Invalid custom ID for punish config history pagination: %s", i.MessageComponentData().CustomID)
+		return
+	}
+	page, _ := strconv.Atoi(parts[1])
+	displayPunishConfigHistory(s, i.Interaction, page)
+}
+
+// openPunishConfig 加载处罚配置并连接惩罚数据库，返回的错误可直接展示给用户
+func openPunishConfig() (*model.PunishConfig, *sqlx.DB, error) {
+	punishConfig, err := utils.LoadPunishConfig(punishConfigPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载处罚配置失败。")
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("连接惩罚数据库失败。")
+	}
+	return punishConfig, db, nil
+}
+
+// savePunishConfigChange 将修改后的处罚类型保存为新版本
+func savePunishConfigChange(s *discordgo.Session, i *discordgo.Interaction, db *sqlx.DB, actions map[string]model.ActionConfig, actorID, comment string) {
+	versionID, err := punishments_db.SavePunishConfigVersion(db, i.GuildID, actions, actorID, comment)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "保存处罚配置失败。")
+		log.Printf("保存处罚配置时出错: %v", err)
+		return
+	}
+	utils.InvalidatePunishConfigCache()
+
+	utils.SendFollowUp(s, i, fmt.Sprintf("✅ %s，已保存为版本 #%d。", comment, versionID))
+}
+
+// importPunishConfigFile 将 punish_config.json 中本服务器的配置导入数据库
+func importPunishConfigFile(s *discordgo.Session, i *discordgo.Interaction, db *sqlx.DB, actorID string) {
+	fileData, err := os.ReadFile(punishConfigPath)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "读取 punish_config.json 失败。")
+		return
+	}
+	var fileConfig model.PunishConfig
+	if err := json.Unmarshal(fileData, &fileConfig); err != nil {
+		utils.SendFollowUpError(s, i, fmt.Sprintf("解析 punish_config.json 失败: %v", err))
+		return
+	}
+
+	actions, ok := fileConfig.PunishConfig[i.GuildID]
+	if !ok {
+		utils.SendFollowUpError(s, i, "punish_config.json 中没有本服务器的处罚配置。")
+		return
+	}
+	savePunishConfigChange(s, i, db, actions, actorID, fmt.Sprintf("从 punish_config.json 导入 %d 个处罚类型", len(actions)))
+}
+
+// restorePunishConfigVersion 将指定版本的配置另存为最新版本
+func restorePunishConfigVersion(s *discordgo.Session, i *discordgo.Interaction, db *sqlx.DB, versionID int64, actorID string) {
+	version, err := punishments_db.GetPunishConfigVersionByID(db, i.GuildID, versionID)
+	if err != nil {
+		utils.SendFollowUpError(s, i, fmt.Sprintf("找不到版本 #%d。", versionID))
+		return
+	}
+
+	var actions map[string]model.ActionConfig
+	if err := json.Unmarshal([]byte(version.ActionsJSON), &actions); err != nil {
+		utils.SendFollowUpError(s, i, fmt.Sprintf("版本 #%d 的数据已损坏。", versionID))
+		return
+	}
+	savePunishConfigChange(s, i, db, actions, actorID, fmt.Sprintf("恢复到版本 #%d", versionID))
+}
+
+func displayPunishConfig(s *discordgo.Session, i *discordgo.Interaction, actions map[string]model.ActionConfig, actionKey string) {
+	if actionKey == "" {
+		keys := utils.OrderedPunishActions(actions)
+
+		embed := &discordgo.MessageEmbed{
+			Title: "处罚类型列表",
+			Color: 0x5865F2,
+		}
+		if len(keys) == 0 {
+			embed.Description = "本服务器尚未配置任何处罚类型。"
+		}
+		for _, key := range keys {
+			action := actions[key]
+			value := fmt.Sprintf("等级数: %d\n管理频道: %s", len(action.Data), channelMention(action.AdminChannelID))
+			if action.Hidden {
+				value += "\n不在快速处罚中显示"
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  fmt.Sprintf("%s · %s", key, action.Name),
+				Value: value,
+			})
+		}
+		s.InteractionResponseEdit(i, &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}})
+		return
+	}
+
+	action, ok := actions[actionKey]
+	if !ok {
+		utils.SendFollowUpError(s, i, fmt.Sprintf("找不到处罚类型 `%s`。", actionKey))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("处罚类型 %s · %s", actionKey, action.Name),
+		Description: fmt.Sprintf("管理频道: %s\n统计周期: `%s`", channelMention(action.AdminChannelID), action.Timescale),
+		Color:       0x5865F2,
+	}
+	for idx, key := range sortedLevelKeys(action.Data) {
+		level := action.Data[key]
+		value := fmt.Sprintf("禁言: `%s` · 添加身份组: %s", level.Timeout, utils.RoleMentionList(level.AddRole))
+		if level.AddRoleTimeoutTime != "" {
+			value += fmt.Sprintf(" (`%s` 天)", level.AddRoleTimeoutTime)
+		}
+		value += "\n移除身份组: " + utils.RoleMentionList(level.RemoveRoleID)
+		if level.Ban != "" {
+			value += fmt.Sprintf("\n封禁: `%s`", level.Ban)
+			if level.BanDeleteMessageDays > 0 {
+				value += fmt.Sprintf(" (删除 %d 天内的消息)", level.BanDeleteMessageDays)
+			}
+		}
+		if level.RequiresApproval {
+			value += "\n需要第二位管理员确认"
+		}
+		if utils.PunishPreviewRequired(action, level) {
+			value += "\n执行前需要预览确认"
+		}
+		if level.Description != "" {
+			value += "\n" + utils.TruncateString(level.Description, 200)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("等级 %d", idx),
+			Value: value,
+		})
+	}
+	s.InteractionResponseEdit(i, &discordgo.WebhookEdit{Embeds: &[]*discordgo.MessageEmbed{embed}})
+}
+
+func displayPunishConfigHistory(s *discordgo.Session, i *discordgo.Interaction, page int) {
+	_, db, err := openPunishConfig()
+	if err != nil {
+		utils.SendFollowUpError(s, i, err.Error())
+		return
+	}
+	defer db.Close()
+
+	if page < 1 {
+		page = 1
+	}
+	versions, total, err := punishments_db.GetPunishConfigVersions(db, i.GuildID, configVersionsPerPage, (page-1)*configVersionsPerPage)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "检索配置历史失败。")
+		log.Printf("获取配置历史时出错: %v", err)
+		return
+	}
+	if total == 0 {
+		utils.SendFollowUp(s, i, "本服务器的处罚配置尚未保存过任何版本，当前使用 punish_config.json。")
+		return
+	}
+
+	totalPages := (total + configVersionsPerPage - 1) / configVersionsPerPage
+	embed := &discordgo.MessageEmbed{
+		Title: "处罚配置历史",
+		Color: 0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("第 %d 页，共 %d 页 | 共 %d 个版本", page, totalPages, total),
+		},
+	}
+	for _, version := range versions {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("版本 #%d", version.VersionID),
+			Value: fmt.Sprintf("%s\n操作人: <@%s> · <t:%d:f>", version.Comment, version.ActorID, version.CreatedAt),
+		})
+	}
+
+	components := utils.CreatePaginationComponents(page, totalPages, "punish_config_history")
+	s.InteractionResponseEdit(i, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
+
+func showActionEditModal(s *discordgo.Session, i *discordgo.InteractionCreate, actionKey string) {
+	if actionKey == "" || strings.Contains(actionKey, ":") {
+		utils.SendEphemeralResponse(s, i, "请提供有效的处罚类型标识（不能包含 `:`）。")
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig(punishConfigPath)
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "加载处罚配置失败。")
+		return
+	}
+
+	action, exists := punishConfig.PunishConfig[i.GuildID][actionKey]
+	if !exists {
+		action = model.ActionConfig{Name: actionKey, GuildID: i.GuildID, Timescale: "30d"}
+	}
+	// 等级通过 level_edit 单独编辑
+	action.Data = nil
+
+	showConfigJSONModal(s, i, "punish_config_action_modal:"+actionKey, fmt.Sprintf("编辑处罚类型 %s", actionKey), action)
+}
+
+func showLevelEditModal(s *discordgo.Session, i *discordgo.InteractionCreate, actionKey string, level int) {
+	punishConfig, err := utils.LoadPunishConfig(punishConfigPath)
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "加载处罚配置失败。")
+		return
+	}
+
+	action, ok := punishConfig.PunishConfig[i.GuildID][actionKey]
+	if !ok {
+		utils.SendEphemeralResponse(s, i, fmt.Sprintf("找不到处罚类型 `%s`，请先使用 action_edit 创建。", actionKey))
+		return
+	}
+
+	order := sortedLevelKeys(action.Data)
+	if level < 0 {
+		level = len(order)
+	}
+	if level > len(order) {
+		utils.SendEphemeralResponse(s, i, "等级必须连续，请先添加前面的等级。")
+		return
+	}
+
+	punishLevel := model.PunishLevel{Timeout: "0", RemoveRoleID: []string{}, AddRole: []string{}}
+	if level < len(order) {
+		punishLevel = action.Data[order[level]]
+	}
+
+	showConfigJSONModal(s, i, fmt.Sprintf("punish_config_level_modal:%s:%d", actionKey, level), fmt.Sprintf("编辑 %s 等级 %d", actionKey, level), punishLevel)
+}
+
+// showConfigJSONModal 弹出一个预填了 JSON 的编辑框
+func showConfigJSONModal(s *discordgo.Session, i *discordgo.InteractionCreate, customID, title string, value interface{}) {
+	jsonData, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "序列化配置失败。")
+		return
+	}
+	if len(jsonData) > 4000 {
+		utils.SendEphemeralResponse(s, i, "配置内容过长，无法在对话框中编辑，请修改 punish_config.json 后使用 import 导入。")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID,
+			Title:    utils.TruncateString(title, 45),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID: "config_json",
+							Label:    "配置 (JSON)",
+							Style:    discordgo.TextInputParagraph,
+							Value:    string(jsonData),
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("无法显示配置编辑框: %v", err)
+	}
+}
+
+// sortedLevelKeys 按数字顺序返回等级键
+func sortedLevelKeys(levels map[string]model.PunishLevel) []string {
+	keys := make([]string, 0, len(levels))
+	for key := range levels {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		na, errA := strconv.Atoi(keys[a])
+		nb, errB := strconv.Atoi(keys[b])
+		if errA != nil || errB != nil {
+			return keys[a] < keys[b]
+		}
+		return na < nb
+	})
+	return keys
+}
+
+// renumberLevels 按给定顺序将等级重新编号为 0..n-1，并同步更新积分阈值
+func renumberLevels(action model.ActionConfig, order []string) model.ActionConfig {
+	levels := make(map[string]model.PunishLevel, len(order))
+	var thresholds map[string]float64
+	if action.Escalation != nil && action.Escalation.Thresholds != nil {
+		thresholds = make(map[string]float64)
+	}
+
+	for idx, oldKey := range order {
+		newKey := strconv.Itoa(idx)
+		levels[newKey] = action.Data[oldKey]
+		if thresholds != nil {
+			if threshold, ok := action.Escalation.Thresholds[oldKey]; ok {
+				thresholds[newKey] = threshold
+			}
+		}
+	}
+
+	action.Data = levels
+	if thresholds != nil {
+		escalation := *action.Escalation
+		escalation.Thresholds = thresholds
+		action.Escalation = &escalation
+	}
+	return action
+}
+
+func channelMention(channelID string) string {
+	if channelID == "" {
+		return "未设置"
+	}
+	return fmt.Sprintf("<#%s>", channelID)
+}
@@ -1,10 +1,14 @@
 package punish_admin
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"newer_helper/model"
+	"newer_helper/scanner"
 	"newer_helper/utils"
 	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
 	"strconv"
 
 	"github.com/bwmarrin/discordgo"
@@ -12,7 +16,7 @@ import (
 )
 
 // HandlePunishDeleteCommand 处理 /punish_delete 命令
-func HandlePunishDeleteCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func HandlePunishDeleteCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -49,16 +53,36 @@ func HandlePunishDeleteCommand(s *discordgo.Session, i *discordgo.InteractionCre
 	}
 	defer punishDB.Close()
 
-	deletePunishment(s, i, punishDB, punishmentID)
+	reason := ""
+	if opt, ok := optionMap["reason"]; ok {
+		reason = opt.StringValue()
+	}
+
+	deletePunishment(ctx, s, i, punishDB, punishmentID, reason)
 }
 
-func deletePunishment(s *discordgo.Session, i *discordgo.InteractionCreate, db *sqlx.DB, punishmentID int64) {
-	err := punishments_db.DeletePunishmentRecordByID(db, punishmentID)
+func deletePunishment(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, db *sqlx.DB, punishmentID int64, reason string) {
+	record, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+	if err != nil || record.GuildID != i.GuildID {
+		utils.SendFollowUpError(s, i.Interaction, "找不到相关的惩罚记录。")
+		return
+	}
+
+	// 软删除，记录可在保留期内通过 /punish_restore 恢复
+	err = punishments_db.SoftDeletePunishmentRecord(db, punishmentID, i.Member.User.ID, reason)
 	if err != nil {
 		utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("删除惩罚记录失败: %v", err))
 		return
 	}
-	content := fmt.Sprintf("✅ 成功删除ID为 %d 的惩罚记录。", punishmentID)
+	scanner.CancelPunishmentRoles(punishmentID)
+
+	// 写入审计日志
+	if deleted, err := punishments_db.GetDeletedPunishmentRecordByID(db, punishmentID); err == nil {
+		if err := punishments_db.RecordAudit(ctx, db, model.AuditActionDelete, i.Member.User.ID, reason, record, deleted); err != nil {
+			trace.Logf(ctx, "无法写入处罚 %d 的审计日志: %v", punishmentID, err)
+		}
+	}
+	content := fmt.Sprintf("✅ 成功删除ID为 %d 的惩罚记录。超级管理员可在保留期内使用 /punish_restore 恢复。", punishmentID)
 	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 		Content: &content,
 	})
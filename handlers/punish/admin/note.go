@@ -0,0 +1,264 @@
+package punish_admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"newer_helper/bot"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jmoiron/sqlx"
+)
+
+const notesPerPage = 8
+
+// userNotesPreviewLimit 是处罚预览和搜索结果中显示的备注数量
+const userNotesPreviewLimit = 5
+
+// userNoteKindNames 备注类型的显示名称
+var userNoteKindNames = map[string]string{
+	model.UserNoteKindNote:    "📝 备注",
+	model.UserNoteKindWarning: "⚠️ 警告",
+}
+
+// HandlePunishNoteCommand 处理 /punish_note 命令
+func HandlePunishNoteCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("无法延迟交互: %v", err)
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	operation := optionMap["operation"].StringValue()
+	var targetUser *discordgo.User
+	if opt, ok := optionMap["user"]; ok {
+		targetUser = opt.UserValue(s)
+	}
+	content := ""
+	if opt, ok := optionMap["content"]; ok {
+		content = strings.TrimSpace(opt.StringValue())
+	}
+
+	if operation == "list" {
+		if targetUser == nil {
+			utils.SendFollowUpError(s, i.Interaction, "请指定要查看备注的用户。")
+			return
+		}
+		displayUserNotes(s, i.Interaction, targetUser.ID, 1)
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "连接惩罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	switch operation {
+	case "add", "warn":
+		if targetUser == nil || content == "" {
+			utils.SendFollowUpError(s, i.Interaction, "请指定用户和内容。")
+			return
+		}
+		kind := model.UserNoteKindNote
+		if operation == "warn" {
+			kind = model.UserNoteKindWarning
+		}
+		addUserNote(ctx, s, i, b, db, punishConfig, targetUser, kind, content)
+	case "delete":
+		opt, ok := optionMap["note_id"]
+		if !ok {
+			utils.SendFollowUpError(s, i.Interaction, "请指定要删除的备注ID。")
+			return
+		}
+		deleteUserNote(ctx, s, i, b, db, opt.IntValue())
+	default:
+		utils.SendFollowUpError(s, i.Interaction, "未知的操作。")
+	}
+}
+
+func addUserNote(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot, db *sqlx.DB, punishConfig *model.PunishConfig, targetUser *discordgo.User, kind, content string) {
+	note := model.UserNote{
+		GuildID:   i.GuildID,
+		UserID:    targetUser.ID,
+		AuthorID:  i.Member.User.ID,
+		Kind:      kind,
+		Content:   content,
+		CreatedAt: time.Now().Unix(),
+	}
+	noteID, err := punishments_db.AddUserNote(db, note)
+	if err != nil {
+		trace.Logf(ctx, "无法为用户 %s 添加备注: %v", targetUser.ID, err)
+		utils.SendFollowUpError(s, i.Interaction, "添加备注失败。")
+		return
+	}
+
+	kindName := userNoteKindNames[kind]
+	utils.LogInfoCtx(ctx, s, b.GetConfig().LogChannelID, "用户备注", "添加"+kindName,
+		fmt.Sprintf("管理员 <@%s> 为用户 <@%s> 添加了%s #%d: %s", i.Member.User.ID, targetUser.ID, kindName, noteID, utils.TruncateString(content, 500)))
+
+	message := fmt.Sprintf("✅ 已为 %s 添加%s #%d。", targetUser.Mention(), kindName, noteID)
+	if kind == model.UserNoteKindWarning {
+		var counted []string
+		for _, actionConfig := range punishConfig.PunishConfig[i.GuildID] {
+			if actionConfig.CountWarnings {
+				counted = append(counted, actionConfig.Name)
+			}
+		}
+		if len(counted) > 0 {
+			message += fmt.Sprintf("\n该警告将计入以下处罚类型的升级: %s", strings.Join(counted, "、"))
+		}
+	}
+	utils.SendFollowUp(s, i.Interaction, message)
+}
+
+func deleteUserNote(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot, db *sqlx.DB, noteID int64) {
+	note, err := punishments_db.GetUserNoteByID(db, noteID)
+	if err != nil || note.GuildID != i.GuildID {
+		utils.SendFollowUpError(s, i.Interaction, "找不到该备注。")
+		return
+	}
+
+	if err := punishments_db.DeleteUserNote(db, noteID); err != nil {
+		trace.Logf(ctx, "无法删除备注 %d: %v", noteID, err)
+		utils.SendFollowUpError(s, i.Interaction, "删除备注失败。")
+		return
+	}
+
+	kindName := userNoteKindNames[note.Kind]
+	utils.LogInfoCtx(ctx, s, b.GetConfig().LogChannelID, "用户备注", "删除"+kindName,
+		fmt.Sprintf("管理员 <@%s> 删除了用户 <@%s> 的%s #%d（作者 <@%s>）: %s", i.Member.User.ID, note.UserID, kindName, noteID, note.AuthorID, utils.TruncateString(note.Content, 500)))
+	utils.SendFollowUp(s, i.Interaction, fmt.Sprintf("✅ 已删除%s #%d。", kindName, noteID))
+}
+
+// HandlePunishNotePagination 处理备注列表的翻页按钮
+func HandlePunishNotePagination(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Failed to defer note pagination interaction: %v", err)
+		return
+	}
+
+	customID := i.MessageComponentData().CustomID
+	parts := strings.Split(customID, ":")
+	if len(parts) != 3 {
+		log.Printf("Invalid custom ID for note pagination: %s", customID)
+		return
+	}
+
+	page, _ := strconv.Atoi(parts[1])
+	displayUserNotes(s, i.Interaction, parts[2], page)
+}
+
+func displayUserNotes(s *discordgo.Session, i *discordgo.Interaction, userID string, page int) {
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		utils.SendFollowUpError(s, i, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "连接惩罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	if page < 1 {
+		page = 1
+	}
+	notes, total, err := punishments_db.GetUserNotes(db, i.GuildID, userID, notesPerPage, (page-1)*notesPerPage)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "检索备注失败。")
+		log.Printf("获取用户备注时出错: %v", err)
+		return
+	}
+
+	if total == 0 {
+		utils.SendFollowUp(s, i, "该用户没有备注或警告。")
+		return
+	}
+
+	totalPages := (total + notesPerPage - 1) / notesPerPage
+	if page > totalPages {
+		page = totalPages
+		notes, _, err = punishments_db.GetUserNotes(db, i.GuildID, userID, notesPerPage, (page-1)*notesPerPage)
+		if err != nil {
+			utils.SendFollowUpError(s, i, "检索备注失败。")
+			log.Printf("获取用户备注时出错: %v", err)
+			return
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "用户备注与警告",
+		Description: fmt.Sprintf("用户: <@%s>", userID),
+		Color:       0x5865F2,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("第 %d 页，共 %d 页 | 共 %d 条记录", page, totalPages, total),
+		},
+	}
+	for _, note := range notes {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("#%d · %s", note.NoteID, userNoteKindNames[note.Kind]),
+			Value: fmt.Sprintf("作者: <@%s>\n时间: <t:%d:f>\n%s", note.AuthorID, note.CreatedAt, utils.TruncateString(note.Content, 900)),
+		})
+	}
+
+	components := utils.CreatePaginationComponents(page, totalPages, "punish_note_page", userID)
+
+	s.InteractionResponseEdit(i, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	})
+}
+
+// BuildUserNotesField 生成显示用户最近备注和警告的嵌入字段，没有备注时返回 nil
+func BuildUserNotesField(db *sqlx.DB, guildID, userID string) *discordgo.MessageEmbedField {
+	notes, total, err := punishments_db.GetUserNotes(db, guildID, userID, userNotesPreviewLimit, 0)
+	if err != nil {
+		log.Printf("获取用户 %s 的备注时出错: %v", userID, err)
+		return nil
+	}
+	if total == 0 {
+		return nil
+	}
+
+	lines := make([]string, 0, len(notes)+1)
+	for _, note := range notes {
+		lines = append(lines, fmt.Sprintf("%s #%d · <@%s> · <t:%d:d>: %s", userNoteKindNames[note.Kind], note.NoteID, note.AuthorID, note.CreatedAt, utils.TruncateString(note.Content, 100)))
+	}
+	if total > len(notes) {
+		lines = append(lines, fmt.Sprintf("……另有 %d 条，使用 /punish_note 查看全部", total-len(notes)))
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:  fmt.Sprintf("管理员备注与警告 (%d)", total),
+		Value: utils.TruncateString(strings.Join(lines, "\n"), 1024),
+	}
+}
@@ -0,0 +1,54 @@
+package punish_admin
+
+import (
+	"log"
+	"newer_helper/scanner"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandlePunishReconcileCommand 处理 /punish_reconcile 命令，立即核对当前服务器的处罚身份组
+func HandlePunishReconcileCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("无法延迟交互: %v", err)
+		return
+	}
+
+	autoFix := false
+	for _, opt := range i.ApplicationCommandData().Options {
+		if opt.Name == "fix" {
+			autoFix = opt.BoolValue()
+		}
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "连接惩罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	report, err := scanner.ReconcilePunishmentRoles(s, db, i.GuildID, autoFix)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "核对处罚身份组失败。")
+		log.Printf("核对处罚身份组时出错: %v", err)
+		return
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{scanner.BuildReconcileEmbed(report)},
+	})
+}
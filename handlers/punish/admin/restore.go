@@ -0,0 +1,98 @@
+package punish_admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/scanner"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"strconv"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandlePunishRestoreCommand 处理 /punish_restore 命令
+func HandlePunishRestoreCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("无法延迟交互: %v", err)
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	punishmentIDStr := optionMap["punishment_id"].StringValue()
+	punishmentID, convErr := strconv.ParseInt(punishmentIDStr, 10, 64)
+	if convErr != nil {
+		utils.SendFollowUpError(s, i.Interaction, "无效的惩罚ID。")
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "连接惩罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	record, err := punishments_db.GetDeletedPunishmentRecordByID(db, punishmentID)
+	if err != nil || record.GuildID != i.GuildID {
+		utils.SendFollowUpError(s, i.Interaction, "找不到已删除的相关惩罚记录，它可能未被删除或已被永久清除。")
+		return
+	}
+
+	// 撤销时已解除身份组、禁言等处罚，恢复后的记录不能再是生效状态
+	revoked, err := punishments_db.HasAuditEntry(db, punishmentID, model.AuditActionRevoke)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "检查惩罚记录的撤销状态失败。")
+		log.Printf("检查处罚 %d 是否已撤销时出错: %v", punishmentID, err)
+		return
+	}
+
+	err = punishments_db.RestorePunishmentRecord(db, punishmentID)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("恢复惩罚记录失败: %v", err))
+		return
+	}
+
+	// 写入审计日志
+	if restored, err := punishments_db.GetPunishmentRecordByID(db, punishmentID); err == nil {
+		if err := punishments_db.RecordAudit(ctx, db, model.AuditActionRestore, i.Member.User.ID, "", record, restored); err != nil {
+			trace.Logf(ctx, "无法写入处罚 %d 的审计日志: %v", punishmentID, err)
+		}
+		if revoked && restored.IsInEffect() {
+			if err := punishments_db.ChangePunishmentStatus(ctx, db, punishmentID, model.PunishmentStatusCancelled, i.Member.User.ID, "恢复已撤销的处罚记录"); err != nil {
+				trace.Logf(ctx, "无法将恢复的处罚 %d 标记为已取消: %v", punishmentID, err)
+			}
+			restored.PunishmentStatus = model.PunishmentStatusCancelled
+		}
+		scanner.SchedulePunishmentRecord(*restored)
+	}
+
+	// 恢复的只是记录，撤销时解除的身份组、禁言等不会重新执行
+	content := fmt.Sprintf("✅ 成功恢复ID为 %d 的惩罚记录（原删除人: <@%s>，删除时间: <t:%d:f>）。\n注意：此操作仅恢复记录，不会重新对用户执行处罚。",
+		punishmentID, record.DeletedBy, record.DeletedAt)
+	if revoked {
+		content += "\n该处罚已被撤销，恢复后的记录状态为已取消。"
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
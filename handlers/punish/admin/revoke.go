@@ -1,12 +1,16 @@
 package punish_admin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"newer_helper/grpc/events"
 	"newer_helper/model"
+	"newer_helper/scanner"
 	"newer_helper/utils"
 	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
 	"strconv"
 	"time"
 
@@ -15,7 +19,7 @@ import (
 )
 
 // HandlePunishRevokeCommand 处理 /punish_revoke 命令
-func HandlePunishRevokeCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func HandlePunishRevokeCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -52,22 +56,87 @@ func HandlePunishRevokeCommand(s *discordgo.Session, i *discordgo.InteractionCre
 	}
 	defer punishDB.Close()
 
+	reason := ""
+	if opt, ok := optionMap["reason"]; ok {
+		reason = opt.StringValue()
+	}
+
 	record, err := punishments_db.GetPunishmentRecordByID(punishDB, punishmentID)
 	if err != nil {
 		utils.SendFollowUpError(s, i.Interaction, "找不到相关的惩罚记录。")
 		log.Printf("查找要执行操作的惩罚记录时出错: %v", err)
 		return
 	}
+	// 只能撤销本服务器的处罚，撤销会在记录所属的服务器解除封禁和禁言
+	if record.GuildID != i.GuildID {
+		utils.SendFollowUpError(s, i.Interaction, "找不到相关的惩罚记录。")
+		return
+	}
+
+	revokePunishment(ctx, s, i, punishDB, record, reason)
+}
+
+func revokePunishment(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, db *sqlx.DB, record *model.PunishmentRecord, reason string) {
+	if err := RevokePunishment(ctx, s, db, record, i.Member.User.ID, i.Member.User.Username, reason); err != nil {
+		utils.SendFollowUpError(s, i.Interaction, err.Error())
+		return
+	}
 
-	revokePunishment(s, i, punishDB, record)
+	content := fmt.Sprintf("✅ 成功撤销并删除ID为 %d 的惩罚记录。", record.PunishmentID)
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
 }
 
-func revokePunishment(s *discordgo.Session, i *discordgo.InteractionCreate, db *sqlx.DB, record *model.PunishmentRecord) {
+// RevokePunishment 撤销处罚：恢复用户的身份组和禁言状态，软删除记录，写入审计日志并通知管理频道。
+// 返回的错误可直接展示给操作者。
+func RevokePunishment(ctx context.Context, s *discordgo.Session, db *sqlx.DB, record *model.PunishmentRecord, adminID, adminUsername, reason string) error {
 	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
 	if err != nil {
-		utils.SendFollowUpError(s, i.Interaction, "加载处罚配置失败。")
-		return
+		return fmt.Errorf("加载处罚配置失败。")
+	}
+
+	actionConfig, err := RestorePunishmentRoles(s, db, punishConfig, record)
+	if err != nil {
+		return err
+	}
+
+	// 软删除惩罚记录
+	err = punishments_db.SoftDeletePunishmentRecord(db, record.PunishmentID, adminID, reason)
+	if err != nil {
+		return fmt.Errorf("撤销后删除惩罚记录失败: %v", err)
+	}
+
+	// 写入审计日志
+	if deleted, err := punishments_db.GetDeletedPunishmentRecordByID(db, record.PunishmentID); err == nil {
+		if err := punishments_db.RecordAudit(ctx, db, model.AuditActionRevoke, adminID, reason, record, deleted); err != nil {
+			trace.Logf(ctx, "无法写入处罚 %d 的审计日志: %v", record.PunishmentID, err)
+		}
+	}
+
+	// 通知网关上的其他服务
+	events.Publish(events.PunishRevoked, events.NewPunishmentEvent(record, adminID), map[string]string{"guild_id": record.GuildID, "reason": reason, "trace_id": trace.ID(ctx)})
+
+	// 发送撤销通知到admin channel
+	if actionConfig.AdminChannelID != "" {
+		revocationEmbed := buildRevocationEmbed(record, actionConfig, adminUsername, reason)
+		if traceID := trace.ID(ctx); traceID != "" {
+			revocationEmbed.Footer = &discordgo.MessageEmbedFooter{Text: trace.Footer(ctx, "")}
+		}
+		_, err = s.ChannelMessageSendEmbed(actionConfig.AdminChannelID, revocationEmbed)
+		if err != nil {
+			trace.Logf(ctx, "无法发送撤销通知到admin channel %s: %v", actionConfig.AdminChannelID, err)
+			// 不影响主流程，继续执行
+		}
 	}
+
+	return nil
+}
+
+// RestorePunishmentRoles undoes the Discord side effects of a punishment: it lifts bans and timeouts,
+// removes roles added by the punishment and restores the revocation role. The record itself is left untouched.
+// Returned errors are suitable for showing to the operator.
+func RestorePunishmentRoles(s *discordgo.Session, db *sqlx.DB, punishConfig *model.PunishConfig, record *model.PunishmentRecord) (model.ActionConfig, error) {
 	// 检查用户是否被封禁，如果是则解封
 	_, banErr := s.GuildBan(record.GuildID, record.UserID)
 	if banErr == nil { // 如果 err 为 nil，则用户被封禁
@@ -81,39 +150,26 @@ func revokePunishment(s *discordgo.Session, i *discordgo.InteractionCreate, db *
 	// 获取特定于服务器的操作配置
 	guildActions, ok := punishConfig.PunishConfig[record.GuildID]
 	if !ok {
-		utils.SendFollowUpError(s, i.Interaction, "找不到此服务器的处罚配置。")
-		return
+		return model.ActionConfig{}, fmt.Errorf("找不到此服务器的处罚配置。")
 	}
 
 	// 获取具体的操作配置
 	actionConfig, ok := guildActions[record.ActionType]
 	if !ok {
-		utils.SendFollowUpError(s, i.Interaction, "找不到此处罚类型的配置。")
-		return
+		return model.ActionConfig{}, fmt.Errorf("找不到此处罚类型的配置。")
 	}
 
-	// 获取用户相同类型的所有处罚，以确定处罚级别
-	userPunishments, err := punishments_db.GetPunishmentRecordsByUserIDAndActionType(db, record.UserID, record.ActionType)
+	// 确定该处罚实际执行的等级
+	punishmentLevel, err := appliedLevelKey(db, record)
 	if err != nil {
-		utils.SendFollowUpError(s, i.Interaction, "获取用户处罚历史失败。")
-		return
-	}
-
-	punishmentIndex := -1
-	for i, p := range userPunishments {
-		if p.PunishmentID == record.PunishmentID {
-			punishmentIndex = i
-			break
-		}
+		return model.ActionConfig{}, err
 	}
+	levelData, levelFound := actionConfig.Data[punishmentLevel]
 
-	if punishmentIndex != -1 {
-		punishmentLevel := strconv.Itoa(punishmentIndex)
-		if levelData, ok := actionConfig.Data[punishmentLevel]; ok {
-			for _, roleID := range levelData.AddRole {
-				if roleID != "0" {
-					s.GuildMemberRoleRemove(record.GuildID, record.UserID, roleID)
-				}
+	if levelFound {
+		for _, roleID := range levelData.AddRole {
+			if roleID != "0" {
+				s.GuildMemberRoleRemove(record.GuildID, record.UserID, roleID)
 			}
 		}
 	}
@@ -123,14 +179,11 @@ func revokePunishment(s *discordgo.Session, i *discordgo.InteractionCreate, db *
 		if revConfig.RecoverRoleID != "" && revConfig.RecoverRoleID != "0" {
 			// 检查原始惩罚是否实际移除了任何角色
 			rolesWereRemoved := false
-			if punishmentIndex != -1 {
-				punishmentLevel := strconv.Itoa(punishmentIndex)
-				if levelData, ok := actionConfig.Data[punishmentLevel]; ok {
-					for _, roleID := range levelData.RemoveRoleID {
-						if roleID != "0" {
-							rolesWereRemoved = true
-							break
-						}
+			if levelFound {
+				for _, roleID := range levelData.RemoveRoleID {
+					if roleID != "0" {
+						rolesWereRemoved = true
+						break
 					}
 				}
 			}
@@ -158,31 +211,14 @@ func revokePunishment(s *discordgo.Session, i *discordgo.InteractionCreate, db *
 	// 移除禁言
 	s.GuildMemberTimeout(record.GuildID, record.UserID, nil)
 
-	// 删除惩罚记录
-	err = punishments_db.DeletePunishmentRecordByID(db, record.PunishmentID)
-	if err != nil {
-		utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("撤销后删除惩罚记录失败: %v", err))
-		return
-	}
-
-	// 发送撤销通知到admin channel
-	if actionConfig.AdminChannelID != "" {
-		revocationEmbed := buildRevocationEmbed(record, actionConfig, i.Member.User.Username)
-		_, err = s.ChannelMessageSendEmbed(actionConfig.AdminChannelID, revocationEmbed)
-		if err != nil {
-			log.Printf("无法发送撤销通知到admin channel %s: %v", actionConfig.AdminChannelID, err)
-			// 不影响主流程，继续执行
-		}
-	}
+	// 取消尚未执行的临时身份组移除和解封计划
+	scanner.CancelPunishmentRoles(record.PunishmentID)
 
-	content := fmt.Sprintf("✅ 成功撤销并删除ID为 %d 的惩罚记录。", record.PunishmentID)
-	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: &content,
-	})
+	return actionConfig, nil
 }
 
 // buildRevocationEmbed creates an embed message for punishment revocation notification.
-func buildRevocationEmbed(record *model.PunishmentRecord, actionConfig model.ActionConfig, adminUsername string) *discordgo.MessageEmbed {
+func buildRevocationEmbed(record *model.PunishmentRecord, actionConfig model.ActionConfig, adminUsername, reason string) *discordgo.MessageEmbed {
 	embed := &discordgo.MessageEmbed{
 		Title: "✅ 处罚撤销通知",
 		Color: 0x00FF00, // Green color for revocation
@@ -211,5 +247,38 @@ func buildRevocationEmbed(record *model.PunishmentRecord, actionConfig model.Act
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
+	if record.BanUntil != 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "封禁",
+			Value: "已解除封禁",
+		})
+	}
+
+	if reason != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "撤销原因",
+			Value: reason,
+		})
+	}
+
 	return embed
 }
+
+// appliedLevelKey 返回处罚执行时所用等级的键。
+// 旧记录没有保存等级，按该记录在用户同类型处罚中的序号推断。
+func appliedLevelKey(db *sqlx.DB, record *model.PunishmentRecord) (string, error) {
+	if record.LevelKey != "" {
+		return record.LevelKey, nil
+	}
+
+	userPunishments, err := punishments_db.GetPunishmentRecordsByUserIDAndActionType(db, record.UserID, record.ActionType)
+	if err != nil {
+		return "", fmt.Errorf("获取用户处罚历史失败。")
+	}
+	for i, p := range userPunishments {
+		if p.PunishmentID == record.PunishmentID {
+			return strconv.Itoa(i), nil
+		}
+	}
+	return "", nil
+}
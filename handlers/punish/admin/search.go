@@ -1,22 +1,40 @@
 package punish_admin
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"log"
+	"newer_helper/bot"
 	"newer_helper/model"
 	"newer_helper/utils"
 	punishments_db "newer_helper/utils/database/punishments"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/jmoiron/sqlx"
 )
 
 const recordsPerPageV2 = 5
 
+// punishmentStatusNames 处罚状态的显示名称
+var punishmentStatusNames = map[string]string{
+	model.PunishmentStatusActive:          "生效中",
+	model.PunishmentStatusCompleted:       "已完成",
+	model.PunishmentStatusCancelled:       "已撤销",
+	model.PunishmentStatusAppealed:        "申诉中",
+	model.PunishmentStatusAppealRejected:  "申诉被驳回",
+	model.PunishmentStatusPendingApproval: "待审批",
+	model.PunishmentStatusRejected:        "审批被拒绝",
+	model.PunishmentStatusExpired:         "审批已过期",
+}
+
 // HandlePunishSearchCommand 处理 /punish_search 命令
-func HandlePunishSearchCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func HandlePunishSearchCommand(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
 	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -33,14 +51,195 @@ func HandlePunishSearchCommand(s *discordgo.Session, i *discordgo.InteractionCre
 	for _, opt := range options {
 		optionMap[opt.Name] = opt
 	}
+	optionValue := func(name string) string {
+		if opt, ok := optionMap[name]; ok {
+			return strings.TrimSpace(opt.StringValue())
+		}
+		return ""
+	}
 
-	searchBy := optionMap["search_by"].StringValue()
-	input := optionMap["input"].StringValue()
+	search := &bot.PendingSearch{
+		SearchBy: optionValue("search_by"),
+		Input:    optionValue("input"),
+		Query:    optionValue("query"),
+		Since:    optionValue("since"),
+		Until:    optionValue("until"),
+		Action:   optionValue("action"),
+		Status:   optionValue("status"),
+		GuildID:  optionValue("guild_id"),
+	}
+	if (search.SearchBy == "") != (search.Input == "") {
+		utils.SendFollowUpError(s, i.Interaction, "search_by 和 input 需要同时提供。")
+		return
+	}
+	if *search == (bot.PendingSearch{}) {
+		utils.SendFollowUpError(s, i.Interaction, "请至少提供一个搜索条件。")
+		return
+	}
 
-	displayPunishmentsV2(s, i.Interaction, searchBy, input, 1)
+	startPunishmentSearch(s, i.Interaction, b, search, 1)
 }
 
-func displayPunishmentsV2(s *discordgo.Session, i *discordgo.Interaction, searchBy, input string, page int) {
+// HandlePunishPaginationV2 处理搜索结果的翻页按钮
+func HandlePunishPaginationV2(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Failed to defer pagination interaction: %v", err)
+		return
+	}
+
+	customID := i.MessageComponentData().CustomID
+	parts := strings.Split(customID, ":")
+	page, _ := strconv.Atoi(parts[1])
+
+	switch len(parts) {
+	case 3:
+		searchID := parts[2]
+		search, err := b.GetPendingSearch(searchID)
+		if err != nil {
+			if bot.IsPendingExpired(err) {
+				utils.SendFollowUpError(s, i.Interaction, "搜索结果已过期，请重新执行 /punish_search。")
+				return
+			}
+			log.Printf("Failed to load search %s: %v", searchID, err)
+			utils.SendFollowUpError(s, i.Interaction, "加载搜索条件失败。")
+			return
+		}
+		displayPunishmentsV2(s, i.Interaction, b, searchID, search, page)
+	case 4:
+		// Buttons sent before searches were stored carry the search type and ID themselves
+		startPunishmentSearch(s, i.Interaction, b, &bot.PendingSearch{SearchBy: parts[2], Input: parts[3]}, page)
+	default:
+		log.Printf("Invalid custom ID for v2 pagination: %s", customID)
+	}
+}
+
+// startPunishmentSearch 保存搜索条件以便翻页按钮引用，并显示指定页
+func startPunishmentSearch(s *discordgo.Session, i *discordgo.Interaction, b *bot.Bot, search *bot.PendingSearch, page int) {
+	if _, err := parseSearchFilter(search); err != nil {
+		utils.SendFollowUpError(s, i, err.Error())
+		return
+	}
+
+	searchIDBytes := make([]byte, 8)
+	if _, err := rand.Read(searchIDBytes); err != nil {
+		log.Printf("Error generating random ID for punishment search: %v", err)
+		utils.SendFollowUpError(s, i, "保存搜索条件失败。")
+		return
+	}
+	searchID := hex.EncodeToString(searchIDBytes)
+	if err := b.SavePendingSearch(searchID, search); err != nil {
+		log.Printf("Error saving punishment search: %v", err)
+		utils.SendFollowUpError(s, i, "保存搜索条件失败。")
+		return
+	}
+
+	displayPunishmentsV2(s, i, b, searchID, search, page)
+}
+
+// parseSearchFilter 将搜索条件转换为数据库查询条件，不包含服务器范围
+func parseSearchFilter(search *bot.PendingSearch) (punishments_db.SearchFilter, error) {
+	filter := punishments_db.SearchFilter{
+		Query:      search.Query,
+		ActionType: search.Action,
+		Status:     search.Status,
+	}
+
+	switch search.SearchBy {
+	case "":
+	case "punishment_id":
+		id, err := strconv.ParseInt(search.Input, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("无效的惩罚ID。")
+		}
+		filter.PunishmentID = id
+	case "punished_user_id":
+		filter.UserID = search.Input
+	case "punisher_id":
+		filter.AdminID = search.Input
+	default:
+		return filter, fmt.Errorf("无效的搜索方式。")
+	}
+
+	if search.Status != "" {
+		if _, ok := punishmentStatusNames[search.Status]; !ok {
+			return filter, fmt.Errorf("无效的处罚状态。")
+		}
+	}
+	if search.Since != "" {
+		t, err := time.ParseInLocation(auditDateLayout, search.Since, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("无效的开始日期，请使用 YYYY-MM-DD 格式。")
+		}
+		filter.Since = t.Unix()
+	}
+	if search.Until != "" {
+		t, err := time.ParseInLocation(auditDateLayout, search.Until, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("无效的结束日期，请使用 YYYY-MM-DD 格式。")
+		}
+		// 结束日期包含当天
+		filter.Until = t.AddDate(0, 0, 1).Unix()
+	}
+	if filter.Since != 0 && filter.Until != 0 && filter.Since >= filter.Until {
+		return filter, fmt.Errorf("开始日期必须早于结束日期。")
+	}
+
+	return filter, nil
+}
+
+// adminGuildIDs 返回调用者拥有管理权限的已配置服务器，每次翻页都会重新检查
+func adminGuildIDs(s *discordgo.Session, i *discordgo.Interaction, config *model.Config) []string {
+	if i.Member == nil || i.Member.User == nil {
+		return nil
+	}
+	userID := i.Member.User.ID
+
+	var guildIDs []string
+	for guildID, serverConfig := range config.ServerConfigs {
+		var roles []string
+		if guildID == i.GuildID {
+			roles = i.Member.Roles
+		} else {
+			member, err := s.State.Member(guildID, userID)
+			if err != nil {
+				member, err = s.GuildMember(guildID, userID)
+			}
+			if err == nil {
+				roles = member.Roles
+			}
+		}
+		permissionLevel := utils.CheckPermission(roles, userID, serverConfig.AdminRoleIDs, nil, config.DeveloperUserIDs, config.SuperAdminRoleIDs)
+		if permissionLevel == utils.AdminPermission || permissionLevel == utils.SuperAdminPermission || permissionLevel == utils.DeveloperPermission {
+			guildIDs = append(guildIDs, guildID)
+		}
+	}
+	sort.Strings(guildIDs)
+	return guildIDs
+}
+
+func displayPunishmentsV2(s *discordgo.Session, i *discordgo.Interaction, b *bot.Bot, searchID string, search *bot.PendingSearch, page int) {
+	filter, err := parseSearchFilter(search)
+	if err != nil {
+		utils.SendFollowUpError(s, i, err.Error())
+		return
+	}
+
+	filter.GuildIDs = adminGuildIDs(s, i, b.GetConfig())
+	if len(filter.GuildIDs) == 0 {
+		utils.SendFollowUpError(s, i, "你没有任何服务器的管理权限。")
+		return
+	}
+	if search.GuildID != "" {
+		if !slices.Contains(filter.GuildIDs, search.GuildID) {
+			utils.SendFollowUpError(s, i, "你没有该服务器的管理权限。")
+			return
+		}
+		filter.GuildIDs = []string{search.GuildID}
+	}
+
 	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
 	if err != nil {
 		utils.SendFollowUpError(s, i, "加载处罚配置失败。")
@@ -53,79 +252,117 @@ func displayPunishmentsV2(s *discordgo.Session, i *discordgo.Interaction, search
 	}
 	defer db.Close()
 
-	var records []model.PunishmentRecord
-	var title, description string
+	if page < 1 {
+		page = 1
+	}
+	records, total, err := punishments_db.SearchPunishments(db, filter, recordsPerPageV2, (page-1)*recordsPerPageV2)
+	if err != nil {
+		utils.SendFollowUpError(s, i, "检索惩罚记录失败。")
+		log.Printf("获取惩罚记录时出错: %v", err)
+		return
+	}
 
-	switch searchBy {
-	case "punishment_id":
-		id, convErr := strconv.ParseInt(input, 10, 64)
-		if convErr != nil {
-			utils.SendFollowUpError(s, i, "无效的惩罚ID。")
-			return
+	// 按用户搜索时附上该用户在各服务器的备注和警告，即使没有处罚记录也会显示
+	var noteFields []*discordgo.MessageEmbedField
+	if search.SearchBy == "punished_user_id" {
+		for _, guildID := range filter.GuildIDs {
+			field := BuildUserNotesField(db, guildID, search.Input)
+			if field == nil {
+				continue
+			}
+			if len(filter.GuildIDs) > 1 {
+				field.Name += fmt.Sprintf(" · 服务器 %s", guildID)
+			}
+			noteFields = append(noteFields, field)
 		}
-		record, getErr := punishments_db.GetPunishmentRecordByID(db, id)
-		if getErr != nil {
-			utils.SendFollowUpError(s, i, "找不到该ID的惩罚记录。")
+	}
+
+	if total == 0 && len(noteFields) == 0 {
+		utils.SendFollowUp(s, i, "未找到惩罚记录。")
+		return
+	}
+
+	totalPages := max((total+recordsPerPageV2-1)/recordsPerPageV2, 1)
+	if page > totalPages {
+		page = totalPages
+		records, _, err = punishments_db.SearchPunishments(db, filter, recordsPerPageV2, (page-1)*recordsPerPageV2)
+		if err != nil {
+			utils.SendFollowUpError(s, i, "检索惩罚记录失败。")
+			log.Printf("获取惩罚记录时出错: %v", err)
 			return
 		}
-		records = append(records, *record)
-		title = "惩罚记录 ID: " + input
+	}
+
+	title := "处罚记录搜索结果"
+	var descriptionLines []string
+	switch search.SearchBy {
+	case "punishment_id":
+		title = "惩罚记录 ID: " + search.Input
 	case "punished_user_id":
-		records, err = punishments_db.GetPunishmentRecordsByUserID(db, input, nil)
-		user, uErr := s.User(input)
 		title = "用户的惩罚记录"
-		if uErr == nil {
+		if user, uErr := s.User(search.Input); uErr == nil {
 			title = fmt.Sprintf("用户 %s 的惩罚记录", user.Username)
 		}
-		description = fmt.Sprintf("用户: <@%s>", input)
+		descriptionLines = append(descriptionLines, fmt.Sprintf("用户: <@%s>", search.Input))
 	case "punisher_id":
-		records, err = punishments_db.GetPunishmentRecordsByAdminID(db, input)
-		user, uErr := s.User(input)
 		title = "管理员执行的惩罚记录"
-		if uErr == nil {
+		if user, uErr := s.User(search.Input); uErr == nil {
 			title = fmt.Sprintf("管理员 %s 执行的惩罚记录", user.Username)
 		}
-		description = fmt.Sprintf("管理员: <@%s>", input)
+		descriptionLines = append(descriptionLines, fmt.Sprintf("管理员: <@%s>", search.Input))
 	}
-
-	if err != nil {
-		utils.SendFollowUpError(s, i, "检索惩罚记录失败。")
-		log.Printf("获取惩罚记录时出错: %v", err)
-		return
+	if search.Query != "" {
+		descriptionLines = append(descriptionLines, fmt.Sprintf("关键词: `%s`", search.Query))
 	}
-
-	if len(records) == 0 {
-		utils.SendFollowUp(s, i, "未找到惩罚记录。")
-		return
+	if search.Since != "" || search.Until != "" {
+		descriptionLines = append(descriptionLines, fmt.Sprintf("日期: `%s` ~ `%s`", search.Since, search.Until))
 	}
-
-	totalPages := (len(records) + recordsPerPageV2 - 1) / recordsPerPageV2
-	if page < 1 {
-		page = 1
+	if search.Action != "" {
+		descriptionLines = append(descriptionLines, fmt.Sprintf("处罚类型: `%s`", search.Action))
 	}
-	if page > totalPages {
-		page = totalPages
+	if search.Status != "" {
+		descriptionLines = append(descriptionLines, "处罚状态: "+punishmentStatusNames[search.Status])
+	}
+	if search.GuildID != "" {
+		descriptionLines = append(descriptionLines, fmt.Sprintf("服务器: `%s`", search.GuildID))
 	}
+	if total == 0 {
+		descriptionLines = append(descriptionLines, "未找到惩罚记录。")
+	}
+	description := strings.Join(descriptionLines, "\n")
 
-	start := (page - 1) * recordsPerPageV2
-	end := start + recordsPerPageV2
-	if end > len(records) {
-		end = len(records)
+	if search.SearchBy == "punished_user_id" {
+		userRecords, uErr := punishments_db.GetPunishmentRecordsByUserID(db, search.Input, nil)
+		if uErr == nil {
+			scoped := userRecords[:0]
+			for _, record := range userRecords {
+				if slices.Contains(filter.GuildIDs, record.GuildID) {
+					scoped = append(scoped, record)
+				}
+			}
+			if summary := buildEscalationSummary(db, punishConfig, filter.GuildIDs, search.Input, scoped); summary != "" {
+				description += "\n\n" + summary
+			}
+		}
 	}
-	pageRecords := records[start:end]
 
 	embed := &discordgo.MessageEmbed{
 		Title:       title,
 		Description: description,
 		Color:       0x00ff00,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("第 %d 页，共 %d 页", page, totalPages),
+			Text: fmt.Sprintf("第 %d 页，共 %d 页 | 共 %d 条记录", page, totalPages, total),
 		},
 	}
 
-	for _, record := range pageRecords {
+	for _, record := range records {
 		timestamp := time.Unix(record.Timestamp, 0).Format(time.RFC1123)
-		value := fmt.Sprintf("用户: <@%s> (%s)\n原因: %s\n管理员: <@%s>", record.UserID, record.UserUsername, record.Reason, record.AdminID)
+		value := fmt.Sprintf("用户: <@%s> (%s)\n原因: %s\n管理员: <@%s>", record.UserID, record.UserUsername, utils.TruncateString(record.Reason, 300), record.AdminID)
+		statusName, ok := punishmentStatusNames[record.PunishmentStatus]
+		if !ok {
+			statusName = record.PunishmentStatus
+		}
+		value += fmt.Sprintf("\n类型: `%s` | 状态: %s", record.ActionType, statusName)
 
 		member, err := s.State.Member(record.GuildID, record.UserID)
 		if err != nil {
@@ -141,36 +378,67 @@ func displayPunishmentsV2(s *discordgo.Session, i *discordgo.Interaction, search
 		})
 	}
 
-	var components []discordgo.MessageComponent
-	if totalPages > 1 {
-		components = utils.CreatePaginationComponents(page, totalPages, "punish_page_v2", searchBy, input)
+	if page == 1 {
+		embed.Fields = append(embed.Fields, noteFields...)
 	}
 
+	components := utils.CreatePaginationComponents(page, totalPages, "punish_page_v2", searchID)
+
 	s.InteractionResponseEdit(i, &discordgo.WebhookEdit{
 		Embeds:     &[]*discordgo.MessageEmbed{embed},
 		Components: &components,
 	})
 }
 
-func HandlePunishPaginationV2(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredMessageUpdate,
-	})
-	if err != nil {
-		log.Printf("Failed to defer pagination interaction: %v", err)
-		return
+// buildEscalationSummary 为使用积分制升级的处罚类型生成用户当前积分和降级时间的说明
+// 启用 count_warnings 的处罚类型会将用户的正式警告计入积分
+func buildEscalationSummary(db *sqlx.DB, punishConfig *model.PunishConfig, guildIDs []string, userID string, records []model.PunishmentRecord) string {
+	recordsByGuild := make(map[string][]model.PunishmentRecord)
+	for _, record := range records {
+		recordsByGuild[record.GuildID] = append(recordsByGuild[record.GuildID], record)
 	}
 
-	customID := i.MessageComponentData().CustomID
-	parts := strings.Split(customID, ":")
-	if len(parts) != 4 {
-		log.Printf("Invalid custom ID for v2 pagination: %s", customID)
-		return
-	}
+	now := time.Now()
+	var lines []string
+	for _, guildID := range guildIDs {
+		guildActions, ok := punishConfig.PunishConfig[guildID]
+		if !ok {
+			continue
+		}
+		guildRecords := recordsByGuild[guildID]
+		warnings, err := punishments_db.GetUserWarnings(db, guildID, userID)
+		if err != nil {
+			log.Printf("获取用户 %s 的警告时出错: %v", userID, err)
+		}
+		warningRecords := utils.WarningRecords(warnings)
 
-	page, _ := strconv.Atoi(parts[1])
-	searchBy := parts[2]
-	input := parts[3]
+		actionKeys := make([]string, 0, len(guildActions))
+		for key := range guildActions {
+			actionKeys = append(actionKeys, key)
+		}
+		sort.Strings(actionKeys)
 
-	displayPunishmentsV2(s, i.Interaction, searchBy, input, page)
+		for _, key := range actionKeys {
+			actionConfig := guildActions[key]
+			if !utils.IsPointsEscalation(actionConfig) {
+				continue
+			}
+			scored := guildRecords
+			if actionConfig.CountWarnings {
+				scored = append(slices.Clip(guildRecords), warningRecords...)
+			}
+			if len(scored) == 0 {
+				continue
+			}
+			score := utils.CalculatePunishmentScore(scored, guildActions, actionConfig, now)
+			levelKey, _ := utils.SelectLevelByScore(actionConfig, score)
+			line := fmt.Sprintf("**%s**: 当前积分 `%.2f`，对应等级 `%s`", actionConfig.Name, score, levelKey)
+			if dropAt, ok := utils.NextLevelDropTime(scored, guildActions, actionConfig, now); ok {
+				line += fmt.Sprintf("，<t:%d:R> 降级", dropAt.Unix())
+			}
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,423 @@
+package punish
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"newer_helper/bot"
+	"newer_helper/grpc/events"
+	punish_admin "newer_helper/handlers/punish/admin"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// buildAppealComponents creates the "Appeal" button attached to the punishment DM.
+func buildAppealComponents(punishmentID int64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "申诉",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("punish_appeal_%d", punishmentID),
+				},
+			},
+		},
+	}
+}
+
+// interactionUser returns the user behind an interaction, which is set on Member in guilds and on User in DMs.
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// HandleAppealButton opens the appeal modal for the punished user.
+func HandleAppealButton(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	customID := i.MessageComponentData().CustomID
+	punishmentID, err := strconv.ParseInt(strings.TrimPrefix(customID, "punish_appeal_"), 10, 64)
+	if err != nil {
+		log.Printf("Invalid punish appeal CustomID: %s", customID)
+		utils.SendEphemeralResponse(s, i, "无效的申诉请求。")
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config: %v", err)
+		utils.SendEphemeralResponse(s, i, "加载处罚配置失败，请稍后再试。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB: %v", err)
+		utils.SendEphemeralResponse(s, i, "连接处罚数据库失败，请稍后再试。")
+		return
+	}
+	defer db.Close()
+
+	record, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "找不到对应的处罚记录，该处罚可能已被撤销。")
+		return
+	}
+
+	user := interactionUser(i)
+	if user == nil || user.ID != record.UserID {
+		utils.SendEphemeralResponse(s, i, "只有被处罚的用户本人可以提交申诉。")
+		return
+	}
+
+	if msg := appealBlockedMessage(record); msg != "" {
+		utils.SendEphemeralResponse(s, i, msg)
+		return
+	}
+
+	actionConfig, ok := punishConfig.PunishConfig[record.GuildID][record.ActionType]
+	if !ok || actionConfig.AdminChannelID == "" {
+		utils.SendEphemeralResponse(s, i, "此处罚类型未配置审核频道，暂时无法提交申诉，请联系管理员。")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("punish_appeal_modal_%d", punishmentID),
+			Title:    fmt.Sprintf("处罚申诉 #%d", punishmentID),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "appeal_reason",
+							Label:       "申诉理由",
+							Style:       discordgo.TextInputParagraph,
+							Placeholder: "请说明您认为该处罚不合理的原因",
+							Required:    true,
+							MinLength:   10,
+							MaxLength:   1000,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		log.Printf("Error responding with appeal modal: %v", err)
+	}
+}
+
+// appealBlockedMessage returns a user-facing explanation if the punishment cannot be appealed, or "" if it can.
+func appealBlockedMessage(record *model.PunishmentRecord) string {
+	switch record.PunishmentStatus {
+	case model.PunishmentStatusActive:
+		return ""
+	case model.PunishmentStatusAppealed:
+		return "您已对该处罚提交过申诉，请耐心等待管理员审核。"
+	case model.PunishmentStatusAppealRejected:
+		return "您对该处罚的申诉已被驳回，无法再次申诉。"
+	default:
+		return "该处罚已结束或已撤销，无需申诉。"
+	}
+}
+
+// HandleAppealModalSubmit stores the appeal and posts it to the action's admin channel for review.
+func HandleAppealModalSubmit(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	data := i.ModalSubmitData()
+	punishmentID, err := strconv.ParseInt(strings.TrimPrefix(data.CustomID, "punish_appeal_modal_"), 10, 64)
+	if err != nil {
+		log.Printf("Invalid punish appeal modal CustomID: %s", data.CustomID)
+		return
+	}
+	appealReason := data.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+
+	if err := utils.DeferResponse(s, i, true); err != nil {
+		log.Printf("Failed to defer interaction: %v", err)
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config: %v", err)
+		utils.SendFollowUpError(s, i.Interaction, "加载处罚配置失败，请稍后再试。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB: %v", err)
+		utils.SendFollowUpError(s, i.Interaction, "连接处罚数据库失败，请稍后再试。")
+		return
+	}
+	defer db.Close()
+
+	record, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+	if err != nil {
+		utils.SendFollowUpError(s, i.Interaction, "找不到对应的处罚记录，该处罚可能已被撤销。")
+		return
+	}
+
+	user := interactionUser(i)
+	if user == nil || user.ID != record.UserID {
+		utils.SendFollowUpError(s, i.Interaction, "只有被处罚的用户本人可以提交申诉。")
+		return
+	}
+	if msg := appealBlockedMessage(record); msg != "" {
+		utils.SendFollowUpError(s, i.Interaction, msg)
+		return
+	}
+
+	actionConfig, ok := punishConfig.PunishConfig[record.GuildID][record.ActionType]
+	if !ok || actionConfig.AdminChannelID == "" {
+		utils.SendFollowUpError(s, i.Interaction, "此处罚类型未配置审核频道，暂时无法提交申诉，请联系管理员。")
+		return
+	}
+
+	appeal := model.PunishmentAppeal{
+		PunishmentID: record.PunishmentID,
+		UserID:       record.UserID,
+		GuildID:      record.GuildID,
+		Reason:       appealReason,
+		Status:       model.AppealStatusPending,
+		CreatedAt:    time.Now().Unix(),
+	}
+	appealID, err := punishments_db.AddPunishmentAppeal(db, appeal)
+	if err != nil {
+		log.Printf("Error saving punishment appeal: %v", err)
+		utils.SendFollowUpError(s, i.Interaction, "保存申诉失败，请稍后再试。")
+		return
+	}
+	appeal.AppealID = appealID
+
+	if err := punishments_db.ChangePunishmentStatus(ctx, db, record.PunishmentID, model.PunishmentStatusAppealed, user.ID, appealReason); err != nil {
+		log.Printf("Error updating punishment status for appeal %d: %v", appealID, err)
+	}
+
+	reviewMessage, err := s.ChannelMessageSendComplex(actionConfig.AdminChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{buildAppealReviewEmbed(record, &appeal, actionConfig, user)},
+		Components: buildAppealReviewComponents(appealID),
+	})
+	if err != nil {
+		log.Printf("Error sending appeal %d to admin channel %s: %v", appealID, actionConfig.AdminChannelID, err)
+	} else if err := punishments_db.SetPunishmentAppealMessage(db, appealID, reviewMessage.ChannelID, reviewMessage.ID); err != nil {
+		log.Printf("Error saving review message for appeal %d: %v", appealID, err)
+	}
+
+	utils.SendFollowUp(s, i.Interaction, fmt.Sprintf("✅ 您的申诉（编号 %d）已提交，管理员审核后会私信通知您结果。", appealID))
+}
+
+// HandleAppealReview handles the Approve/Reject buttons on an appeal review message.
+func HandleAppealReview(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	customID := i.MessageComponentData().CustomID
+	var approve bool
+	var idStr string
+	switch {
+	case strings.HasPrefix(customID, "punish_appeal_approve_"):
+		approve = true
+		idStr = strings.TrimPrefix(customID, "punish_appeal_approve_")
+	case strings.HasPrefix(customID, "punish_appeal_reject_"):
+		idStr = strings.TrimPrefix(customID, "punish_appeal_reject_")
+	default:
+		log.Printf("Invalid punish appeal review CustomID: %s", customID)
+		return
+	}
+	appealID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("Invalid punish appeal review CustomID: %s", customID)
+		return
+	}
+
+	serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+	if !ok || i.Member == nil {
+		utils.SendEphemeralResponse(s, i, "Server configuration not found.")
+		return
+	}
+	permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+	if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+		utils.SendEphemeralResponse(s, i, "You do not have permission to review appeals.")
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config: %v", err)
+		utils.SendEphemeralResponse(s, i, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB: %v", err)
+		utils.SendEphemeralResponse(s, i, "连接处罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	appeal, err := punishments_db.GetPunishmentAppealByID(db, appealID)
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "找不到该申诉。")
+		return
+	}
+	if appeal.GuildID != i.GuildID {
+		utils.SendEphemeralResponse(s, i, "该申诉不属于本服务器。")
+		return
+	}
+	record, err := punishments_db.GetPunishmentRecordByID(db, appeal.PunishmentID)
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "找不到该申诉对应的处罚记录，可能已被撤销或删除。")
+		return
+	}
+	// A punishment that already ended must not be restored or brought back into effect by the review
+	if !record.IsInEffect() {
+		utils.SendEphemeralResponse(s, i, "该申诉对应的处罚已结束或已撤销，无需再审核。")
+		return
+	}
+
+	status := model.AppealStatusRejected
+	if approve {
+		status = model.AppealStatusApproved
+	}
+	if err := punishments_db.ResolvePunishmentAppeal(db, appealID, status, i.Member.User.ID); err != nil {
+		utils.SendEphemeralResponse(s, i, "该申诉已被处理。")
+		return
+	}
+	appeal.Status = status
+	appeal.ReviewerID = i.Member.User.ID
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		log.Printf("Failed to defer appeal review interaction: %v", err)
+	}
+
+	actionConfig := punishConfig.PunishConfig[record.GuildID][record.ActionType]
+	guildName := "一个服务器"
+	if guild, err := s.Guild(record.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	var userNotice *discordgo.MessageEmbed
+	if approve {
+		if _, err := punish_admin.RestorePunishmentRoles(s, db, punishConfig, record); err != nil {
+			log.Printf("Error restoring roles for approved appeal %d: %v", appealID, err)
+		}
+		if err := punishments_db.ChangePunishmentStatus(ctx, db, record.PunishmentID, model.PunishmentStatusCancelled, i.Member.User.ID, fmt.Sprintf("申诉 #%d 通过", appealID)); err != nil {
+			log.Printf("Error cancelling punishment %d after approved appeal: %v", record.PunishmentID, err)
+		} else {
+			publishPunishmentEvent(ctx, db, events.PunishRevoked, record.PunishmentID, i.Member.User.ID)
+		}
+		userNotice = &discordgo.MessageEmbed{
+			Title:       "申诉通过",
+			Description: fmt.Sprintf("您在 **%s** 服务器对处罚 #%d 的申诉已通过，相关处罚已撤销。", guildName, record.PunishmentID),
+			Color:       0x00ff00,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}
+	} else {
+		if err := punishments_db.ChangePunishmentStatus(ctx, db, record.PunishmentID, model.PunishmentStatusAppealRejected, i.Member.User.ID, fmt.Sprintf("申诉 #%d 被驳回", appealID)); err != nil {
+			log.Printf("Error updating punishment %d after rejected appeal: %v", record.PunishmentID, err)
+		}
+		userNotice = &discordgo.MessageEmbed{
+			Title:       "申诉被驳回",
+			Description: fmt.Sprintf("您在 **%s** 服务器对处罚 #%d 的申诉经审核后被驳回，原处罚维持不变。", guildName, record.PunishmentID),
+			Color:       0xff0000,
+			Timestamp:   time.Now().Format(time.RFC3339),
+		}
+	}
+	utils.SendPrivateEmbedMessage(s, record.UserID, userNotice)
+
+	appellant := &discordgo.User{ID: record.UserID, Username: record.UserUsername}
+	reviewEmbed := buildAppealReviewEmbed(record, appeal, actionConfig, appellant)
+	emptyComponents := []discordgo.MessageComponent{}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{reviewEmbed},
+		Components: &emptyComponents,
+	})
+	if err != nil {
+		log.Printf("Failed to update appeal review message: %v", err)
+	}
+}
+
+// buildAppealReviewComponents creates the Approve/Reject buttons for an appeal review message.
+func buildAppealReviewComponents(appealID int64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "通过申诉",
+					Style:    discordgo.SuccessButton,
+					CustomID: fmt.Sprintf("punish_appeal_approve_%d", appealID),
+				},
+				discordgo.Button{
+					Label:    "驳回申诉",
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("punish_appeal_reject_%d", appealID),
+				},
+			},
+		},
+	}
+}
+
+// buildAppealReviewEmbed creates the embed shown to admins for an appeal, reflecting its current status.
+func buildAppealReviewEmbed(record *model.PunishmentRecord, appeal *model.PunishmentAppeal, actionConfig model.ActionConfig, appellant *discordgo.User) *discordgo.MessageEmbed {
+	actionName := actionConfig.Name
+	if actionName == "" {
+		actionName = record.ActionType
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("处罚申诉 #%d", appeal.AppealID),
+		Color: 0xffa500, // Orange
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:  "申诉用户",
+				Value: fmt.Sprintf("<@%s> (`%s`)", appellant.ID, appellant.Username),
+			},
+			{
+				Name:   "处罚ID",
+				Value:  fmt.Sprintf("%d", record.PunishmentID),
+				Inline: true,
+			},
+			{
+				Name:   "处罚类型",
+				Value:  actionName,
+				Inline: true,
+			},
+			{
+				Name:   "原处罚人",
+				Value:  fmt.Sprintf("<@%s>", record.AdminID),
+				Inline: true,
+			},
+			{
+				Name:  "原处罚原因",
+				Value: record.Reason,
+			},
+			{
+				Name:  "申诉理由",
+				Value: appeal.Reason,
+			},
+		},
+		Timestamp: time.Unix(appeal.CreatedAt, 0).Format(time.RFC3339),
+	}
+
+	switch appeal.Status {
+	case model.AppealStatusApproved:
+		embed.Color = 0x00ff00
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "审核结果",
+			Value: fmt.Sprintf("✅ 已通过，处罚已撤销（审核人: <@%s>）", appeal.ReviewerID),
+		})
+	case model.AppealStatusRejected:
+		embed.Color = 0xff0000
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "审核结果",
+			Value: fmt.Sprintf("❌ 已驳回（审核人: <@%s>）", appeal.ReviewerID),
+		})
+	}
+
+	return embed
+}
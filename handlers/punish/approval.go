@@ -0,0 +1,292 @@
+package punish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"newer_helper/bot"
+	"newer_helper/grpc/events"
+	"newer_helper/model"
+	"newer_helper/scanner"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jmoiron/sqlx"
+)
+
+// defaultApprovalTimeout is used when an action does not configure approval_timeout.
+const defaultApprovalTimeout = 24 * time.Hour
+
+// approvalTimeout returns how long a punishment of this action waits for a second admin.
+func approvalTimeout(actionConfig model.ActionConfig) time.Duration {
+	if actionConfig.ApprovalTimeout == "" {
+		return defaultApprovalTimeout
+	}
+	timeout, err := utils.ParseDuration(actionConfig.ApprovalTimeout)
+	if err != nil || timeout <= 0 {
+		log.Printf("Invalid approval_timeout '%s' for action '%s', using default", actionConfig.ApprovalTimeout, actionConfig.Name)
+		return defaultApprovalTimeout
+	}
+	return timeout
+}
+
+// submitPunishmentApproval stores the punishment as pending_approval and posts it to the admin channel
+// for a second admin to confirm. Nothing is applied to the user until then.
+// It returns the pending punishment's ID and how long it waits for approval.
+func submitPunishmentApproval(ctx context.Context, s *discordgo.Session, db *sqlx.DB, plan punishmentPlan, actionConfig model.ActionConfig, allEvidence []Evidence) (int64, time.Duration, error) {
+	if actionConfig.AdminChannelID == "" {
+		return 0, 0, punishError(PunishErrNotFound, "处罚类型 '%s' 的该等级需要审批，但未配置审核频道。", actionConfig.Name)
+	}
+
+	punishmentID, err := addPunishmentRecord(db, plan, appliedPunishment{}, model.PunishmentStatusPendingApproval)
+	if err != nil {
+		log.Printf("Error saving pending punishment record: %v", err)
+		return 0, 0, punishError(PunishErrInternal, "Failed to save the punishment record.")
+	}
+
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		log.Printf("Error serializing punishment plan: %v", err)
+		discardPendingPunishment(db, punishmentID)
+		return 0, 0, punishError(PunishErrInternal, "Failed to save the punishment record.")
+	}
+
+	now := time.Now()
+	timeout := approvalTimeout(actionConfig)
+	approval := model.PunishmentApproval{
+		PunishmentID: punishmentID,
+		GuildID:      plan.GuildID,
+		RequesterID:  plan.AdminID,
+		PlanJSON:     string(planJSON),
+		Status:       model.ApprovalStatusPending,
+		CreatedAt:    now.Unix(),
+		ExpiresAt:    now.Add(timeout).Unix(),
+	}
+	if err := punishments_db.AddPunishmentApproval(db, approval); err != nil {
+		log.Printf("Error saving punishment approval: %v", err)
+		discardPendingPunishment(db, punishmentID)
+		return 0, 0, punishError(PunishErrInternal, "Failed to save the punishment record.")
+	}
+
+	currentGuildHistory, otherGuildsHistory, err := getPunishmentHistory(db, plan.TargetUser.ID, plan.GuildID)
+	if err != nil {
+		log.Printf("Error fetching punishment history: %v", err)
+	}
+	embed := buildPunishmentEmbedNew(plan.AdminUsername, plan.TargetUser, &actionConfig, plan.Reason, allEvidence, currentGuildHistory, otherGuildsHistory, false, "", punishmentID, &plan.Level, false)
+	embed.Title = "⏳ 待审批: " + embed.Title
+	embed.Footer.Text = trace.Footer(ctx, embed.Footer.Text)
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "审批",
+		Value: fmt.Sprintf("该处罚等级需要另一位管理员确认后才会执行。\n发起人: <@%s>\n截止: <t:%d:R>", plan.AdminID, approval.ExpiresAt),
+	})
+
+	message, err := s.ChannelMessageSendComplex(actionConfig.AdminChannelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: buildApprovalComponents(punishmentID),
+	})
+	if err != nil {
+		log.Printf("Error sending approval request for punishment %d: %v", punishmentID, err)
+		discardPendingPunishment(db, punishmentID)
+		return 0, 0, punishError(PunishErrInternal, "发送审批请求失败，请检查审核频道配置。")
+	}
+	if err := punishments_db.SetPunishmentApprovalMessage(db, punishmentID, message.ChannelID, message.ID); err != nil {
+		log.Printf("Error saving approval message for punishment %d: %v", punishmentID, err)
+	}
+	auditPunishmentCreated(ctx, db, punishmentID, plan.AdminID, plan.Reason)
+
+	return punishmentID, timeout, nil
+}
+
+// discardPendingPunishment removes a pending punishment whose approval request could not be submitted,
+// so that it does not later show up as expired.
+func discardPendingPunishment(db *sqlx.DB, punishmentID int64) {
+	if err := punishments_db.DiscardPendingPunishment(db, punishmentID); err != nil {
+		log.Printf("Error discarding pending punishment %d: %v", punishmentID, err)
+	}
+}
+
+// buildApprovalComponents creates the Confirm/Reject buttons for an approval request.
+func buildApprovalComponents(punishmentID int64) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "确认执行",
+					Style:    discordgo.DangerButton,
+					CustomID: fmt.Sprintf("punish_approval_confirm_%d", punishmentID),
+				},
+				discordgo.Button{
+					Label:    "驳回",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("punish_approval_reject_%d", punishmentID),
+				},
+			},
+		},
+	}
+}
+
+// HandlePunishApprovalDecision handles the Confirm/Reject buttons on an approval request.
+// The admin who requested the punishment cannot confirm it themselves.
+func HandlePunishApprovalDecision(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	customID := i.MessageComponentData().CustomID
+	var confirm bool
+	var idStr string
+	switch {
+	case strings.HasPrefix(customID, "punish_approval_confirm_"):
+		confirm = true
+		idStr = strings.TrimPrefix(customID, "punish_approval_confirm_")
+	case strings.HasPrefix(customID, "punish_approval_reject_"):
+		idStr = strings.TrimPrefix(customID, "punish_approval_reject_")
+	default:
+		log.Printf("Invalid punish approval CustomID: %s", customID)
+		return
+	}
+	punishmentID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		log.Printf("Invalid punish approval CustomID: %s", customID)
+		return
+	}
+
+	serverConfig, ok := b.GetConfig().ServerConfigs[i.GuildID]
+	if !ok || i.Member == nil {
+		utils.SendEphemeralResponse(s, i, "Server configuration not found.")
+		return
+	}
+	permissionLevel := utils.CheckPermission(i.Member.Roles, i.Member.User.ID, serverConfig.AdminRoleIDs, nil, b.GetConfig().DeveloperUserIDs, b.GetConfig().SuperAdminRoleIDs)
+	if permissionLevel != utils.AdminPermission && permissionLevel != utils.SuperAdminPermission && permissionLevel != utils.DeveloperPermission {
+		utils.SendEphemeralResponse(s, i, "You do not have permission to review punishments.")
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config: %v", err)
+		utils.SendEphemeralResponse(s, i, "加载处罚配置失败。")
+		return
+	}
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB: %v", err)
+		utils.SendEphemeralResponse(s, i, "连接处罚数据库失败。")
+		return
+	}
+	defer db.Close()
+
+	approval, err := punishments_db.GetPunishmentApprovalByID(db, punishmentID)
+	if err != nil {
+		utils.SendEphemeralResponse(s, i, "找不到该审批请求。")
+		return
+	}
+	if approval.GuildID != i.GuildID {
+		utils.SendEphemeralResponse(s, i, "该审批请求不属于本服务器。")
+		return
+	}
+	if confirm && approval.RequesterID == i.Member.User.ID {
+		utils.SendEphemeralResponse(s, i, "该处罚需要由另一位管理员确认，发起人不能自行确认。")
+		return
+	}
+
+	var plan punishmentPlan
+	if err := json.Unmarshal([]byte(approval.PlanJSON), &plan); err != nil {
+		log.Printf("Error decoding punishment plan for %d: %v", punishmentID, err)
+		utils.SendEphemeralResponse(s, i, "审批数据损坏，无法执行。")
+		return
+	}
+
+	status := model.ApprovalStatusRejected
+	if confirm {
+		status = model.ApprovalStatusApproved
+	}
+	if err := punishments_db.ResolvePunishmentApproval(db, punishmentID, status, i.Member.User.ID); err != nil {
+		utils.SendEphemeralResponse(s, i, "该审批请求已被处理或已过期。")
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		log.Printf("Failed to defer approval interaction: %v", err)
+	}
+
+	var embed *discordgo.MessageEmbed
+	if confirm {
+		embed = enforceApprovedPunishment(ctx, s, b, db, plan, punishConfig.PunishConfig[plan.GuildID][plan.ActionType], punishmentID, i.Member.User.ID)
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "审批结果",
+			Value: fmt.Sprintf("✅ 发起人 <@%s>，确认人 <@%s>", approval.RequesterID, i.Member.User.ID),
+		})
+	} else {
+		if err := punishments_db.ChangePunishmentStatus(ctx, db, punishmentID, model.PunishmentStatusRejected, i.Member.User.ID, "审批驳回"); err != nil {
+			log.Printf("Error rejecting punishment %d: %v", punishmentID, err)
+		}
+		embed = resolvedApprovalEmbed(i.Message, fmt.Sprintf("❌ 已被 <@%s> 驳回，处罚未执行。", i.Member.User.ID))
+	}
+
+	emptyComponents := []discordgo.MessageComponent{}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &emptyComponents,
+	})
+	if err != nil {
+		log.Printf("Failed to update approval message: %v", err)
+	}
+}
+
+// enforceApprovedPunishment applies an approved plan, activates its record and notifies the user.
+// It returns the admin embed that replaces the approval request.
+func enforceApprovedPunishment(ctx context.Context, s *discordgo.Session, b *bot.Bot, db *sqlx.DB, plan punishmentPlan, actionConfig model.ActionConfig, punishmentID int64, approverID string) *discordgo.MessageEmbed {
+	before, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+	if err != nil {
+		log.Printf("Error loading punishment %d for audit: %v", punishmentID, err)
+	}
+
+	applied := applyPunishmentLevel(s, plan.GuildID, plan.TargetUser, plan.Level)
+
+	tempRolesJSON, rolesRemoveAtJSON, err := encodeTempRoles(applied.tempRoles, applied.rolesRemoveAt)
+	if err != nil {
+		log.Printf("Error serializing temp roles for punishment %d: %v", punishmentID, err)
+	} else if err := punishments_db.ActivatePunishmentRecord(db, punishmentID, tempRolesJSON, rolesRemoveAtJSON, unixOrZero(applied.timeoutUntil), applied.banUntil); err != nil {
+		log.Printf("Error activating punishment %d: %v", punishmentID, err)
+	} else {
+		scanner.SchedulePunishmentRoles(punishmentID, applied.rolesRemoveAt, applied.banUntil)
+		publishPunishmentEvent(ctx, db, events.PunishCreated, punishmentID, plan.AdminID)
+		if before != nil {
+			after := *before
+			after.PunishmentStatus = model.PunishmentStatusActive
+			after.TempRolesJSON = tempRolesJSON
+			after.RolesRemoveAt = rolesRemoveAtJSON
+			after.TimeoutUntil = unixOrZero(applied.timeoutUntil)
+			after.BanUntil = applied.banUntil
+			if err := punishments_db.RecordAudit(ctx, db, model.AuditActionStatusChange, approverID, "审批通过", before, &after); err != nil {
+				log.Printf("Error writing audit entry for punishment %d: %v", punishmentID, err)
+			}
+		}
+	}
+
+	var allEvidence []Evidence
+	if err := json.Unmarshal([]byte(plan.EvidenceJSON), &allEvidence); err != nil {
+		log.Printf("Error decoding evidence for punishment %d: %v", punishmentID, err)
+	}
+
+	return notifyPunishment(ctx, s, b, db, plan, actionConfig, allEvidence, punishmentID, applied.timeoutApplied, applied.timeoutDurationStr)
+}
+
+// resolvedApprovalEmbed copies the approval request embed and appends the outcome.
+func resolvedApprovalEmbed(message *discordgo.Message, result string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{Title: "处罚审批"}
+	if message != nil && len(message.Embeds) > 0 {
+		embed = message.Embeds[0]
+	}
+	embed.Color = 0x808080 // Grey
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:  "审批结果",
+		Value: result,
+	})
+	return embed
+}
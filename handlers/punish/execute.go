@@ -0,0 +1,206 @@
+package punish
+
+import (
+	"context"
+	"fmt"
+	"newer_helper/bot"
+	"newer_helper/grpc/events"
+	"newer_helper/model"
+	"newer_helper/scanner"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PunishErrorKind classifies why a punishment could not be executed.
+type PunishErrorKind int
+
+const (
+	PunishErrInvalid     PunishErrorKind = iota // the request itself is not allowed, e.g. punishing the bot
+	PunishErrNotFound                           // the guild, action, level or member does not exist
+	PunishErrDenied                             // the target is whitelisted
+	PunishErrRateLimited                        // the target or admin hit a rate limit
+	PunishErrInternal                           // configuration, database or Discord failures
+)
+
+// PunishError is a punishment failure with a message suitable for showing to the operator.
+type PunishError struct {
+	Kind    PunishErrorKind
+	Message string
+}
+
+func (e *PunishError) Error() string {
+	return e.Message
+}
+
+func punishError(kind PunishErrorKind, format string, args ...any) error {
+	return &PunishError{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// PunishmentRequest describes a punishment to execute, independently of where it was issued.
+type PunishmentRequest struct {
+	GuildID       string
+	ChannelID     string // channel to post the public notice in, empty for none
+	MessageID     string // ID of the interaction or request that issued the punishment
+	AdminID       string
+	AdminUsername string
+	TargetUser    *discordgo.User
+	Action        string
+	Reason        string
+	EvidenceLinks string
+	Level         *model.PunishLevel // pins the punish level, e.g. to the one of a confirmed preview; nil picks it from the user's history
+	LevelKey      string             // key of the pinned Level in the action's Data
+}
+
+// PunishmentResult is the outcome of a successfully executed or submitted punishment.
+type PunishmentResult struct {
+	PunishmentID    int64
+	Status          string // model.PunishmentStatusActive, or model.PunishmentStatusPendingApproval if it awaits a second admin
+	ApprovalTimeout time.Duration
+	Level           model.PunishLevel
+}
+
+// ExecutePunishment is the core of the punishment process shared by /punish, quick punish and the gRPC service.
+// It centralizes configuration loading, validation, escalation, role application, database operations and notifications.
+// ctx carries the trace ID recorded in the audit log and shown on the admin log embed. Returned errors are *PunishError.
+func ExecutePunishment(ctx context.Context, s *discordgo.Session, b *bot.Bot, req PunishmentRequest) (*PunishmentResult, error) {
+	targetUser := req.TargetUser
+	if targetUser.ID == s.State.User.ID {
+		return nil, punishError(PunishErrInvalid, "错误，这样做是不被允许的（恼！）")
+	}
+
+	isSelfPunish := req.AdminID == targetUser.ID
+
+	if !isSelfPunish {
+		if !utils.CheckAndSetPunishLock(targetUser.ID) {
+			return nil, punishError(PunishErrRateLimited, "对该用户的处罚操作过于频繁，请 5 分钟后再试。")
+		}
+	}
+
+	// Load new punishment configuration
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		trace.Logf(ctx, "Error loading punish config: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to load punishment configuration.")
+	}
+
+	// Get guild-specific action configurations
+	guildActions, ok := punishConfig.PunishConfig[req.GuildID]
+	if !ok {
+		return nil, punishError(PunishErrNotFound, "❓ 此服务器未找到可用配置文件")
+	}
+
+	// Get specific action configuration
+	actionConfig, ok := guildActions[req.Action]
+	if !ok {
+		return nil, punishError(PunishErrNotFound, "❓ 处罚类型 '%s' 未在配置中找到", req.Action)
+	}
+
+	// Check admin rate limit (exclude self-punishment)
+	if !isSelfPunish && !utils.CheckAndIncrementAdminAction(req.AdminID, req.Action, actionConfig.PeeUserLimit, 24*time.Hour) {
+		return nil, punishError(PunishErrRateLimited, "您今天执行 '%s' 操作的次数已达上限。", actionConfig.Name)
+	}
+
+	// Get target member for whitelist check
+	targetMember, err := s.GuildMember(req.GuildID, targetUser.ID)
+	if err != nil {
+		trace.Logf(ctx, "Error getting member details: %v", err)
+		return nil, punishError(PunishErrNotFound, "Could not retrieve member details.")
+	}
+
+	// Check whitelist (using action-specific whitelist)
+	if !isSelfPunish && isUserWhitelistedForAction(targetMember, actionConfig) {
+		return nil, punishError(PunishErrDenied, "This user is on the whitelist and cannot be punished.")
+	}
+
+	// Process evidence
+	evidenceJSON, allEvidence, err := processEvidence(s, req.EvidenceLinks, targetUser)
+	if err != nil {
+		trace.Logf(ctx, "Error processing evidence: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to process evidence.")
+	}
+
+	// Connect to database using the database path from punish config
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		trace.Logf(ctx, "Error connecting to punishment DB: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to connect to the punishment database.")
+	}
+	defer db.Close()
+
+	// Determine punishment level from the user's history (count or points, per the action's escalation mode)
+	levelKey, punishLevel := req.LevelKey, req.Level
+	if punishLevel == nil {
+		levelKey, punishLevel, err = determinePunishmentLevel(db, req.GuildID, targetUser.ID, guildActions, actionConfig)
+		if err != nil {
+			trace.Logf(ctx, "Error determining punishment level: %v", err)
+			return nil, punishError(PunishErrInternal, "Failed to retrieve punishment history.")
+		}
+	}
+
+	// Check if we still don't have a punishment level (config might be empty)
+	if punishLevel == nil {
+		trace.Logf(ctx, "No punishment levels configured for action '%s' in guild %s", req.Action, req.GuildID)
+		return nil, punishError(PunishErrNotFound, "❌ 处罚类型 '%s' 未配置任何惩罚等级", actionConfig.Name)
+	}
+
+	plan := punishmentPlan{
+		GuildID:       req.GuildID,
+		ChannelID:     req.ChannelID,
+		MessageID:     req.MessageID,
+		ActionType:    req.Action,
+		TargetUser:    targetUser,
+		AdminID:       req.AdminID,
+		AdminUsername: req.AdminUsername,
+		Reason:        req.Reason,
+		EvidenceJSON:  evidenceJSON,
+		Level:         *punishLevel,
+		LevelKey:      levelKey,
+		IsSelfPunish:  isSelfPunish,
+	}
+
+	// Severe levels are only applied once a second admin confirms them
+	if punishLevel.RequiresApproval && !isSelfPunish {
+		punishmentID, timeout, err := submitPunishmentApproval(ctx, s, db, plan, actionConfig, allEvidence)
+		if err != nil {
+			return nil, err
+		}
+		return &PunishmentResult{
+			PunishmentID:    punishmentID,
+			Status:          model.PunishmentStatusPendingApproval,
+			ApprovalTimeout: timeout,
+			Level:           plan.Level,
+		}, nil
+	}
+
+	// Apply punishments according to the level
+	applied := applyPunishmentLevel(s, plan.GuildID, targetUser, plan.Level)
+
+	// Record the punishment
+	punishmentID, err := addPunishmentRecord(db, plan, applied, model.PunishmentStatusActive)
+	if err != nil {
+		trace.Logf(ctx, "Error saving punishment record: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to save the punishment record.")
+	}
+	auditPunishmentCreated(ctx, db, punishmentID, plan.AdminID, plan.Reason)
+	scanner.SchedulePunishmentRoles(punishmentID, applied.rolesRemoveAt, applied.banUntil)
+	publishPunishmentEvent(ctx, db, events.PunishCreated, punishmentID, plan.AdminID)
+
+	// Notify the user and the command channel, then log to the admin channel
+	adminEmbed := notifyPunishment(ctx, s, b, db, plan, actionConfig, allEvidence, punishmentID, applied.timeoutApplied, applied.timeoutDurationStr)
+	if actionConfig.AdminChannelID != "" {
+		_, err = s.ChannelMessageSendEmbed(actionConfig.AdminChannelID, adminEmbed)
+		if err != nil {
+			trace.Logf(ctx, "Error sending admin log message: %v", err)
+		}
+	}
+
+	return &PunishmentResult{
+		PunishmentID: punishmentID,
+		Status:       model.PunishmentStatusActive,
+		Level:        plan.Level,
+	}, nil
+}
@@ -1,23 +1,27 @@
 package punish
 
 import (
-	"crypto/rand"
-	"encoding/hex"
+	"context"
 	"fmt"
 	"log"
 	"newer_helper/bot"
 	preset_pkg "newer_helper/handlers/preset"
+	punish_admin "newer_helper/handlers/punish/admin"
+	"newer_helper/model"
 	"newer_helper/utils"
 	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/jmoiron/sqlx"
 )
 
 // HandlePunishCommand handles the initial slash command for punishing a user.
 // It parses options and passes them to the core punishment logic.
-func HandlePunishCommand(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+func HandlePunishCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
 	if err := utils.DeferResponse(s, i, true); err != nil {
 		log.Printf("Failed to defer interaction: %v", err)
 		return
@@ -30,7 +34,7 @@ func HandlePunishCommand(s *discordgo.Session, i *discordgo.InteractionCreate, b
 		reason = "使用第三方类型提问，违反问答规范"
 	}
 
-	applyAndLogPunishment(s, i, b, cmdOptions.TargetUser, cmdOptions.Action, reason, cmdOptions.MessageLinks)
+	applyAndLogPunishment(ctx, s, i, b, cmdOptions.TargetUser, cmdOptions.Action, reason, cmdOptions.MessageLinks, nil, "")
 }
 
 // HandleQuickPunishCommand creates and displays a modal for a quick punishment.
@@ -93,14 +97,12 @@ func HandlePunishModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreat
 	messageLink := fmt.Sprintf("https://discord.com/channels/%s/%s/%s", i.GuildID, i.ChannelID, targetMessage.ID)
 
 	// --- Create and store pending punishment ---
-	pendingIDBytes := make([]byte, 8)
-	_, err = rand.Read(pendingIDBytes)
+	pendingID, err := newPendingID()
 	if err != nil {
 		log.Printf("Error generating random ID for pending punishment: %v", err)
 		utils.SendFollowUpError(s, i.Interaction, "Failed to create a pending punishment.")
 		return
 	}
-	pendingID := hex.EncodeToString(pendingIDBytes)
 
 	pendingPunishment := &bot.PendingPunishment{
 		TargetUser:    targetMessage.Author,
@@ -110,9 +112,11 @@ func HandlePunishModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreat
 		Timestamp:     time.Now(),
 	}
 
-	b.GetPendingPunishmentsMutex().Lock()
-	b.GetPendingPunishments()[pendingID] = pendingPunishment
-	b.GetPendingPunishmentsMutex().Unlock()
+	if err := b.SavePendingPunishment(pendingID, pendingPunishment); err != nil {
+		log.Printf("Error saving pending punishment: %v", err)
+		utils.SendFollowUpError(s, i.Interaction, "Failed to create a pending punishment.")
+		return
+	}
 
 	// --- Load punish config to get actions ---
 	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
@@ -127,43 +131,20 @@ func HandlePunishModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreat
 		return
 	}
 
-	// --- Create action buttons ---
-	// Define fixed order for consistent button positioning
-	actionOrder := []string{"re-answer", "cheat", "tag"}
-
-	var components []discordgo.MessageComponent
-	actionRow := discordgo.ActionsRow{}
-	for _, actionKey := range actionOrder {
-		if actionConfig, exists := guildActions[actionKey]; exists {
-			actionRow.Components = append(actionRow.Components, discordgo.Button{
-				Label:    actionConfig.Name,
-				Style:    discordgo.PrimaryButton,
-				CustomID: fmt.Sprintf("punish_action_%s_%s", pendingID, actionKey),
-			})
-		}
-	}
-
-	// Add any additional actions not in the predefined order
-	for actionKey, actionConfig := range guildActions {
-		alreadyAdded := false
-		for _, orderedKey := range actionOrder {
-			if orderedKey == actionKey {
-				alreadyAdded = true
-				break
-			}
-		}
-		if !alreadyAdded {
-			actionRow.Components = append(actionRow.Components, discordgo.Button{
-				Label:    actionConfig.Name,
-				Style:    discordgo.PrimaryButton,
-				CustomID: fmt.Sprintf("punish_action_%s_%s", pendingID, actionKey),
-			})
-		}
+	components := buildPunishActionComponents(pendingID, guildActions)
+	if len(components) == 0 {
+		utils.SendFollowUpError(s, i.Interaction, "此服务器没有可用于快速处罚的处罚类型")
+		return
 	}
-	components = append(components, actionRow)
 
 	// Send preview message
 	embed := buildPunishmentPreviewEmbed(i, targetMessage.Author, reason, messageLink)
+	if db, err := punishments_db.Init(punishConfig.DatabasePath); err != nil {
+		log.Printf("Error connecting to punishment DB for preview: %v", err)
+	} else {
+		embed.Fields = append(embed.Fields, buildPreviewHistoryFields(db, i.GuildID, targetMessage.Author.ID)...)
+		db.Close()
+	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -178,25 +159,31 @@ func HandlePunishModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreat
 }
 
 // HandlePunishActionSelection handles the selection of a punishment action from the preview message.
-func HandlePunishActionSelection(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
-	customIDParts := strings.Split(i.MessageComponentData().CustomID, "_")
-	if len(customIDParts) != 4 {
-		log.Printf("Invalid punish action CustomID: %s", i.MessageComponentData().CustomID)
+func HandlePunishActionSelection(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	// Buttons carry the action in their custom ID, the select menu in its value.
+	// The pending ID is hex, but action keys may themselves contain underscores.
+	data := i.MessageComponentData()
+	customIDParts := strings.SplitN(strings.TrimPrefix(data.CustomID, "punish_action_"), "_", 2)
+	if data.ComponentType == discordgo.SelectMenuComponent && len(customIDParts) == 1 && len(data.Values) == 1 {
+		customIDParts = append(customIDParts, data.Values[0])
+	}
+	if len(customIDParts) != 2 {
+		log.Printf("Invalid punish action CustomID: %s", data.CustomID)
+		utils.SendEphemeralResponse(s, i, "无效的处罚请求。")
 		return
 	}
-	pendingID := customIDParts[2]
-	action := customIDParts[3]
+	pendingID := customIDParts[0]
+	action := customIDParts[1]
 
-	b.GetPendingPunishmentsMutex().Lock()
-	pendingPunishment, ok := b.GetPendingPunishments()[pendingID]
-	if !ok {
-		b.GetPendingPunishmentsMutex().Unlock()
-		utils.SendEphemeralResponse(s, i, "This punishment request has expired or is invalid.")
+	// Taking the entry removes it, which prevents double execution
+	pendingPunishment, err := b.TakePendingPunishment(pendingID)
+	if err != nil {
+		if !bot.IsPendingExpired(err) {
+			log.Printf("Error loading pending punishment %s: %v", pendingID, err)
+		}
+		utils.SendEphemeralResponse(s, i, "⏰ 此处罚请求已过期（超过5分钟未选择处罚类型），请重新发起快速处罚。")
 		return
 	}
-	// Immediately remove to prevent double execution
-	delete(b.GetPendingPunishments(), pendingID)
-	b.GetPendingPunishmentsMutex().Unlock()
 
 	// Defer the response now that we have the interaction from the button click
 	if err := utils.DeferResponse(s, i, true); err != nil {
@@ -204,8 +191,10 @@ func HandlePunishActionSelection(s *discordgo.Session, i *discordgo.InteractionC
 		return
 	}
 
-	// Execute the punishment
-	applyAndLogPunishment(s, i, b, pendingPunishment.TargetUser, action, pendingPunishment.Reason, pendingPunishment.EvidenceLinks)
+	// Execute the punishment, unless it first waits for its preview to be confirmed
+	if previewed := applyAndLogPunishment(ctx, s, i, b, pendingPunishment.TargetUser, action, pendingPunishment.Reason, pendingPunishment.EvidenceLinks, nil, ""); previewed {
+		return
+	}
 
 	// Remove all components (hide all buttons) after punishment is applied
 	emptyComponents := []discordgo.MessageComponent{}
@@ -244,126 +233,111 @@ func buildPunishmentPreviewEmbed(i *discordgo.InteractionCreate, targetUser *dis
 	}
 }
 
-// applyAndLogPunishment is the core function that handles the punishment process.
-// It centralizes the logic for configuration loading, validation, database operations, and notifications.
-func applyAndLogPunishment(s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot, targetUser *discordgo.User, action, reason, evidenceLinks string) {
-	if targetUser.ID == s.State.User.ID {
-		utils.SendFollowUpError(s, i.Interaction, "错误，这样做是不被允许的（恼！）")
-		return
-	}
-
-	isSelfPunish := i.Member.User.ID == targetUser.ID
+// previewHistoryLimit is the number of past punishments listed in the punishment preview.
+const previewHistoryLimit = 5
 
-	if !isSelfPunish {
-		if !utils.CheckAndSetPunishLock(targetUser.ID) {
-			utils.SendFollowUpError(s, i.Interaction, "对该用户的处罚操作过于频繁，请 5 分钟后再试。")
-			return
-		}
-	}
+// buildPreviewHistoryFields lists the user's recent punishments and moderator notes in the guild,
+// so the admin can see them before choosing an action.
+func buildPreviewHistoryFields(db *sqlx.DB, guildID, userID string) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
 
-	// Load new punishment configuration
-	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	records, err := punishments_db.GetPunishmentRecordsByUserID(db, userID, nil)
 	if err != nil {
-		log.Printf("Error loading punish config: %v", err)
-		utils.SendFollowUpError(s, i.Interaction, "Failed to load punishment configuration.")
-		return
+		log.Printf("Error loading punishment history for preview: %v", err)
 	}
-
-	// Get guild-specific action configurations
-	guildActions, ok := punishConfig.PunishConfig[i.GuildID]
-	if !ok {
-		utils.SendFollowUpError(s, i.Interaction, "❓ 此服务器未找到可用配置文件")
-		return
-	}
-
-	// Get specific action configuration
-	actionConfig, ok := guildActions[action]
-	if !ok {
-		utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("❓ 处罚类型 '%s' 未在配置中找到", action))
-		return
-	}
-
-	// Check admin rate limit (exclude self-punishment)
-	if !isSelfPunish && !utils.CheckAndIncrementAdminAction(i.Member.User.ID, action, actionConfig.PeeUserLimit, 24*time.Hour) {
-		utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("您今天执行 '%s' 操作的次数已达上限。", actionConfig.Name))
-		return
+	var guildRecords []model.PunishmentRecord
+	for _, record := range records {
+		if record.GuildID == guildID {
+			guildRecords = append(guildRecords, record)
+		}
 	}
-
-	// Get target member for whitelist check
-	targetMember, err := s.GuildMember(i.GuildID, targetUser.ID)
-	if err != nil {
-		log.Printf("Error getting member details: %v", err)
-		utils.SendFollowUpError(s, i.Interaction, "Could not retrieve member details.")
-		return
+	if len(guildRecords) > 0 {
+		sort.Slice(guildRecords, func(a, b int) bool {
+			return guildRecords[a].Timestamp > guildRecords[b].Timestamp
+		})
+		var lines []string
+		for _, record := range guildRecords[:min(len(guildRecords), previewHistoryLimit)] {
+			lines = append(lines, fmt.Sprintf("#%d · <t:%d:d> · %s: %s", record.PunishmentID, record.Timestamp, record.ActionType, utils.TruncateString(record.Reason, 60)))
+		}
+		if len(guildRecords) > previewHistoryLimit {
+			lines = append(lines, fmt.Sprintf("……另有 %d 条，使用 /punish_search 查看全部", len(guildRecords)-previewHistoryLimit))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("历史处罚记录 (%d)", len(guildRecords)),
+			Value: utils.TruncateString(strings.Join(lines, "\n"), 1024),
+		})
 	}
 
-	// Check whitelist (using action-specific whitelist)
-	if !isSelfPunish && isUserWhitelistedForAction(targetMember, actionConfig) {
-		utils.SendFollowUpError(s, i.Interaction, "This user is on the whitelist and cannot be punished.")
-		return
+	if field := punish_admin.BuildUserNotesField(db, guildID, userID); field != nil {
+		fields = append(fields, field)
 	}
+	return fields
+}
 
-	// Process evidence
-	evidenceJSON, allEvidence, err := processEvidence(s, evidenceLinks, targetUser)
-	if err != nil {
-		log.Printf("Error processing evidence: %v", err)
-		utils.SendFollowUpError(s, i.Interaction, "Failed to process evidence.")
-		return
+// applyAndLogPunishment executes a punishment issued through an interaction and reports the outcome on it.
+// level and levelKey pin the punish level of a confirmed preview; with nil, the level is picked from the user's history
+// and, if it needs a preview, the preview is shown instead and true is returned.
+func applyAndLogPunishment(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot, targetUser *discordgo.User, action, reason, evidenceLinks string, level *model.PunishLevel, levelKey string) bool {
+	req := PunishmentRequest{
+		GuildID:       i.GuildID,
+		ChannelID:     i.ChannelID,
+		MessageID:     i.ID,
+		AdminID:       i.Member.User.ID,
+		AdminUsername: i.Member.User.Username,
+		TargetUser:    targetUser,
+		Action:        action,
+		Reason:        reason,
+		EvidenceLinks: evidenceLinks,
+		Level:         level,
+		LevelKey:      levelKey,
 	}
 
-	// Connect to database using the database path from punish config
-	db, err := punishments_db.Init(punishConfig.DatabasePath)
-	if err != nil {
-		log.Printf("Error connecting to punishment DB: %v", err)
-		utils.SendFollowUpError(s, i.Interaction, "Failed to connect to the punishment database.")
-		return
+	if level == nil {
+		preview, err := previewPunishment(ctx, req)
+		if err != nil {
+			utils.SendFollowUpError(s, i.Interaction, err.Error())
+			return false
+		}
+		if preview != nil {
+			showPunishmentPreview(ctx, s, i, b, req, preview)
+			return true
+		}
 	}
-	defer db.Close()
 
-	// Get total punishment count for this user (all action types)
-	punishmentCount, err := punishments_db.GetTotalPunishmentCountByUser(db, i.GuildID, targetUser.ID)
+	result, err := ExecutePunishment(ctx, s, b, req)
 	if err != nil {
-		log.Printf("Error getting total punishment count: %v", err)
-		utils.SendFollowUpError(s, i.Interaction, "Failed to retrieve punishment history.")
-		return
+		utils.SendFollowUpError(s, i.Interaction, err.Error())
+		return false
 	}
 
-	// Determine punishment level based on count
-	punishLevel := getPunishmentLevel(actionConfig, punishmentCount)
-	if punishLevel == nil {
-		// Use the highest available level if count exceeds configured levels
-		punishLevel = getHighestPunishmentLevel(actionConfig)
-	}
-
-	// Check if we still don't have a punishment level (config might be empty)
-	if punishLevel == nil {
-		log.Printf("No punishment levels configured for action '%s' in guild %s", action, i.GuildID)
-		utils.SendFollowUpError(s, i.Interaction, fmt.Sprintf("❌ 处罚类型 '%s' 未配置任何惩罚等级", actionConfig.Name))
-		return
+	// Edit deferred response to complete the interaction
+	responseMessage := "✅ 处罚已成功执行。"
+	if result.Status == model.PunishmentStatusPendingApproval {
+		responseMessage = fmt.Sprintf("⏳ 该处罚等级需要另一位管理员确认，已提交至审核频道（处罚ID: %d），%s 内未确认将自动失效。", result.PunishmentID, result.ApprovalTimeout)
 	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &responseMessage,
+	})
+	return false
+}
 
-	// Apply punishments according to the level
-	timeoutApplied, timeoutDurationStr, tempRoles, rolesRemoveAt := applyPunishmentLevel(s, i, targetUser, *punishLevel)
-
-	// Record the punishment
-	punishmentID, err := addPunishmentRecord(db, i, targetUser, reason, evidenceJSON, action, tempRoles, rolesRemoveAt)
-	if err != nil {
-		log.Printf("Error saving punishment record: %v", err)
-		utils.SendFollowUpError(s, i.Interaction, "Failed to save the punishment record.")
-		return
-	}
+// notifyPunishment sends the punishment notice to the user by DM and to the command channel.
+// It returns the detailed embed for the admin channel, which the caller posts or edits in place.
+func notifyPunishment(ctx context.Context, s *discordgo.Session, b *bot.Bot, db *sqlx.DB, plan punishmentPlan, actionConfig model.ActionConfig, allEvidence []Evidence, punishmentID int64, timeoutApplied bool, timeoutDurationStr string) *discordgo.MessageEmbed {
+	punishLevel := &plan.Level
 
 	// Get history for display
-	currentGuildHistory, otherGuildsHistory, err := getPunishmentHistory(db, targetUser.ID, i.GuildID)
+	currentGuildHistory, otherGuildsHistory, err := getPunishmentHistory(db, plan.TargetUser.ID, plan.GuildID)
 	if err != nil {
 		log.Printf("Error fetching punishment history: %v", err)
 	}
 
 	// Build soft embed for private message and command channel
-	softEmbed := buildSoftPunishmentEmbed(targetUser, punishLevel, reason, timeoutDurationStr, i.Member.User.Username, punishmentID, isSelfPunish)
+	softEmbed := buildSoftPunishmentEmbed(plan.TargetUser, punishLevel, plan.Reason, timeoutDurationStr, plan.AdminUsername, punishmentID, plan.IsSelfPunish)
 
 	// Build detailed embed for admin log channel
-	adminEmbed := buildPunishmentEmbedNew(i, targetUser, &actionConfig, reason, allEvidence, currentGuildHistory, otherGuildsHistory, timeoutApplied, timeoutDurationStr, punishmentID, punishLevel, isSelfPunish)
+	adminEmbed := buildPunishmentEmbedNew(plan.AdminUsername, plan.TargetUser, &actionConfig, plan.Reason, allEvidence, currentGuildHistory, otherGuildsHistory, timeoutApplied, timeoutDurationStr, punishmentID, punishLevel, plan.IsSelfPunish)
+	adminEmbed.Footer.Text = trace.Footer(ctx, adminEmbed.Footer.Text)
 
 	// Prepare preset message if configured
 	var presetContent string
@@ -379,36 +353,30 @@ func applyAndLogPunishment(s *discordgo.Session, i *discordgo.InteractionCreate,
 		}
 	}
 
-	// Send soft embed to private message
-	utils.SendPrivateEmbedMessage(s, targetUser.ID, softEmbed)
+	// Send soft embed to private message, with an appeal button unless the user punished themselves
+	privateMessage := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{softEmbed}}
+	if !plan.IsSelfPunish {
+		privateMessage.Components = buildAppealComponents(punishmentID)
+	}
+	utils.SendPrivateComplexMessage(s, plan.TargetUser.ID, privateMessage)
 
 	// Send preset content and embeds to private message
 	if presetContent != "" {
-		utils.SendPrivateMessage(s, targetUser.ID, presetContent)
+		utils.SendPrivateMessage(s, plan.TargetUser.ID, presetContent)
 	}
 	for _, e := range presetEmbeds {
-		utils.SendPrivateEmbedMessage(s, targetUser.ID, e)
+		utils.SendPrivateEmbedMessage(s, plan.TargetUser.ID, e)
 	}
 
-	// Send soft embed to command execution channel
-	_, err = s.ChannelMessageSendEmbed(i.ChannelID, softEmbed)
-	if err != nil {
-		log.Printf("Error sending public punishment message: %v", err)
-	}
-
-	// Send detailed embed to admin log channel
-	if actionConfig.AdminChannelID != "" {
-		_, err = s.ChannelMessageSendEmbed(actionConfig.AdminChannelID, adminEmbed)
+	// Send soft embed to command execution channel, if the punishment was issued from one
+	if plan.ChannelID != "" {
+		_, err = s.ChannelMessageSendEmbed(plan.ChannelID, softEmbed)
 		if err != nil {
-			log.Printf("Error sending admin log message: %v", err)
+			log.Printf("Error sending public punishment message: %v", err)
 		}
 	}
 
-	// Edit deferred response to complete the interaction
-	responseMessage := "✅ 处罚已成功执行。"
-	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: &responseMessage,
-	})
+	return adminEmbed
 }
 
 // HandleResetPunishCooldownCommand handles the slash command to reset all punishment cooldowns.
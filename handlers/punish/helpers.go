@@ -1,8 +1,11 @@
 package punish
 
 import (
+	"context"
+	"newer_helper/grpc/events"
 	"newer_helper/model"
 	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
 	"time"
 
 	"encoding/json"
@@ -135,37 +138,102 @@ func removePunishmentRoles(s *discordgo.Session, guildID, userID string, roleIDs
 	}
 }
 
-// addPunishmentRecord adds a new punishment record to the database and returns the new record's ID.
-func addPunishmentRecord(db *sqlx.DB, i *discordgo.InteractionCreate, targetUser *discordgo.User, reason, evidenceJSON, actionType string, tempRoles []string, rolesRemoveAt map[string]time.Time) (int64, error) {
-	log.Printf("[DEBUG] addPunishmentRecord: tempRoles=%v, rolesRemoveAt=%v", tempRoles, rolesRemoveAt)
+// punishmentPlan holds everything needed to enforce and announce a punishment once its level has been decided.
+// It is stored as JSON while a punishment waits for approval.
+type punishmentPlan struct {
+	GuildID       string            `json:"guild_id"`
+	ChannelID     string            `json:"channel_id"`
+	MessageID     string            `json:"message_id"`
+	ActionType    string            `json:"action_type"`
+	TargetUser    *discordgo.User   `json:"target_user"`
+	AdminID       string            `json:"admin_id"`
+	AdminUsername string            `json:"admin_username"`
+	Reason        string            `json:"reason"`
+	EvidenceJSON  string            `json:"evidence_json"`
+	Level         model.PunishLevel `json:"level"`
+	LevelKey      string            `json:"level_key"`
+	IsSelfPunish  bool              `json:"is_self_punish"`
+}
+
+// encodeTempRoles serializes the temporary roles and their removal times for storage.
+func encodeTempRoles(tempRoles []string, rolesRemoveAt map[string]time.Time) (string, string, error) {
+	if tempRoles == nil {
+		tempRoles = []string{}
+	}
+	if rolesRemoveAt == nil {
+		rolesRemoveAt = make(map[string]time.Time)
+	}
 
-	// Serialize temp roles to JSON
 	tempRolesJSON, err := json.Marshal(tempRoles)
 	if err != nil {
-		return 0, fmt.Errorf("failed to serialize temp roles: %w", err)
+		return "", "", fmt.Errorf("failed to serialize temp roles: %w", err)
 	}
 
-	// Serialize roles remove times to JSON
 	rolesRemoveAtJSON, err := json.Marshal(rolesRemoveAt)
 	if err != nil {
-		return 0, fmt.Errorf("failed to serialize roles remove times: %w", err)
+		return "", "", fmt.Errorf("failed to serialize roles remove times: %w", err)
+	}
+
+	return string(tempRolesJSON), string(rolesRemoveAtJSON), nil
+}
+
+// unixOrZero converts t to Unix seconds, keeping the zero time as 0.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// auditPunishmentCreated records a newly stored punishment in the audit log.
+func auditPunishmentCreated(ctx context.Context, db *sqlx.DB, punishmentID int64, actorID, reason string) {
+	record, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+	if err != nil {
+		trace.Logf(ctx, "Failed to load punishment %d for audit: %v", punishmentID, err)
+		return
+	}
+	if err := punishments_db.RecordAudit(ctx, db, model.AuditActionCreate, actorID, reason, nil, record); err != nil {
+		trace.Logf(ctx, "Failed to write audit entry for punishment %d: %v", punishmentID, err)
+	}
+}
+
+// publishPunishmentEvent announces a change to a punishment to other services connected to the gateway.
+func publishPunishmentEvent(ctx context.Context, db *sqlx.DB, eventType string, punishmentID int64, adminID string) {
+	record, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+	if err != nil {
+		trace.Logf(ctx, "Failed to load punishment %d for %s event: %v", punishmentID, eventType, err)
+		return
 	}
+	events.Publish(eventType, events.NewPunishmentEvent(record, adminID), map[string]string{"guild_id": record.GuildID, "trace_id": trace.ID(ctx)})
+}
 
-	log.Printf("[DEBUG] Serialized: tempRolesJSON='%s', rolesRemoveAtJSON='%s'", string(tempRolesJSON), string(rolesRemoveAtJSON))
+// addPunishmentRecord saves the punishment described by the plan with the given status.
+func addPunishmentRecord(db *sqlx.DB, plan punishmentPlan, applied appliedPunishment, status string) (int64, error) {
+	log.Printf("[DEBUG] addPunishmentRecord: tempRoles=%v, rolesRemoveAt=%v", applied.tempRoles, applied.rolesRemoveAt)
+
+	tempRolesJSON, rolesRemoveAtJSON, err := encodeTempRoles(applied.tempRoles, applied.rolesRemoveAt)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("[DEBUG] Serialized: tempRolesJSON='%s', rolesRemoveAtJSON='%s'", tempRolesJSON, rolesRemoveAtJSON)
 
 	record := model.PunishmentRecord{
-		MessageID:        i.ID,
-		AdminID:          i.Member.User.ID,
-		UserID:           targetUser.ID,
-		UserUsername:     targetUser.Username,
-		Reason:           reason,
-		GuildID:          i.GuildID,
+		MessageID:        plan.MessageID,
+		AdminID:          plan.AdminID,
+		UserID:           plan.TargetUser.ID,
+		UserUsername:     plan.TargetUser.Username,
+		Reason:           plan.Reason,
+		GuildID:          plan.GuildID,
 		Timestamp:        time.Now().Unix(),
-		Evidence:         evidenceJSON,
-		ActionType:       actionType,
-		TempRolesJSON:    string(tempRolesJSON),
-		RolesRemoveAt:    string(rolesRemoveAtJSON),
-		PunishmentStatus: "active",
+		Evidence:         plan.EvidenceJSON,
+		ActionType:       plan.ActionType,
+		TempRolesJSON:    tempRolesJSON,
+		RolesRemoveAt:    rolesRemoveAtJSON,
+		PunishmentStatus: status,
+		TimeoutUntil:     unixOrZero(applied.timeoutUntil),
+		BanUntil:         applied.banUntil,
+		LevelKey:         plan.LevelKey,
 	}
 	return punishments_db.AddPunishmentRecord(db, record)
 }
@@ -251,28 +319,75 @@ func isUserWhitelistedForAction(targetMember *discordgo.Member, actionConfig mod
 	return false
 }
 
-// getPunishmentLevel returns the punishment level configuration for the given count.
-func getPunishmentLevel(actionConfig model.ActionConfig, count int) *model.PunishLevel {
+// determinePunishmentLevel picks the punish level for the user's next punishment and returns its key and config.
+// Actions in points mode use the decayed score of all the user's punishments in the guild;
+// all other actions use the total punishment count. Actions with count_warnings also count formal warnings.
+func determinePunishmentLevel(db *sqlx.DB, guildID, userID string, guildActions map[string]model.ActionConfig, actionConfig model.ActionConfig) (string, *model.PunishLevel, error) {
+	var warnings []model.UserNote
+	if actionConfig.CountWarnings {
+		var err error
+		warnings, err = punishments_db.GetUserWarnings(db, guildID, userID)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if utils.IsPointsEscalation(actionConfig) {
+		records, err := punishments_db.GetPunishmentRecordsByUserID(db, userID, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		var guildRecords []model.PunishmentRecord
+		for _, record := range records {
+			if record.GuildID == guildID {
+				guildRecords = append(guildRecords, record)
+			}
+		}
+		guildRecords = append(guildRecords, utils.WarningRecords(warnings)...)
+		score := utils.CalculatePunishmentScore(guildRecords, guildActions, actionConfig, time.Now())
+		key, level := utils.SelectLevelByScore(actionConfig, score)
+		return key, level, nil
+	}
+
+	// Get total punishment count for this user (all action types)
+	punishmentCount, err := punishments_db.GetTotalPunishmentCountByUser(db, guildID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	punishmentCount += len(warnings)
+
+	levelKey, punishLevel := getPunishmentLevel(actionConfig, punishmentCount)
+	if punishLevel == nil {
+		// Use the highest available level if count exceeds configured levels
+		levelKey, punishLevel = getHighestPunishmentLevel(actionConfig)
+	}
+	return levelKey, punishLevel, nil
+}
+
+// getPunishmentLevel returns the key and configuration of the punishment level for the given count.
+func getPunishmentLevel(actionConfig model.ActionConfig, count int) (string, *model.PunishLevel) {
 	countStr := fmt.Sprintf("%d", count)
 	if level, ok := actionConfig.Data[countStr]; ok {
-		return &level
+		return countStr, &level
 	}
-	return nil
+	return "", nil
 }
 
-// getHighestPunishmentLevel returns the highest available punishment level.
-func getHighestPunishmentLevel(actionConfig model.ActionConfig) *model.PunishLevel {
+// getHighestPunishmentLevel returns the key and configuration of the highest available punishment level.
+func getHighestPunishmentLevel(actionConfig model.ActionConfig) (string, *model.PunishLevel) {
 	var highest *model.PunishLevel
+	var highestLevelKey string
 	var highestKey int = -1
 
 	for key, level := range actionConfig.Data {
 		if keyInt := parseIntSafe(key); keyInt > highestKey {
 			highestKey = keyInt
+			highestLevelKey = key
 			levelCopy := level
 			highest = &levelCopy
 		}
 	}
-	return highest
+	return highestLevelKey, highest
 }
 
 // parseIntSafe safely parses an integer, returning 0 if parsing fails.
@@ -284,42 +399,77 @@ func parseIntSafe(s string) int {
 	return 0
 }
 
+// levelBan reports whether the level bans the user and for how long, zero meaning a permanent ban.
+// The legacy `"timeout": "ban"` is a permanent ban.
+func levelBan(level model.PunishLevel) (bool, time.Duration, error) {
+	ban := level.Ban
+	if ban == "" && level.Timeout == "ban" {
+		ban = model.BanPermanent
+	}
+	switch ban {
+	case "":
+		return false, 0, nil
+	case model.BanPermanent:
+		return true, 0, nil
+	}
+	banDuration, err := utils.ParseDuration(ban)
+	if err == nil && banDuration <= 0 {
+		err = fmt.Errorf("duration must be positive")
+	}
+	return true, banDuration, err
+}
+
+// appliedPunishment describes what applyPunishmentLevel actually did to the user.
+type appliedPunishment struct {
+	timeoutApplied     bool // a timeout or ban was applied
+	timeoutDurationStr string
+	tempRoles          []string
+	rolesRemoveAt      map[string]time.Time
+	timeoutUntil       time.Time // zero unless a timeout was applied
+	banUntil           int64     // see model.PunishmentRecord.BanUntil
+}
+
 // applyPunishmentLevel applies the punishment actions according to the punishment level.
-// Returns: timeoutApplied, timeoutDurationStr, tempRoles, rolesRemoveAt
-func applyPunishmentLevel(s *discordgo.Session, i *discordgo.InteractionCreate, targetUser *discordgo.User, level model.PunishLevel) (bool, string, []string, map[string]time.Time) {
+func applyPunishmentLevel(s *discordgo.Session, guildID string, targetUser *discordgo.User, level model.PunishLevel) appliedPunishment {
 	log.Printf("[DEBUG] applyPunishmentLevel: user=%s, AddRoleTimeoutTime='%s', AddRole=%v",
 		targetUser.ID, level.AddRoleTimeoutTime, level.AddRole)
 
 	// Remove roles
-	removePunishmentRoles(s, i.GuildID, targetUser.ID, level.RemoveRoleID)
+	removePunishmentRoles(s, guildID, targetUser.ID, level.RemoveRoleID)
 
 	timeoutApplied := false
 	timeoutDurationStr := ""
+	var appliedTimeoutUntil time.Time
+	var banUntil int64
 
 	// Apply timeout/ban
-	if level.Timeout != "" && level.Timeout != "0" {
-		if level.Timeout == "ban" {
-			// Apply ban
-			err := s.GuildBanCreateWithReason(i.GuildID, targetUser.ID, "Automatic punishment ban", 0)
+	if banned, banDuration, err := levelBan(level); banned {
+		if err != nil {
+			log.Printf("Invalid ban duration '%s', not banning user %s: %v", level.Ban, targetUser.ID, err)
+		} else if err := s.GuildBanCreateWithReason(guildID, targetUser.ID, "Automatic punishment ban", level.BanDeleteMessageDays); err != nil {
+			log.Printf("Failed to ban user %s: %v", targetUser.ID, err)
+		} else if banDuration > 0 {
+			timeoutApplied = true
+			banUntil = time.Now().Add(banDuration).Unix()
+			timeoutDurationStr = fmt.Sprintf("封禁至 <t:%d:f>", banUntil)
+		} else {
+			timeoutApplied = true
+			banUntil = model.BanUntilPermanent
+			timeoutDurationStr = "永久封禁"
+		}
+	} else if level.Timeout != "" && level.Timeout != "0" {
+		// Apply timeout (assume it's in days)
+		days := parseIntSafe(level.Timeout)
+		if days > 0 {
+			timeoutDuration := time.Duration(days) * 24 * time.Hour
+			timeoutUntil := time.Now().Add(timeoutDuration)
+			err := s.GuildMemberTimeout(guildID, targetUser.ID, &timeoutUntil)
 			if err != nil {
-				log.Printf("Failed to ban user %s: %v", targetUser.ID, err)
+				log.Printf("Failed to timeout user %s: %v", targetUser.ID, err)
 			} else {
 				timeoutApplied = true
-				timeoutDurationStr = "永久封禁"
-			}
-		} else {
-			// Apply timeout (assume it's in days)
-			days := parseIntSafe(level.Timeout)
-			if days > 0 {
-				timeoutDuration := time.Duration(days) * 24 * time.Hour
-				timeoutUntil := time.Now().Add(timeoutDuration)
-				err := s.GuildMemberTimeout(i.GuildID, targetUser.ID, &timeoutUntil)
-				if err != nil {
-					log.Printf("Failed to timeout user %s: %v", targetUser.ID, err)
-				} else {
-					timeoutApplied = true
-					timeoutDurationStr = fmt.Sprintf("%d天", days)
-				}
+				timeoutDurationStr = fmt.Sprintf("%d天", days)
+				appliedTimeoutUntil = timeoutUntil
 			}
 		}
 	}
@@ -334,7 +484,7 @@ func applyPunishmentLevel(s *discordgo.Session, i *discordgo.InteractionCreate,
 			continue // Skip "0" roles
 		}
 
-		err := s.GuildMemberRoleAdd(i.GuildID, targetUser.ID, roleID)
+		err := s.GuildMemberRoleAdd(guildID, targetUser.ID, roleID)
 		if err != nil {
 			log.Printf("Failed to add role %s to user %s: %v", roleID, targetUser.ID, err)
 			continue
@@ -375,7 +525,14 @@ func applyPunishmentLevel(s *discordgo.Session, i *discordgo.InteractionCreate,
 	}
 
 	log.Printf("[DEBUG] Final result: tempRoles=%v, rolesRemoveAt=%v", tempRoles, rolesRemoveAt)
-	return timeoutApplied, timeoutDurationStr, tempRoles, rolesRemoveAt
+	return appliedPunishment{
+		timeoutApplied:     timeoutApplied,
+		timeoutDurationStr: timeoutDurationStr,
+		tempRoles:          tempRoles,
+		rolesRemoveAt:      rolesRemoveAt,
+		timeoutUntil:       appliedTimeoutUntil,
+		banUntil:           banUntil,
+	}
 }
 
 // logPunishmentNew sends a detailed log message to the configured log channel using new config.
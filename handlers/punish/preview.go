@@ -0,0 +1,298 @@
+package punish
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"newer_helper/bot"
+	preset_pkg "newer_helper/handlers/preset"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// punishmentPreview is what a punishment will do, worked out without applying anything.
+type punishmentPreview struct {
+	ActionConfig model.ActionConfig
+	Level        model.PunishLevel
+	LevelKey     string
+	IsSelfPunish bool
+}
+
+// previewPunishment works out the punish level the request would apply. It returns nil if the request needs no
+// preview, including when it cannot be executed at all, so that ExecutePunishment reports the problem.
+// Returned errors are *PunishError.
+func previewPunishment(ctx context.Context, req PunishmentRequest) (*punishmentPreview, error) {
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		trace.Logf(ctx, "Error loading punish config for preview: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to load punishment configuration.")
+	}
+	guildActions := punishConfig.PunishConfig[req.GuildID]
+	actionConfig, ok := guildActions[req.Action]
+	if !ok {
+		return nil, nil
+	}
+
+	// Skip the history lookup for actions where no level needs a preview
+	needed := false
+	for _, level := range actionConfig.Data {
+		if utils.PunishPreviewRequired(actionConfig, level) {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil, nil
+	}
+
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		trace.Logf(ctx, "Error connecting to punishment DB for preview: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to connect to the punishment database.")
+	}
+	defer db.Close()
+
+	levelKey, punishLevel, err := determinePunishmentLevel(db, req.GuildID, req.TargetUser.ID, guildActions, actionConfig)
+	if err != nil {
+		trace.Logf(ctx, "Error determining punishment level for preview: %v", err)
+		return nil, punishError(PunishErrInternal, "Failed to retrieve punishment history.")
+	}
+	if punishLevel == nil || !utils.PunishPreviewRequired(actionConfig, *punishLevel) {
+		return nil, nil
+	}
+
+	return &punishmentPreview{
+		ActionConfig: actionConfig,
+		Level:        *punishLevel,
+		LevelKey:     levelKey,
+		IsSelfPunish: req.AdminID == req.TargetUser.ID,
+	}, nil
+}
+
+// showPunishmentPreview stores the previewed punishment and replaces the deferred response with the preview
+// and its Confirm/Cancel buttons.
+func showPunishmentPreview(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot, req PunishmentRequest, preview *punishmentPreview) {
+	pendingID, err := newPendingID()
+	if err != nil {
+		trace.Logf(ctx, "Error generating random ID for punishment preview: %v", err)
+		utils.SendFollowUpError(s, i.Interaction, "Failed to create a pending punishment.")
+		return
+	}
+
+	level := preview.Level
+	err = b.SavePendingPunishment(pendingID, &bot.PendingPunishment{
+		TargetUser:    req.TargetUser,
+		Reason:        req.Reason,
+		EvidenceLinks: req.EvidenceLinks,
+		Action:        req.Action,
+		Level:         &level,
+		LevelKey:      preview.LevelKey,
+		Timestamp:     time.Now(),
+	})
+	if err != nil {
+		trace.Logf(ctx, "Error saving punishment preview: %v", err)
+		utils.SendFollowUpError(s, i.Interaction, "Failed to create a pending punishment.")
+		return
+	}
+
+	embeds := []*discordgo.MessageEmbed{
+		buildPunishmentPlanEmbed(b, req, preview),
+		buildPreviewDMEmbed(req, preview),
+	}
+	if level.SendPresetID != "" {
+		if preset := b.FindPresetByID(level.SendPresetID); preset != nil {
+			embeds = append(embeds, preset_pkg.FormatPresetMessageSend(preset, "").Embeds...)
+		}
+	}
+	if len(embeds) > 10 {
+		embeds = embeds[:10]
+	}
+
+	content := "⚠️ 处罚尚未执行，请确认以下内容。"
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "确认执行",
+					Style:    discordgo.DangerButton,
+					CustomID: "punish_preview_confirm_" + pendingID,
+				},
+				discordgo.Button{
+					Label:    "取消",
+					Style:    discordgo.SecondaryButton,
+					CustomID: "punish_preview_cancel_" + pendingID,
+				},
+			},
+		},
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		trace.Logf(ctx, "Error sending punishment preview: %v", err)
+	}
+}
+
+// HandlePunishPreviewDecision handles the Confirm/Cancel buttons of a punishment preview.
+// Confirming applies exactly the previewed level, even if the user's history changed in the meantime.
+func HandlePunishPreviewDecision(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, b *bot.Bot) {
+	customID := i.MessageComponentData().CustomID
+	confirm := strings.HasPrefix(customID, "punish_preview_confirm_")
+	pendingID := strings.TrimPrefix(strings.TrimPrefix(customID, "punish_preview_confirm_"), "punish_preview_cancel_")
+
+	emptyComponents := []discordgo.MessageComponent{}
+
+	// Taking the entry removes it, which prevents double execution
+	pending, err := b.TakePendingPunishment(pendingID)
+	if err != nil || pending.Level == nil || !confirm {
+		content := "已取消，未执行任何处罚。"
+		if err != nil {
+			if !bot.IsPendingExpired(err) {
+				log.Printf("Error loading punishment preview %s: %v", pendingID, err)
+			}
+			content = "⏰ 此处罚预览已过期（超过5分钟未确认），请重新发起处罚。"
+		}
+		err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Content:    content,
+				Components: emptyComponents,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to update punishment preview: %v", err)
+		}
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Failed to defer interaction: %v", err)
+		return
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Components: &emptyComponents,
+	})
+
+	applyAndLogPunishment(ctx, s, i, b, pending.TargetUser, pending.Action, pending.Reason, pending.EvidenceLinks, pending.Level, pending.LevelKey)
+}
+
+// buildPunishmentPlanEmbed lists everything the previewed level will do to the user.
+func buildPunishmentPlanEmbed(b *bot.Bot, req PunishmentRequest, preview *punishmentPreview) *discordgo.MessageEmbed {
+	level := preview.Level
+
+	removeRoles := level.RemoveRoleID
+	if slices.Contains(removeRoles, "0") {
+		// removePunishmentRoles skips the whole list if it contains "0"
+		removeRoles = nil
+	}
+
+	addRoles := utils.RoleMentionList(level.AddRole)
+	if addRoles != "无" {
+		if days := parseIntSafe(level.AddRoleTimeoutTime); days > 0 {
+			addRoles += fmt.Sprintf("（%d 天后移除）", days)
+		} else {
+			addRoles += "（永久）"
+		}
+	}
+
+	sanction := expectedTimeoutDuration(level)
+	if banned, _, err := levelBan(level); banned {
+		if err != nil {
+			sanction = fmt.Sprintf("❌ 封禁时长 `%s` 无效，不会封禁", level.Ban)
+		} else if level.BanDeleteMessageDays > 0 {
+			sanction += fmt.Sprintf("，删除 %d 天内的消息", level.BanDeleteMessageDays)
+		}
+	} else if sanction != "" {
+		sanction = "禁言 " + sanction
+	} else {
+		sanction = "无"
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "处罚预览（尚未执行）",
+		Description: fmt.Sprintf("确认后将对 %s 执行以下处罚。", req.TargetUser.Mention()),
+		Color:       getEmbedColor(&level),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "处罚类型", Value: fmt.Sprintf("%s (`%s`)", preview.ActionConfig.Name, req.Action), Inline: true},
+			{Name: "禁言/封禁", Value: sanction, Inline: true},
+			{Name: "处罚原因", Value: utils.TruncateString(req.Reason, 1024)},
+			{Name: "移除身份组", Value: utils.RoleMentionList(removeRoles)},
+			{Name: "添加身份组", Value: addRoles},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("操作人: %s", req.AdminUsername),
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if level.SendPresetID != "" {
+		value := fmt.Sprintf("❌ 找不到预设 `%s`，不会发送", level.SendPresetID)
+		if preset := b.FindPresetByID(level.SendPresetID); preset != nil {
+			value = fmt.Sprintf("**%s** (`%s`)", preset.Name, preset.ID)
+			if content := preset_pkg.FormatPresetMessageSend(preset, "").Content; content != "" {
+				value += "\n" + utils.TruncateString(content, 900)
+			}
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "发送预设", Value: value})
+	}
+	if level.RequiresApproval && !preview.IsSelfPunish {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "审批",
+			Value: "该等级需要另一位管理员确认，确认后将提交至审核频道",
+		})
+	}
+	return embed
+}
+
+// buildPreviewDMEmbed renders the private message the user will receive.
+func buildPreviewDMEmbed(req PunishmentRequest, preview *punishmentPreview) *discordgo.MessageEmbed {
+	level := preview.Level
+	embed := buildSoftPunishmentEmbed(req.TargetUser, &level, req.Reason, expectedTimeoutDuration(level), req.AdminUsername, 0, preview.IsSelfPunish)
+	embed.Title = "私信预览"
+	if embed.Description == "" {
+		embed.Description = "*（该等级未配置私信内容）*"
+	}
+	embed.Footer.Text = "处罚ID 将在执行后生成"
+	return embed
+}
+
+// expectedTimeoutDuration predicts the timeout or ban description applyPunishmentLevel reports for the level,
+// empty if it applies neither.
+func expectedTimeoutDuration(level model.PunishLevel) string {
+	if banned, banDuration, err := levelBan(level); banned {
+		switch {
+		case err != nil:
+			return ""
+		case banDuration > 0:
+			return fmt.Sprintf("封禁至 <t:%d:f>", time.Now().Add(banDuration).Unix())
+		}
+		return "永久封禁"
+	}
+	if days := parseIntSafe(level.Timeout); days > 0 {
+		return fmt.Sprintf("%d天", days)
+	}
+	return ""
+}
+
+// newPendingID returns a random ID for a pending punishment.
+func newPendingID() (string, error) {
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(idBytes), nil
+}
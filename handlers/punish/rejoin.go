@@ -0,0 +1,164 @@
+package punish
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandleGuildMemberAdd re-applies the temporary roles and remaining timeout of in-effect punishments
+// when a punished member rejoins, so leaving the guild cannot be used to shed them.
+func HandleGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.User == nil || m.User.Bot {
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config for member rejoin: %v", err)
+		return
+	}
+	guildActions, ok := punishConfig.PunishConfig[m.GuildID]
+	if !ok {
+		return
+	}
+
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB for member rejoin: %v", err)
+		return
+	}
+	defer db.Close()
+
+	records, err := punishments_db.GetInEffectPunishmentsByUser(db, m.GuildID, m.User.ID)
+	if err != nil {
+		log.Printf("Error getting in-effect punishments for rejoining user %s: %v", m.User.ID, err)
+		return
+	}
+
+	now := time.Now()
+	for _, record := range records {
+		reappliedRoles, timeoutUntil := reapplyPunishment(s, record, now)
+		if len(reappliedRoles) == 0 && timeoutUntil.IsZero() {
+			continue
+		}
+
+		log.Printf("Re-applied punishment ID %d to rejoining user %s (roles: %v)", record.PunishmentID, m.User.ID, reappliedRoles)
+
+		actionConfig := guildActions[record.ActionType]
+		if actionConfig.AdminChannelID == "" {
+			continue
+		}
+		embed := buildRejoinEmbed(m.User, record, actionConfig, reappliedRoles, timeoutUntil)
+		if _, err := s.ChannelMessageSendEmbed(actionConfig.AdminChannelID, embed); err != nil {
+			log.Printf("Error sending rejoin notice to admin channel %s: %v", actionConfig.AdminChannelID, err)
+		}
+	}
+}
+
+// reapplyPunishment adds back the temporary roles of a punishment that have not expired yet and restores
+// the remainder of its timeout. It returns the roles and timeout that were actually applied.
+func reapplyPunishment(s *discordgo.Session, record model.PunishmentRecord, now time.Time) ([]string, time.Time) {
+	var tempRoles []string
+	if record.TempRolesJSON != "" {
+		if err := json.Unmarshal([]byte(record.TempRolesJSON), &tempRoles); err != nil {
+			log.Printf("Failed to parse temp_roles_json for punishment ID %d: %v", record.PunishmentID, err)
+		}
+	}
+	rolesRemoveAt := make(map[string]time.Time)
+	if record.RolesRemoveAt != "" {
+		if err := json.Unmarshal([]byte(record.RolesRemoveAt), &rolesRemoveAt); err != nil {
+			log.Printf("Failed to parse roles_remove_at for punishment ID %d: %v", record.PunishmentID, err)
+		}
+	}
+
+	var reappliedRoles []string
+	for _, roleID := range tempRoles {
+		if roleID == "" || roleID == "0" {
+			continue
+		}
+		// Without any removal times the roles are permanent; otherwise roles missing from the
+		// schedule have already expired and been removed.
+		if len(rolesRemoveAt) > 0 {
+			removeAt, ok := rolesRemoveAt[roleID]
+			if !ok || !removeAt.After(now) {
+				continue
+			}
+		}
+		if err := s.GuildMemberRoleAdd(record.GuildID, record.UserID, roleID); err != nil {
+			log.Printf("Failed to re-add role %s to user %s: %v", roleID, record.UserID, err)
+			continue
+		}
+		reappliedRoles = append(reappliedRoles, roleID)
+	}
+
+	var timeoutUntil time.Time
+	if record.TimeoutUntil > now.Unix() {
+		until := time.Unix(record.TimeoutUntil, 0)
+		if err := s.GuildMemberTimeout(record.GuildID, record.UserID, &until); err != nil {
+			log.Printf("Failed to restore timeout for user %s: %v", record.UserID, err)
+		} else {
+			timeoutUntil = until
+		}
+	}
+
+	return reappliedRoles, timeoutUntil
+}
+
+// buildRejoinEmbed creates the admin channel notice for a punished member who left and rejoined.
+func buildRejoinEmbed(user *discordgo.User, record model.PunishmentRecord, actionConfig model.ActionConfig, reappliedRoles []string, timeoutUntil time.Time) *discordgo.MessageEmbed {
+	actionName := actionConfig.Name
+	if actionName == "" {
+		actionName = record.ActionType
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚠️ 检测到处罚规避",
+		Description: fmt.Sprintf("<@%s> 在处罚生效期间退出并重新加入了服务器，已重新执行尚未到期的处罚。", user.ID),
+		Color:       0xFFA500, // Orange
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name:   "用户",
+				Value:  fmt.Sprintf("<@%s> (`%s`)", user.ID, user.Username),
+				Inline: true,
+			},
+			{
+				Name:   "处罚ID",
+				Value:  fmt.Sprintf("%d", record.PunishmentID),
+				Inline: true,
+			},
+			{
+				Name:   "处罚类型",
+				Value:  actionName,
+				Inline: true,
+			},
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if len(reappliedRoles) > 0 {
+		mentions := make([]string, len(reappliedRoles))
+		for i, roleID := range reappliedRoles {
+			mentions[i] = fmt.Sprintf("<@&%s>", roleID)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "重新添加的身份组",
+			Value: strings.Join(mentions, " "),
+		})
+	}
+	if !timeoutUntil.IsZero() {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "恢复禁言至",
+			Value: fmt.Sprintf("<t:%d:f>", timeoutUntil.Unix()),
+		})
+	}
+
+	return embed
+}
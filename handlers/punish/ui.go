@@ -2,6 +2,7 @@ package punish
 
 import (
 	"fmt"
+	"log"
 	"newer_helper/model"
 	"newer_helper/utils"
 	"time"
@@ -9,8 +10,74 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// maxPunishActionButtons is the number of actions shown as buttons; with more, a select menu is used instead.
+	maxPunishActionButtons = 5
+	// maxPunishActionOptions is the most options a Discord select menu can hold.
+	maxPunishActionOptions = 25
+)
+
+// buildPunishActionComponents renders the visible actions of a guild for the quick-punish preview,
+// as a row of buttons or, when there are too many for one row, as a select menu.
+func buildPunishActionComponents(pendingID string, guildActions map[string]model.ActionConfig) []discordgo.MessageComponent {
+	var actionKeys []string
+	for _, actionKey := range utils.OrderedPunishActions(guildActions) {
+		if !guildActions[actionKey].Hidden {
+			actionKeys = append(actionKeys, actionKey)
+		}
+	}
+	if len(actionKeys) == 0 {
+		return nil
+	}
+
+	if len(actionKeys) <= maxPunishActionButtons {
+		row := discordgo.ActionsRow{}
+		for _, actionKey := range actionKeys {
+			actionConfig := guildActions[actionKey]
+			style, _ := utils.PunishActionButtonStyle(actionConfig)
+			emoji, _ := utils.PunishActionEmoji(actionConfig)
+			row.Components = append(row.Components, discordgo.Button{
+				Label:    utils.TruncateString(utils.PunishActionLabel(actionKey, actionConfig), 80),
+				Style:    style,
+				Emoji:    emoji,
+				CustomID: fmt.Sprintf("punish_action_%s_%s", pendingID, actionKey),
+			})
+		}
+		return []discordgo.MessageComponent{row}
+	}
+
+	if len(actionKeys) > maxPunishActionOptions {
+		log.Printf("Quick punish preview shows only the first %d of %d actions", maxPunishActionOptions, len(actionKeys))
+		actionKeys = actionKeys[:maxPunishActionOptions]
+	}
+	options := make([]discordgo.SelectMenuOption, 0, len(actionKeys))
+	for _, actionKey := range actionKeys {
+		actionConfig := guildActions[actionKey]
+		emoji, _ := utils.PunishActionEmoji(actionConfig)
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       utils.TruncateString(utils.PunishActionLabel(actionKey, actionConfig), 100),
+			Value:       actionKey,
+			Description: utils.TruncateString(actionKey, 100),
+			Emoji:       emoji,
+		})
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "punish_action_" + pendingID,
+					Placeholder: "选择处罚类型",
+					MinValues:   &[]int{1}[0],
+					MaxValues:   1,
+					Options:     options,
+				},
+			},
+		},
+	}
+}
+
 // buildPunishmentEmbedNew creates the rich embed message for the punishment announcement using new config.
-func buildPunishmentEmbedNew(i *discordgo.InteractionCreate, targetUser *discordgo.User, actionConfig *model.ActionConfig, reason string, allEvidence []Evidence, currentGuildHistory []model.PunishmentRecord, otherGuildsHistory map[string][]model.PunishmentRecord, timeoutApplied bool, timeoutDurationStr string, punishmentID int64, punishLevel *model.PunishLevel, isSelfPunish bool) *discordgo.MessageEmbed {
+func buildPunishmentEmbedNew(adminUsername string, targetUser *discordgo.User, actionConfig *model.ActionConfig, reason string, allEvidence []Evidence, currentGuildHistory []model.PunishmentRecord, otherGuildsHistory map[string][]model.PunishmentRecord, timeoutApplied bool, timeoutDurationStr string, punishmentID int64, punishLevel *model.PunishLevel, isSelfPunish bool) *discordgo.MessageEmbed {
 	// Get display name, fallback to type if actionConfig is nil
 	displayName := "未知处罚"
 	if actionConfig != nil {
@@ -42,11 +109,11 @@ func buildPunishmentEmbedNew(i *discordgo.InteractionCreate, targetUser *discord
 
 	if isSelfPunish {
 		embed.Footer = &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("自我处罚 by %s | 处罚ID: %d | ⚠️ 已修改逻辑：自我处罚现已入库", i.Member.User.Username, punishmentID),
+			Text: fmt.Sprintf("自我处罚 by %s | 处罚ID: %d | ⚠️ 已修改逻辑：自我处罚现已入库", adminUsername, punishmentID),
 		}
 	} else {
 		embed.Footer = &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("由 %s 操作 | 处罚ID: %d", i.Member.User.Username, punishmentID),
+			Text: fmt.Sprintf("由 %s 操作 | 处罚ID: %d", adminUsername, punishmentID),
 		}
 	}
 
@@ -3,10 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	grpcclient "newer_helper/grpc/client"
 	"newer_helper/utils"
 	"newer_helper/utils/database"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -82,6 +84,7 @@ func SystemInfoHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			{Name: "🌍 缓存服务器数", Value: fmt.Sprintf("%d", guilds), Inline: true},
 			{Name: "👥 缓存用户数", Value: fmt.Sprintf("%d", users), Inline: true},
 			{Name: "🗨️ 缓存子区数", Value: fmt.Sprintf("%d", threads), Inline: true},
+			{Name: "🔌 网关连接", Value: gatewayStatusText(), Inline: false},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
 			Text: fmt.Sprintf("系统监控・今天%s", time.Now().Format("15:04")),
@@ -95,3 +98,33 @@ func SystemInfoHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		},
 	})
 }
+
+// gatewayStatusText describes the gRPC gateway connection for the system info embed
+func gatewayStatusText() string {
+	status, ok := grpcclient.DefaultStatus()
+	if !ok {
+		return "未配置"
+	}
+
+	var lines []string
+	switch status.State {
+	case grpcclient.StateConnected:
+		lines = append(lines, fmt.Sprintf("✅ 已连接 `%s`", status.Address))
+		lines = append(lines, fmt.Sprintf("连接时长: %s", time.Since(status.ConnectedSince).Round(time.Second)))
+		if !status.LastHeartbeatAt.IsZero() {
+			lines = append(lines, fmt.Sprintf("心跳往返: %s", status.LastHeartbeatRTT.Round(time.Millisecond)))
+		}
+	case grpcclient.StateReconnecting:
+		lines = append(lines, fmt.Sprintf("🔄 重连中 `%s` (第 %d 次尝试)", status.Address, status.ReconnectAttempts))
+	case grpcclient.StateConnecting:
+		lines = append(lines, fmt.Sprintf("⏳ 连接中 `%s`", status.Address))
+	case grpcclient.StateClosed:
+		lines = append(lines, "⏹️ 已关闭")
+	default:
+		lines = append(lines, "❌ 未连接")
+	}
+	if status.LastError != "" {
+		lines = append(lines, fmt.Sprintf("最近错误 (%s): %s", status.LastErrorAt.Format("01-02 15:04:05"), utils.TruncateString(status.LastError, 300)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"newer_helper/grpc/events"
 	"newer_helper/model"
 	tasks_emoji "newer_helper/tasks/new_card_emoji"
 	"newer_helper/utils"
@@ -79,6 +80,7 @@ func HandleThreadCreate(s *discordgo.Session, t *discordgo.ThreadCreate, cfg *mo
 		utils.LogError(s, logChannelID, "NewPost", "SavePost", fmt.Sprintf("Error saving post: %v", err))
 	} else {
 		utils.LogInfo(s, logChannelID, "NewPost", "Save", fmt.Sprintf("Successfully saved new post <#%s> to channel <#%s>", post.ID, post.ChannelID))
+		events.Publish(events.PostCreated, post, map[string]string{"guild_id": guildID})
 		if rollCardGuildConfig, ok := cfg.RollCardConfigs[guildID]; ok {
 			go utils.PushNewCard(s, guildID, post, &rollCardGuildConfig)
 			// 为新帖子创建emoji发送计时器
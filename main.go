@@ -7,6 +7,9 @@ import (
 	"newer_helper/bot"
 	"newer_helper/config"
 	grpcclient "newer_helper/grpc/client"
+	"newer_helper/grpc/events"
+	postpb "newer_helper/grpc/proto/gen/post"
+	punishpb "newer_helper/grpc/proto/gen/punish"
 	grpcserver "newer_helper/grpc/server"
 	"newer_helper/handlers"
 	"newer_helper/utils"
@@ -78,22 +81,54 @@ func main() {
 	}
 
 	// Initialize gRPC punish server with punishment database
-	punishServer := grpcserver.NewPunishServer(punishDB)
+	punishServer := grpcserver.NewPunishServer(punishDB, b)
 	log.Println("Initialized gRPC Punish Server")
 
-	// Initialize and connect gRPC client with punish server
-	grpcClient, err := grpcclient.NewClient(punishServer)
+	// Initialize gRPC post server over the forum post databases
+	postServer := grpcserver.NewPostServer(b)
+	log.Println("Initialized gRPC Post Server")
+
+	// Start the standalone gRPC listener if configured; it runs alongside or instead of the gateway connection
+	var grpcListener *grpcserver.Listener
+	listenerConfig, err := grpcserver.LoadListenerConfig()
+	if err != nil {
+		log.Printf("Warning: Invalid gRPC listener config: %v", err)
+		log.Println("Continuing without gRPC listener...")
+	} else if listenerConfig != nil {
+		grpcListener, err = grpcserver.NewListener(listenerConfig)
+		if err != nil {
+			log.Printf("Warning: Failed to create gRPC listener: %v", err)
+		} else {
+			punishpb.RegisterPunishServerServer(grpcListener, punishServer)
+			postpb.RegisterPostServiceServer(grpcListener, postServer)
+			if err := grpcListener.Start(); err != nil {
+				log.Printf("Warning: Failed to start gRPC listener: %v", err)
+				grpcListener = nil
+			} else {
+				log.Printf("gRPC listener serving on %s (%s, %d API keys)", listenerConfig.Address, listenerConfig.TLSMode(), len(listenerConfig.APIKeys))
+			}
+		}
+	}
+
+	// Initialize gRPC client, register services and connect
+	grpcClient, err := grpcclient.NewClient()
 	if err != nil {
 		log.Printf("Warning: Failed to create gRPC client: %v", err)
 		log.Println("Continuing without gRPC connection...")
 	} else {
+		punishpb.RegisterPunishServerServer(grpcClient, punishServer)
+		postpb.RegisterPostServiceServer(grpcClient, postServer)
+
+		// The client keeps retrying in the background when the first attempt fails
 		if err := grpcClient.Connect(); err != nil {
 			log.Printf("Warning: Failed to connect to gRPC gateway: %v", err)
-			log.Println("Continuing without gRPC connection...")
+			log.Println("Continuing while the gRPC client retries in the background...")
 		} else {
-			defer grpcClient.Close()
-			log.Println("gRPC client connected and ready to handle punish service requests")
+			log.Println("gRPC client connected and ready to handle punish and post service requests")
 		}
+		defer grpcClient.Close()
+		events.SetBus(grpcClient)
+		grpcclient.SetDefault(grpcClient)
 	}
 
 	log.Println("Bot is now running. Press CTRL-C to exit.")
@@ -105,6 +140,9 @@ func main() {
 
 	// Gracefully shutdown
 	log.Println("Shutting down gracefully...")
+	if grpcListener != nil {
+		grpcListener.Stop()
+	}
 	b.Close()
 	log.Println("Bot has been shut down.")
 }
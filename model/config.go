@@ -65,6 +65,10 @@ type Config struct {
 		} `json:"data"`
 	}
 	EvidenceCleaner EvidenceCleanerConfig
+	// PunishDeleteRetentionDays is how long soft-deleted punishment records are kept before being purged.
+	PunishDeleteRetentionDays int
+	// PunishReconcileAutoFix makes the scheduled role reconciliation correct drifted roles instead of only reporting them.
+	PunishReconcileAutoFix bool
 }
 
 // ThreadConfig holds the configuration for thread database paths.
@@ -132,8 +136,17 @@ type PunishLevel struct {
 	AddRoleTimeoutTime string   `json:"add_role_timeout_time"`
 	SendPresetID       string   `json:"send_preset_id,omitempty"`
 	Description        string   `json:"description,omitempty"`
+	RequiresApproval   bool     `json:"requires_approval,omitempty"` // A second admin must confirm before this level is applied
+	RequiresPreview    bool     `json:"requires_preview,omitempty"`  // The admin must confirm a preview before this level is applied; always the case for bans and levels requiring approval
+	// Ban bans the user from the guild: "permanent", or a duration such as "30d" after which the user is unbanned.
+	Ban string `json:"ban,omitempty"`
+	// BanDeleteMessageDays is how many days of the user's messages Discord deletes when banning, 0-7.
+	BanDeleteMessageDays int `json:"ban_delete_message_days,omitempty"`
 }
 
+// BanPermanent is the value of PunishLevel.Ban for a ban without a scheduled unban.
+const BanPermanent = "permanent"
+
 // ActionConfig defines the configuration for a specific punishment action type.
 type ActionConfig struct {
 	Type            string                 `json:"tpye"` // Note: keeping the typo to match JSON
@@ -146,6 +159,41 @@ type ActionConfig struct {
 	RemoveRoleID    []string               `json:"remove_role_id"`
 	WhitelistRoleID []string               `json:"whitelist_role_id"`
 	Data            map[string]PunishLevel `json:"data"`
+	Escalation      *EscalationConfig      `json:"escalation,omitempty"`
+	ApprovalTimeout string                 `json:"approval_timeout,omitempty"` // How long a level with requires_approval waits for confirmation, e.g. "24h"
+	CountWarnings   bool                   `json:"count_warnings,omitempty"`   // Whether the user's formal warnings count towards the punish level
+	Order           int                    `json:"order,omitempty"`            // Position among the guild's actions, ascending; actions without an order come last, in the legacy re-answer, cheat, tag order
+	ButtonLabel     string                 `json:"button_label,omitempty"`     // Quick-punish button label, defaults to Name
+	ButtonEmoji     string                 `json:"button_emoji,omitempty"`     // Unicode emoji or custom emoji such as "<:name:id>"
+	ButtonStyle     string                 `json:"button_style,omitempty"`     // "primary" (default), "secondary", "success" or "danger"
+	Hidden          bool                   `json:"hidden,omitempty"`           // Whether the action is left out of the quick-punish preview
+	RequirePreview  bool                   `json:"require_preview,omitempty"`  // Whether admins must confirm a preview before any level of the action is applied
+}
+
+// Escalation modes for ActionConfig.Escalation.Mode.
+const (
+	EscalationModeCount  = "count"
+	EscalationModePoints = "points"
+)
+
+// EscalationConfig selects how the punish level is chosen for an action.
+// Without it, the level is picked by the user's punishment count (the "count" mode).
+type EscalationConfig struct {
+	Mode       string             `json:"mode"`       // "count" (default) or "points"
+	Points     float64            `json:"points"`     // Points each punishment of this action contributes, defaults to 1
+	HalfLife   string             `json:"half_life"`  // Time for points to halve, e.g. "30d"; empty means points never decay
+	Thresholds map[string]float64 `json:"thresholds"` // Level key in Data -> minimum score for that level
+}
+
+// PunishConfigVersion is one saved revision of a guild's punish actions.
+// The database table will be named 'punish_config_versions'.
+type PunishConfigVersion struct {
+	VersionID   int64  `db:"version_id"` // Primary Key, Auto-increment
+	GuildID     string `db:"guild_id"`
+	ActionsJSON string `db:"actions_json"` // JSON object of action key -> ActionConfig
+	ActorID     string `db:"actor_id"`
+	Comment     string `db:"comment"` // Short description of the change
+	CreatedAt   int64  `db:"created_at"`
 }
 
 // RevocationConfig defines the structure for revocation settings.
@@ -2,6 +2,37 @@ package model
 
 import "time"
 
+// Punishment status values stored in punishments.punishment_status.
+const (
+	PunishmentStatusActive         = "active"
+	PunishmentStatusCompleted      = "completed"
+	PunishmentStatusCancelled      = "cancelled"
+	PunishmentStatusAppealed       = "appealed"
+	PunishmentStatusAppealRejected = "appeal_rejected"
+	// Statuses of punishments that require a second admin's approval.
+	PunishmentStatusPendingApproval = "pending_approval"
+	PunishmentStatusRejected        = "rejected"
+	PunishmentStatusExpired         = "expired"
+)
+
+// Appeal status values stored in punishment_appeals.status.
+const (
+	AppealStatusPending  = "pending"
+	AppealStatusApproved = "approved"
+	AppealStatusRejected = "rejected"
+)
+
+// Approval status values stored in punishment_approvals.status.
+const (
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+	ApprovalStatusExpired  = "expired"
+)
+
+// BanUntilPermanent is the BanUntil of a punishment that banned the user without a scheduled unban.
+const BanUntilPermanent int64 = -1
+
 // TempRoleRemoval represents a temporary role that needs to be removed at a specific time
 type TempRoleRemoval struct {
 	RoleID   string    `json:"role_id"`
@@ -11,17 +42,124 @@ type TempRoleRemoval struct {
 // PunishmentRecord represents a single punishment record in the database.
 // The database table will be named 'punishments'.
 type PunishmentRecord struct {
-	PunishmentID      int64  `db:"punishment_id"`      // Primary Key, Auto-increment
-	MessageID         string `db:"message_id"`
-	AdminID           string `db:"admin_id"`
-	UserID            string `db:"user_id"`
-	UserUsername      string `db:"user_username"`
-	Reason            string `db:"reason"`
-	GuildID           string `db:"guild_id"`
-	Timestamp         int64  `db:"timestamp"`
-	Evidence          string `db:"evidence"`           // JSON string with message content and file paths
-	ActionType        string `db:"action_type"`        // Type of punishment action (e.g., "re-answer", "cheat", "tag")
-	TempRolesJSON     string `db:"temp_roles_json"`    // JSON array of temporary role IDs added by this punishment
-	RolesRemoveAt     string `db:"roles_remove_at"`    // JSON object mapping role IDs to their removal timestamps
-	PunishmentStatus  string `db:"punishment_status"`  // Status: active, completed, cancelled
+	PunishmentID     int64  `db:"punishment_id"` // Primary Key, Auto-increment
+	MessageID        string `db:"message_id"`
+	AdminID          string `db:"admin_id"`
+	UserID           string `db:"user_id"`
+	UserUsername     string `db:"user_username"`
+	Reason           string `db:"reason"`
+	GuildID          string `db:"guild_id"`
+	Timestamp        int64  `db:"timestamp"`
+	Evidence         string `db:"evidence"`          // JSON string with message content and file paths
+	ActionType       string `db:"action_type"`       // Type of punishment action (e.g., "re-answer", "cheat", "tag")
+	TempRolesJSON    string `db:"temp_roles_json"`   // JSON array of temporary role IDs added by this punishment
+	RolesRemoveAt    string `db:"roles_remove_at"`   // JSON object mapping role IDs to their removal timestamps
+	PunishmentStatus string `db:"punishment_status"` // Status: active, completed, cancelled, appealed, appeal_rejected, pending_approval, rejected, expired
+	TimeoutUntil     int64  `db:"timeout_until"`     // Unix timestamp the Discord timeout applied by this punishment ends, 0 if none
+	BanUntil         int64  `db:"ban_until"`         // Unix timestamp the user is unbanned, BanUntilPermanent for a permanent ban, 0 if none
+	LevelKey         string `db:"level_key"`         // Key of the applied level in the action's Data, empty for records created before it was stored
+	DeletedAt        int64  `db:"deleted_at"`        // Unix timestamp of the soft delete, 0 if the record is not deleted
+	DeletedBy        string `db:"deleted_by"`        // ID of the admin who deleted the record
+	DeleteReason     string `db:"delete_reason"`     // Reason given when deleting the record
+}
+
+// IsInEffect reports whether the punishment is still being enforced.
+// An appeal does not suspend a punishment until it has been approved.
+func (r PunishmentRecord) IsInEffect() bool {
+	switch r.PunishmentStatus {
+	case PunishmentStatusActive, PunishmentStatusAppealed, PunishmentStatusAppealRejected:
+		return true
+	}
+	return false
+}
+
+// CountsTowardEscalation reports whether the punishment was actually enforced and so counts towards the next punish level.
+func (r PunishmentRecord) CountsTowardEscalation() bool {
+	switch r.PunishmentStatus {
+	case PunishmentStatusCancelled, PunishmentStatusPendingApproval, PunishmentStatusRejected, PunishmentStatusExpired:
+		return false
+	}
+	return true
+}
+
+// PunishmentAppeal represents an appeal submitted by a punished user.
+// The database table will be named 'punishment_appeals'.
+type PunishmentAppeal struct {
+	AppealID       int64  `db:"appeal_id"` // Primary Key, Auto-increment
+	PunishmentID   int64  `db:"punishment_id"`
+	UserID         string `db:"user_id"`
+	GuildID        string `db:"guild_id"`
+	Reason         string `db:"reason"`
+	Status         string `db:"status"` // Status: pending, approved, rejected
+	ReviewerID     string `db:"reviewer_id"`
+	AdminChannelID string `db:"admin_channel_id"`
+	AdminMessageID string `db:"admin_message_id"`
+	CreatedAt      int64  `db:"created_at"`
+	ReviewedAt     int64  `db:"reviewed_at"`
+}
+
+// PunishmentApproval represents a severe punishment waiting for a second admin to confirm it.
+// The database table will be named 'punishment_approvals'.
+type PunishmentApproval struct {
+	PunishmentID   int64  `db:"punishment_id"` // Primary Key, the punishment record in pending_approval status
+	GuildID        string `db:"guild_id"`
+	RequesterID    string `db:"requester_id"`
+	ApproverID     string `db:"approver_id"`
+	PlanJSON       string `db:"plan_json"` // JSON of the punishment to enforce once approved
+	Status         string `db:"status"`    // Status: pending, approved, rejected, expired
+	AdminChannelID string `db:"admin_channel_id"`
+	AdminMessageID string `db:"admin_message_id"`
+	CreatedAt      int64  `db:"created_at"`
+	ExpiresAt      int64  `db:"expires_at"`
+	ResolvedAt     int64  `db:"resolved_at"`
+}
+
+// Audit actions stored in punishment_audit.action.
+const (
+	AuditActionCreate       = "create"
+	AuditActionRevoke       = "revoke"
+	AuditActionDelete       = "delete"
+	AuditActionStatusChange = "status_change"
+	AuditActionUpdate       = "update"
+	AuditActionRestore      = "restore"
+	AuditActionPurge        = "purge"
+)
+
+// PunishmentAuditEntry is one append-only entry in the moderation audit log.
+// The database table will be named 'punishment_audit'.
+type PunishmentAuditEntry struct {
+	AuditID      int64  `db:"audit_id"` // Primary Key, Auto-increment
+	PunishmentID int64  `db:"punishment_id"`
+	GuildID      string `db:"guild_id"`
+	Action       string `db:"action"`   // create, revoke, delete, status_change, update, restore, purge
+	ActorID      string `db:"actor_id"` // Discord user ID, or "system" for background jobs
+	Reason       string `db:"reason"`
+	BeforeJSON   string `db:"before_json"` // Snapshot of the record before the change, empty for create
+	AfterJSON    string `db:"after_json"`  // Snapshot of the record after the change, empty for purge
+	CreatedAt    int64  `db:"created_at"`
+	TraceID      string `db:"trace_id"` // Trace ID of the interaction or gateway request that made the change
+}
+
+// User note kinds stored in user_notes.kind.
+const (
+	UserNoteKindNote    = "note"
+	UserNoteKindWarning = "warning"
+)
+
+// UserNote is a moderator note or formal warning about a user. Unlike a punishment it has no effect on the
+// user, and only counts towards escalation for actions with count_warnings enabled.
+// The database table will be named 'user_notes'.
+type UserNote struct {
+	NoteID    int64  `db:"note_id"` // Primary Key, Auto-increment
+	GuildID   string `db:"guild_id"`
+	UserID    string `db:"user_id"`
+	AuthorID  string `db:"author_id"`
+	Kind      string `db:"kind"` // Kind: note, warning
+	Content   string `db:"content"`
+	CreatedAt int64  `db:"created_at"`
+}
+
+// IsWarning reports whether the note is a formal warning.
+func (n UserNote) IsWarning() bool {
+	return n.Kind == UserNoteKindWarning
 }
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// StartApprovalExpiryTimer starts a background goroutine that expires punishment approval requests
+// nobody confirmed in time.
+func StartApprovalExpiryTimer(s *discordgo.Session, logChannelID string, ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	go func() {
+		defer ticker.Stop()
+		processExpiredApprovals(s, logChannelID)
+		for {
+			select {
+			case <-ticker.C:
+				processExpiredApprovals(s, logChannelID)
+			case <-ctx.Done():
+				log.Println("Stopping punishment approval expiry timer.")
+				return
+			}
+		}
+	}()
+}
+
+// processExpiredApprovals marks overdue approval requests as expired, updates their admin messages and logs them.
+func processExpiredApprovals(s *discordgo.Session, logChannelID string) {
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config for approval expiry: %v", err)
+		return
+	}
+
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB for approval expiry: %v", err)
+		return
+	}
+	defer db.Close()
+
+	expired, err := punishments_db.ExpirePunishmentApprovals(db)
+	if err != nil {
+		log.Printf("Error expiring punishment approvals: %v", err)
+		return
+	}
+
+	for _, approval := range expired {
+		ctx := trace.New(context.Background())
+		trace.Logf(ctx, "Punishment approval for ID %d expired without confirmation", approval.PunishmentID)
+		if record, err := punishments_db.GetPunishmentRecordByID(db, approval.PunishmentID); err == nil {
+			before := *record
+			before.PunishmentStatus = model.PunishmentStatusPendingApproval
+			if err := punishments_db.RecordAudit(ctx, db, model.AuditActionStatusChange, punishments_db.AuditActorSystem, "审批超时", &before, record); err != nil {
+				trace.Logf(ctx, "Failed to write audit entry for punishment ID %d: %v", approval.PunishmentID, err)
+			}
+		}
+		markApprovalMessageExpired(s, approval)
+
+		if logChannelID != "" {
+			logInfo := fmt.Sprintf("处罚ID: `%d`\n发起人: <@%s>\n发起时间: <t:%d:f>\n该处罚在审批期限内未获确认，已自动失效，未对用户执行。",
+				approval.PunishmentID, approval.RequesterID, approval.CreatedAt)
+			if err := utils.LogWarnCtx(ctx, s, logChannelID, "处罚审批", "超时失效", logInfo); err != nil {
+				log.Printf("Failed to send approval expiry log: %v", err)
+			}
+		}
+	}
+}
+
+// markApprovalMessageExpired removes the buttons from an expired approval request and notes the outcome.
+func markApprovalMessageExpired(s *discordgo.Session, approval model.PunishmentApproval) {
+	if approval.AdminChannelID == "" || approval.AdminMessageID == "" {
+		return
+	}
+
+	message, err := s.ChannelMessage(approval.AdminChannelID, approval.AdminMessageID)
+	if err != nil {
+		log.Printf("Failed to fetch approval message for punishment %d: %v", approval.PunishmentID, err)
+		return
+	}
+
+	embeds := message.Embeds
+	if len(embeds) > 0 {
+		embeds[0].Color = 0x808080 // Grey
+		embeds[0].Fields = append(embeds[0].Fields, &discordgo.MessageEmbedField{
+			Name:  "审批结果",
+			Value: "⌛ 超时未确认，已自动失效，处罚未执行。",
+		})
+	}
+	emptyComponents := []discordgo.MessageComponent{}
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    approval.AdminChannelID,
+		ID:         approval.AdminMessageID,
+		Embeds:     &embeds,
+		Components: &emptyComponents,
+	})
+	if err != nil {
+		log.Printf("Failed to update expired approval message for punishment %d: %v", approval.PunishmentID, err)
+	}
+}
@@ -0,0 +1,36 @@
+package scanner
+
+import (
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CheckPunishConfig validates the punish configuration of every configured guild against the live guild
+// and posts a report to the log channel for each guild with problems.
+func CheckPunishConfig(s *discordgo.Session, cfg *model.Config) {
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config for validation: %v", err)
+		return
+	}
+
+	for guildID := range punishConfig.PunishConfig {
+		issues := utils.ValidatePunishConfig(s, cfg, punishConfig, guildID, utils.LoadRawPunishActions("config/config_file/punish_config.json", guildID))
+		if len(issues) == 0 {
+			continue
+		}
+
+		log.Printf("Punish config for guild %s has %d issues", guildID, len(issues))
+		for _, issue := range issues {
+			log.Printf("  [%s] %s/%s: %s", issue.Severity, issue.Action, issue.Level, issue.Message)
+		}
+		if cfg.LogChannelID != "" {
+			if _, err := s.ChannelMessageSendEmbed(cfg.LogChannelID, utils.BuildPunishConfigReportEmbed(guildID, issues)); err != nil {
+				log.Printf("Failed to send punish config report: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// PurgeDeletedPunishments permanently removes punishment records that have been soft-deleted
+// for longer than the configured retention period.
+func PurgeDeletedPunishments(s *discordgo.Session, cfg *model.Config) {
+	logChannelID := cfg.LogChannelID
+	retentionDays := cfg.PunishDeleteRetentionDays
+	ctx := trace.New(context.Background())
+
+	// A retention of 0 days would purge records right after deletion, leaving no time to restore them
+	if retentionDays < 1 {
+		log.Printf("Skipping purge of soft-deleted punishments: invalid retention period of %d days", retentionDays)
+		return
+	}
+
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config for deleted punishment purge: %v", err)
+		return
+	}
+
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB for deleted punishment purge: %v", err)
+		return
+	}
+	defer db.Close()
+
+	cutoffTime := time.Now().Add(-time.Duration(retentionDays) * 24 * time.Hour)
+	log.Printf("Starting purge of soft-deleted punishments (deleted more than %d days ago)...", retentionDays)
+
+	purged, err := punishments_db.PurgeDeletedPunishmentRecords(db, cutoffTime)
+	if err != nil {
+		utils.LogErrorCtx(ctx, s, logChannelID, "PurgeDeletedPunishments", "Purge", fmt.Sprintf("Failed to purge soft-deleted punishments: %v", err))
+		return
+	}
+
+	for idx := range purged {
+		reason := fmt.Sprintf("删除超过 %d 天，自动永久清除", retentionDays)
+		if err := punishments_db.RecordAudit(ctx, db, model.AuditActionPurge, punishments_db.AuditActorSystem, reason, &purged[idx], nil); err != nil {
+			log.Printf("Failed to write audit entry for purged punishment ID %d: %v", purged[idx].PunishmentID, err)
+		}
+	}
+
+	if len(purged) > 0 {
+		utils.LogInfoCtx(ctx, s, logChannelID, "PurgeDeletedPunishments", "Success", fmt.Sprintf("Permanently removed %d punishment records deleted more than %d days ago.", len(purged), retentionDays))
+	}
+
+	log.Println("Finished purge of soft-deleted punishments.")
+}
@@ -0,0 +1,285 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"newer_helper/utils"
+	punishments_db "newer_helper/utils/database/punishments"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jmoiron/sqlx"
+)
+
+// RoleDrift describes how a punished member's roles differ from what their punishments require.
+type RoleDrift struct {
+	GuildID       string
+	UserID        string
+	PunishmentIDs []int64
+	MissingRoles  []string // punishment roles that should still be present but are not
+	StaleRoles    []string // punishment roles that have expired but are still present
+	Fixed         bool     // whether the drift was corrected
+}
+
+// ReconcileReport is the result of one reconciliation run.
+type ReconcileReport struct {
+	GuildID     string // empty when all guilds were checked
+	Punishments int
+	Members     int
+	MembersGone int // punished members who are no longer in the guild
+	Drifts      []RoleDrift
+	Errors      []string
+	AutoFix     bool
+	StartedAt   time.Time
+}
+
+// StartPunishmentReconcileTimer periodically compares active punishments with the members' actual roles
+// and posts a digest of mismatches to the log channel.
+func StartPunishmentReconcileTimer(s *discordgo.Session, cfg *model.Config, ctx context.Context) {
+	ticker := time.NewTicker(6 * time.Hour)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				runScheduledReconcile(s, cfg)
+			case <-ctx.Done():
+				log.Println("Stopping punishment reconcile timer.")
+				return
+			}
+		}
+	}()
+}
+
+func runScheduledReconcile(s *discordgo.Session, cfg *model.Config) {
+	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
+	if err != nil {
+		log.Printf("Error loading punish config for reconciliation: %v", err)
+		return
+	}
+
+	db, err := punishments_db.Init(punishConfig.DatabasePath)
+	if err != nil {
+		log.Printf("Error connecting to punishment DB for reconciliation: %v", err)
+		return
+	}
+	defer db.Close()
+
+	report, err := ReconcilePunishmentRoles(s, db, "", cfg.PunishReconcileAutoFix)
+	if err != nil {
+		utils.LogError(s, cfg.LogChannelID, "ReconcilePunishmentRoles", "Query", fmt.Sprintf("Failed to reconcile punishment roles: %v", err))
+		return
+	}
+
+	log.Printf("Punishment role reconciliation checked %d punishments, found %d drifts", report.Punishments, len(report.Drifts))
+	if cfg.LogChannelID != "" && (len(report.Drifts) > 0 || len(report.Errors) > 0) {
+		if _, err := s.ChannelMessageSendEmbed(cfg.LogChannelID, BuildReconcileEmbed(report)); err != nil {
+			log.Printf("Failed to send reconciliation digest: %v", err)
+		}
+	}
+}
+
+// ReconcilePunishmentRoles compares the roles of every member with an in-effect punishment against
+// TempRolesJSON/RolesRemoveAt. guildID limits the check to one guild; empty checks all guilds.
+// With autoFix, missing roles are added back and stale roles removed.
+func ReconcilePunishmentRoles(s *discordgo.Session, db *sqlx.DB, guildID string, autoFix bool) (*ReconcileReport, error) {
+	report := &ReconcileReport{GuildID: guildID, AutoFix: autoFix, StartedAt: time.Now()}
+
+	punishments, err := punishments_db.GetActivePunishments(db)
+	if err != nil {
+		return nil, err
+	}
+
+	type memberKey struct{ guildID, userID string }
+	byMember := make(map[memberKey][]model.PunishmentRecord)
+	var order []memberKey
+	for _, punishment := range punishments {
+		if guildID != "" && punishment.GuildID != guildID {
+			continue
+		}
+		key := memberKey{punishment.GuildID, punishment.UserID}
+		if _, seen := byMember[key]; !seen {
+			order = append(order, key)
+		}
+		byMember[key] = append(byMember[key], punishment)
+		report.Punishments++
+	}
+	report.Members = len(order)
+
+	now := time.Now()
+	for _, key := range order {
+		member, err := s.GuildMember(key.guildID, key.userID)
+		if err != nil {
+			var restErr *discordgo.RESTError
+			if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMember {
+				report.MembersGone++
+				continue
+			}
+			report.Errors = append(report.Errors, fmt.Sprintf("<@%s>: %v", key.userID, err))
+			continue
+		}
+
+		drift := compareMemberRoles(member.Roles, byMember[key], now)
+		if len(drift.MissingRoles) == 0 && len(drift.StaleRoles) == 0 {
+			continue
+		}
+		drift.GuildID = key.guildID
+		drift.UserID = key.userID
+
+		if autoFix {
+			drift.Fixed = fixRoleDrift(s, &drift, report)
+		}
+		report.Drifts = append(report.Drifts, drift)
+	}
+
+	return report, nil
+}
+
+// compareMemberRoles works out which punishment roles a member should have and which have expired.
+// A role is only reported as stale if no other in-effect punishment still requires it.
+func compareMemberRoles(memberRoles []string, records []model.PunishmentRecord, now time.Time) RoleDrift {
+	var drift RoleDrift
+
+	has := make(map[string]bool, len(memberRoles))
+	for _, roleID := range memberRoles {
+		has[roleID] = true
+	}
+
+	expected := make(map[string]bool)
+	tracked := make(map[string]bool)
+	for _, record := range records {
+		drift.PunishmentIDs = append(drift.PunishmentIDs, record.PunishmentID)
+
+		var tempRoles []string
+		if err := json.Unmarshal([]byte(record.TempRolesJSON), &tempRoles); err != nil {
+			continue
+		}
+		rolesRemoveAt := make(map[string]time.Time)
+		if record.RolesRemoveAt != "" {
+			json.Unmarshal([]byte(record.RolesRemoveAt), &rolesRemoveAt)
+		}
+
+		for _, roleID := range tempRoles {
+			if roleID == "" || roleID == "0" {
+				continue
+			}
+			tracked[roleID] = true
+			// Without any removal times the roles are permanent; roles missing from the schedule have expired.
+			if len(rolesRemoveAt) == 0 {
+				expected[roleID] = true
+			} else if removeAt, ok := rolesRemoveAt[roleID]; ok && removeAt.After(now) {
+				expected[roleID] = true
+			}
+		}
+	}
+
+	for roleID := range tracked {
+		switch {
+		case expected[roleID] && !has[roleID]:
+			drift.MissingRoles = append(drift.MissingRoles, roleID)
+		case !expected[roleID] && has[roleID]:
+			drift.StaleRoles = append(drift.StaleRoles, roleID)
+		}
+	}
+	sort.Strings(drift.MissingRoles)
+	sort.Strings(drift.StaleRoles)
+
+	return drift
+}
+
+// fixRoleDrift adds missing roles and removes stale ones, recording failures in the report.
+func fixRoleDrift(s *discordgo.Session, drift *RoleDrift, report *ReconcileReport) bool {
+	fixed := true
+	for _, roleID := range drift.MissingRoles {
+		if err := s.GuildMemberRoleAdd(drift.GuildID, drift.UserID, roleID); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("<@%s> 添加 <@&%s> 失败: %v", drift.UserID, roleID, err))
+			fixed = false
+		}
+	}
+	for _, roleID := range drift.StaleRoles {
+		if err := s.GuildMemberRoleRemove(drift.GuildID, drift.UserID, roleID); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("<@%s> 移除 <@&%s> 失败: %v", drift.UserID, roleID, err))
+			fixed = false
+		}
+	}
+	return fixed
+}
+
+// BuildReconcileEmbed formats a reconciliation report as a digest embed.
+func BuildReconcileEmbed(report *ReconcileReport) *discordgo.MessageEmbed {
+	color := 0x00FF00 // Green
+	if len(report.Drifts) > 0 || len(report.Errors) > 0 {
+		color = 0xFFA500 // Orange
+	}
+
+	mode := "仅报告"
+	if report.AutoFix {
+		mode = "自动修复"
+	}
+
+	var lines []string
+	for _, drift := range report.Drifts {
+		ids := make([]string, len(drift.PunishmentIDs))
+		for i, id := range drift.PunishmentIDs {
+			ids[i] = fmt.Sprintf("%d", id)
+		}
+		line := fmt.Sprintf("<@%s> (处罚 %s)", drift.UserID, strings.Join(ids, ", "))
+		if len(drift.MissingRoles) > 0 {
+			line += " · 缺少: " + roleMentions(drift.MissingRoles)
+		}
+		if len(drift.StaleRoles) > 0 {
+			line += " · 未移除: " + roleMentions(drift.StaleRoles)
+		}
+		if report.AutoFix {
+			if drift.Fixed {
+				line += " ✅"
+			} else {
+				line += " ❌"
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	description := "未发现身份组与处罚记录不一致的情况。"
+	if len(lines) > 0 {
+		description = utils.TruncateString(strings.Join(lines, "\n"), 4000)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "处罚身份组核对报告",
+		Description: description,
+		Color:       color,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "模式", Value: mode, Inline: true},
+			{Name: "检查的处罚", Value: fmt.Sprintf("%d", report.Punishments), Inline: true},
+			{Name: "涉及成员", Value: fmt.Sprintf("%d", report.Members), Inline: true},
+			{Name: "不一致", Value: fmt.Sprintf("%d", len(report.Drifts)), Inline: true},
+			{Name: "已离开服务器", Value: fmt.Sprintf("%d", report.MembersGone), Inline: true},
+		},
+		Timestamp: report.StartedAt.Format(time.RFC3339),
+	}
+	if report.GuildID != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "服务器: " + report.GuildID}
+	}
+	if len(report.Errors) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "错误",
+			Value: utils.TruncateString(strings.Join(report.Errors, "\n"), 1000),
+		})
+	}
+	return embed
+}
+
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for i, roleID := range roleIDs {
+		mentions[i] = fmt.Sprintf("<@&%s>", roleID)
+	}
+	return strings.Join(mentions, " ")
+}
@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"container/heap"
+	"encoding/json"
+	"newer_helper/model"
+	"sync"
+	"time"
+)
+
+// unbanKey is the key of a punishment's scheduled unban among its entries. Role IDs are numeric, so it cannot collide.
+const unbanKey = "unban"
+
+// roleExpiry is a temporary punishment role waiting to be removed from a user,
+// or, if unban is set, a temporary ban waiting to be lifted.
+type roleExpiry struct {
+	punishmentID int64
+	roleID       string // empty for unbans
+	unban        bool
+	removeAt     time.Time
+	attempts     int // failed removal attempts so far
+	index        int // position in the heap, maintained by roleExpiryHeap
+}
+
+// key identifies the entry among the entries of its punishment.
+func (e *roleExpiry) key() string {
+	if e.unban {
+		return unbanKey
+	}
+	return e.roleID
+}
+
+// roleExpiryHeap is a min-heap of role expiries ordered by removal time.
+type roleExpiryHeap []*roleExpiry
+
+func (h roleExpiryHeap) Len() int           { return len(h) }
+func (h roleExpiryHeap) Less(i, j int) bool { return h[i].removeAt.Before(h[j].removeAt) }
+
+func (h roleExpiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *roleExpiryHeap) Push(x any) {
+	entry := x.(*roleExpiry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *roleExpiryHeap) Pop() any {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*h = old[:n-1]
+	return entry
+}
+
+// punishmentRoleScheduler keeps every pending temporary role removal and unban in memory
+// so the timer can sleep until exactly the next one is due.
+type punishmentRoleScheduler struct {
+	mu      sync.Mutex
+	queue   roleExpiryHeap
+	entries map[int64]map[string]*roleExpiry
+	wake    chan struct{}
+}
+
+var roleScheduler = &punishmentRoleScheduler{
+	entries: make(map[int64]map[string]*roleExpiry),
+	wake:    make(chan struct{}, 1),
+}
+
+// SchedulePunishmentRoles schedules the removal of a punishment's temporary roles and the end of its temporary ban,
+// replacing anything previously scheduled for the same punishment. banUntil is stored as model.PunishmentRecord.BanUntil.
+func SchedulePunishmentRoles(punishmentID int64, rolesRemoveAt map[string]time.Time, banUntil int64) {
+	roleScheduler.mu.Lock()
+	roleScheduler.removeLocked(punishmentID)
+	for _, entry := range punishmentExpiries(punishmentID, rolesRemoveAt, banUntilTime(banUntil)) {
+		roleScheduler.pushLocked(entry)
+	}
+	roleScheduler.mu.Unlock()
+	roleScheduler.notify()
+}
+
+// SchedulePunishmentRecord schedules the temporary roles and ban stored on a punishment record.
+// Records that are no longer in effect are removed from the schedule instead.
+func SchedulePunishmentRecord(record model.PunishmentRecord) {
+	if !record.IsInEffect() {
+		CancelPunishmentRoles(record.PunishmentID)
+		return
+	}
+
+	var rolesRemoveAt map[string]time.Time
+	if record.RolesRemoveAt != "" {
+		if err := json.Unmarshal([]byte(record.RolesRemoveAt), &rolesRemoveAt); err != nil {
+			return
+		}
+	}
+	SchedulePunishmentRoles(record.PunishmentID, rolesRemoveAt, record.BanUntil)
+}
+
+// CancelPunishmentRoles drops all scheduled role removals and the unban of a punishment, e.g. after it was revoked.
+func CancelPunishmentRoles(punishmentID int64) {
+	roleScheduler.mu.Lock()
+	roleScheduler.removeLocked(punishmentID)
+	roleScheduler.mu.Unlock()
+	roleScheduler.notify()
+}
+
+// punishmentExpiries builds the schedule entries of a punishment.
+func punishmentExpiries(punishmentID int64, rolesRemoveAt map[string]time.Time, banUntil time.Time) []*roleExpiry {
+	entries := make([]*roleExpiry, 0, len(rolesRemoveAt)+1)
+	for roleID, removeAt := range rolesRemoveAt {
+		entries = append(entries, &roleExpiry{punishmentID: punishmentID, roleID: roleID, removeAt: removeAt})
+	}
+	if !banUntil.IsZero() {
+		entries = append(entries, &roleExpiry{punishmentID: punishmentID, unban: true, removeAt: banUntil})
+	}
+	return entries
+}
+
+// banUntilTime converts a stored ban_until to the time of the scheduled unban, zero for none or a permanent ban.
+func banUntilTime(banUntil int64) time.Time {
+	if banUntil <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(banUntil, 0)
+}
+
+// notify wakes the timer loop so it can recompute how long to sleep.
+func (rs *punishmentRoleScheduler) notify() {
+	select {
+	case rs.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (rs *punishmentRoleScheduler) pushLocked(entry *roleExpiry) {
+	heap.Push(&rs.queue, entry)
+	if rs.entries[entry.punishmentID] == nil {
+		rs.entries[entry.punishmentID] = make(map[string]*roleExpiry)
+	}
+	rs.entries[entry.punishmentID][entry.key()] = entry
+}
+
+func (rs *punishmentRoleScheduler) removeLocked(punishmentID int64) {
+	for _, entry := range rs.entries[punishmentID] {
+		heap.Remove(&rs.queue, entry.index)
+	}
+	delete(rs.entries, punishmentID)
+}
+
+// reset replaces the whole schedule, used when rebuilding it from the database.
+func (rs *punishmentRoleScheduler) reset(schedule []*roleExpiry) {
+	rs.mu.Lock()
+	rs.queue = nil
+	rs.entries = make(map[int64]map[string]*roleExpiry)
+	for _, entry := range schedule {
+		rs.pushLocked(entry)
+	}
+	rs.mu.Unlock()
+	rs.notify()
+}
+
+// nextDue returns the time of the earliest scheduled removal, or false if nothing is scheduled.
+func (rs *punishmentRoleScheduler) nextDue() (time.Time, bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(rs.queue) == 0 {
+		return time.Time{}, false
+	}
+	return rs.queue[0].removeAt, true
+}
+
+// popDue removes and returns every entry due at or before now.
+func (rs *punishmentRoleScheduler) popDue(now time.Time) []*roleExpiry {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	var due []*roleExpiry
+	for len(rs.queue) > 0 && !rs.queue[0].removeAt.After(now) {
+		entry := heap.Pop(&rs.queue).(*roleExpiry)
+		if roles := rs.entries[entry.punishmentID]; roles != nil {
+			delete(roles, entry.key())
+			if len(roles) == 0 {
+				delete(rs.entries, entry.punishmentID)
+			}
+		}
+		due = append(due, entry)
+	}
+	return due
+}
+
+// retry puts a failed entry back on the schedule unless the punishment was rescheduled in the meantime.
+func (rs *punishmentRoleScheduler) retry(entry *roleExpiry, at time.Time) {
+	rs.mu.Lock()
+	if _, exists := rs.entries[entry.punishmentID][entry.key()]; !exists {
+		entry.removeAt = at
+		rs.pushLocked(entry)
+	}
+	rs.mu.Unlock()
+	rs.notify()
+}
@@ -1,161 +1,292 @@
 package scanner
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"newer_helper/model"
 	"newer_helper/utils"
 	punishments_db "newer_helper/utils/database/punishments"
+	"newer_helper/utils/trace"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/jmoiron/sqlx"
 )
 
-// StartPunishmentTimer starts a background goroutine to check for and remove expired punishment roles.
-func StartPunishmentTimer(s *discordgo.Session) {
-	ticker := time.NewTicker(5 * time.Minute) // Check every 5 minutes for better responsiveness
-	go func() {
-		// Run once right away so we don't wait for the first ticker tick after restart
-		processPunishmentTimers(s)
-		for range ticker.C {
-			processPunishmentTimers(s)
+const (
+	// roleRemovalRetryBase is the delay before the first retry of a failed role removal; it doubles on each failure.
+	roleRemovalRetryBase = 30 * time.Second
+	// roleRemovalRetryMax caps the delay between retries.
+	roleRemovalRetryMax = 30 * time.Minute
+)
+
+// StartPunishmentTimer removes temporary punishment roles and lifts temporary bans when they expire. It rebuilds the schedule
+// from the database, then sleeps until the next removal is due or the schedule changes.
+// It blocks until ctx is cancelled.
+func StartPunishmentTimer(s *discordgo.Session, ctx context.Context) {
+	var db *sqlx.DB
+	for {
+		var err error
+		db, err = loadPunishmentSchedule()
+		if err == nil {
+			break
+		}
+		log.Printf("Error loading punishment role schedule, retrying in 1 minute: %v", err)
+		select {
+		case <-time.After(time.Minute):
+		case <-ctx.Done():
+			return
+		}
+	}
+	defer db.Close()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
 		}
-	}()
+		if next, ok := roleScheduler.nextDue(); ok {
+			timer.Reset(time.Until(next))
+		}
+
+		select {
+		case <-timer.C:
+			processDueRoleRemovals(s, db, time.Now())
+		case <-roleScheduler.wake:
+		case <-ctx.Done():
+			log.Println("Stopping punishment role timer.")
+			return
+		}
+	}
 }
 
-// processPunishmentTimers processes all active punishment records with temporary roles
-func processPunishmentTimers(s *discordgo.Session) {
-	// Load punishment config to get database path
+// loadPunishmentSchedule opens the punishment database and fills the schedule with the
+// temporary roles and bans of every active punishment. The returned database is used by the timer for its lifetime.
+func loadPunishmentSchedule() (*sqlx.DB, error) {
 	punishConfig, err := utils.LoadPunishConfig("config/config_file/punish_config.json")
 	if err != nil {
-		log.Printf("Error loading punish config for timer: %v", err)
-		return
+		return nil, fmt.Errorf("failed to load punish config: %w", err)
 	}
 
-	// Connect to database
 	db, err := punishments_db.Init(punishConfig.DatabasePath)
 	if err != nil {
-		log.Printf("Error connecting to punishment DB for timer: %v", err)
-		return
+		return nil, fmt.Errorf("failed to connect to punishment DB: %w", err)
 	}
-	defer db.Close()
 
-	// Get all active punishments
 	punishments, err := punishments_db.GetActivePunishments(db)
 	if err != nil {
-		log.Printf("Error getting active punishments: %v", err)
-		return
+		db.Close()
+		return nil, fmt.Errorf("failed to get active punishments: %w", err)
 	}
 
-	currentTime := time.Now()
+	bans, err := punishments_db.GetScheduledUnbans(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to get scheduled unbans: %w", err)
+	}
 
+	var schedule []*roleExpiry
+	roleCount := 0
 	for _, punishment := range punishments {
-		processedRoles, err := processPunishmentRecord(s, db, punishment, currentTime, punishConfig.PunishConfig)
+		rolesRemoveAt, err := loadRolesRemoveAt(db, punishment, punishConfig.PunishConfig)
 		if err != nil {
-			log.Printf("Failed to process punishment ID %d: %v", punishment.PunishmentID, err)
+			log.Printf("Failed to load role schedule for punishment ID %d: %v", punishment.PunishmentID, err)
 			continue
 		}
-
-		// If all roles have been processed, mark punishment as completed
-		if processedRoles != nil && len(processedRoles) == 0 && punishment.RolesRemoveAt != "{}" && punishment.RolesRemoveAt != "" {
-			err := punishments_db.UpdatePunishmentStatus(db, punishment.PunishmentID, "completed")
-			if err != nil {
-				log.Printf("Failed to update punishment status for ID %d: %v", punishment.PunishmentID, err)
-			}
+		if len(rolesRemoveAt) > 0 {
+			schedule = append(schedule, punishmentExpiries(punishment.PunishmentID, rolesRemoveAt, time.Time{})...)
+			roleCount++
 		}
 	}
+	for _, punishment := range bans {
+		schedule = append(schedule, punishmentExpiries(punishment.PunishmentID, nil, banUntilTime(punishment.BanUntil))...)
+	}
+
+	roleScheduler.reset(schedule)
+	log.Printf("Punishment role timer scheduled %d punishments with temporary roles and %d temporary bans", roleCount, len(bans))
+	return db, nil
 }
 
-// processPunishmentRecord processes a single punishment record and removes expired roles.
-// Returns the remaining roles that haven't expired yet.
-func processPunishmentRecord(
-	s *discordgo.Session,
-	db *sqlx.DB,
-	punishment model.PunishmentRecord,
-	currentTime time.Time,
-	config map[string]map[string]model.ActionConfig,
-) (map[string]time.Time, error) {
+// loadRolesRemoveAt parses the role removal times of a punishment, reconstructing them from the config
+// when they are missing or corrupt.
+func loadRolesRemoveAt(db *sqlx.DB, punishment model.PunishmentRecord, config map[string]map[string]model.ActionConfig) (map[string]time.Time, error) {
 	var rolesRemoveAt map[string]time.Time
-	var err error
 
-	if punishment.RolesRemoveAt == "{}" || punishment.RolesRemoveAt == "" {
-		rolesRemoveAt, err = rebuildRolesRemoveAt(punishment, config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to rebuild missing roles_remove_at: %w", err)
+	if punishment.RolesRemoveAt != "{}" && punishment.RolesRemoveAt != "" {
+		err := json.Unmarshal([]byte(punishment.RolesRemoveAt), &rolesRemoveAt)
+		if err == nil {
+			return rolesRemoveAt, nil
 		}
+		log.Printf("Failed to parse roles_remove_at for punishment ID %d: %v", punishment.PunishmentID, err)
+	}
 
-		if len(rolesRemoveAt) == 0 {
-			return nil, nil
-		}
+	rolesRemoveAt, err := rebuildRolesRemoveAt(punishment, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild roles_remove_at: %w", err)
+	}
 
-		if err := persistRolesRemoveAt(db, punishment.PunishmentID, rolesRemoveAt); err != nil {
-			return nil, fmt.Errorf("failed to persist rebuilt roles_remove_at: %w", err)
-		}
+	if len(rolesRemoveAt) == 0 {
+		return nil, nil
+	}
 
-		log.Printf("Reconstructed roles_remove_at for punishment ID %d", punishment.PunishmentID)
-	} else {
-		err = json.Unmarshal([]byte(punishment.RolesRemoveAt), &rolesRemoveAt)
-		if err != nil {
-			log.Printf("Failed to parse roles_remove_at for punishment ID %d: %v", punishment.PunishmentID, err)
+	if err := persistRolesRemoveAt(db, punishment.PunishmentID, rolesRemoveAt); err != nil {
+		return nil, fmt.Errorf("failed to persist rebuilt roles_remove_at: %w", err)
+	}
 
-			rolesRemoveAt, rebuildErr := rebuildRolesRemoveAt(punishment, config)
-			if rebuildErr != nil {
-				return nil, fmt.Errorf("failed to rebuild invalid roles_remove_at: %w", rebuildErr)
-			}
+	log.Printf("Reconstructed roles_remove_at for punishment ID %d", punishment.PunishmentID)
+	return rolesRemoveAt, nil
+}
 
-			if len(rolesRemoveAt) == 0 {
-				return nil, nil
-			}
+// processDueRoleRemovals removes every role and lifts every ban that is due, and records the result on the punishment.
+// Failed removals are retried with exponential backoff.
+func processDueRoleRemovals(s *discordgo.Session, db *sqlx.DB, now time.Time) {
+	byPunishment := make(map[int64][]*roleExpiry)
+	for _, entry := range roleScheduler.popDue(now) {
+		byPunishment[entry.punishmentID] = append(byPunishment[entry.punishmentID], entry)
+	}
 
-			if err := persistRolesRemoveAt(db, punishment.PunishmentID, rolesRemoveAt); err != nil {
-				return nil, fmt.Errorf("failed to persist rebuilt roles_remove_at: %w", err)
+	for punishmentID, entries := range byPunishment {
+		punishment, err := punishments_db.GetPunishmentRecordByID(db, punishmentID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// The record was deleted, nothing left to do
+				continue
 			}
-
-			log.Printf("Reconstructed corrupt roles_remove_at for punishment ID %d", punishment.PunishmentID)
+			log.Printf("Failed to load punishment ID %d for role removal: %v", punishmentID, err)
+			for _, entry := range entries {
+				retryRoleRemoval(entry, now)
+			}
+			continue
+		}
+		if !punishment.IsInEffect() {
+			continue
 		}
-	}
 
-	remainingRoles := make(map[string]time.Time)
-	rolesRemoved := false
+		var removed []string
+		unbanned := false
+		for _, entry := range entries {
+			if entry.unban {
+				err := s.GuildBanDelete(punishment.GuildID, punishment.UserID)
+				if err != nil && !isBanGone(err) {
+					log.Printf("Failed to unban user %s (attempt %d): %v", punishment.UserID, entry.attempts+1, err)
+					retryRoleRemoval(entry, now)
+					continue
+				}
+				if err != nil {
+					log.Printf("User %s is no longer banned, treating ban as lifted (punishment ID: %d)", punishment.UserID, punishment.PunishmentID)
+				} else {
+					log.Printf("Successfully lifted expired ban of user %s (punishment ID: %d)", punishment.UserID, punishment.PunishmentID)
+				}
+				unbanned = true
+				continue
+			}
 
-	for roleID, removeTime := range rolesRemoveAt {
-		if currentTime.After(removeTime) {
-			// Role has expired, remove it
-			err := s.GuildMemberRoleRemove(punishment.GuildID, punishment.UserID, roleID)
+			err := s.GuildMemberRoleRemove(punishment.GuildID, punishment.UserID, entry.roleID)
+			if err != nil && !isMemberOrRoleGone(err) {
+				log.Printf("Failed to remove role %s from user %s (attempt %d): %v", entry.roleID, punishment.UserID, entry.attempts+1, err)
+				retryRoleRemoval(entry, now)
+				continue
+			}
 			if err != nil {
-				log.Printf("Failed to remove role %s from user %s: %v", roleID, punishment.UserID, err)
-				// Keep the role in the list to retry later
-				remainingRoles[roleID] = removeTime
+				log.Printf("User %s or role %s no longer exists, treating role as removed (punishment ID: %d)",
+					punishment.UserID, entry.roleID, punishment.PunishmentID)
 			} else {
 				log.Printf("Successfully removed expired role %s from user %s (punishment ID: %d)",
-					roleID, punishment.UserID, punishment.PunishmentID)
-				rolesRemoved = true
+					entry.roleID, punishment.UserID, punishment.PunishmentID)
+			}
+			removed = append(removed, entry.roleID)
+		}
+
+		if len(removed) > 0 || unbanned {
+			ctx := trace.New(context.Background())
+			if err := recordRoleRemoval(ctx, db, *punishment, removed, unbanned); err != nil {
+				trace.Logf(ctx, "Failed to update punishment ID %d after role removal: %v", punishment.PunishmentID, err)
 			}
-		} else {
-			// Role hasn't expired yet, keep it
-			remainingRoles[roleID] = removeTime
 		}
 	}
+}
 
-	// Update the database if any roles were removed
-	if rolesRemoved {
-		remainingRolesJSON, err := json.Marshal(remainingRoles)
-		if err != nil {
-			log.Printf("Failed to serialize remaining roles for punishment ID %d: %v", punishment.PunishmentID, err)
-			return remainingRoles, err
+// retryRoleRemoval reschedules a failed removal after an exponentially growing delay.
+func retryRoleRemoval(entry *roleExpiry, now time.Time) {
+	delay := roleRemovalRetryBase << entry.attempts
+	if delay <= 0 || delay > roleRemovalRetryMax {
+		delay = roleRemovalRetryMax
+	}
+	entry.attempts++
+	roleScheduler.retry(entry, now.Add(delay))
+}
+
+// isMemberOrRoleGone reports whether Discord rejected a role removal because the member left or the role was deleted,
+// in which case retrying is pointless.
+func isMemberOrRoleGone(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return false
+	}
+	return restErr.Message.Code == discordgo.ErrCodeUnknownMember || restErr.Message.Code == discordgo.ErrCodeUnknownRole
+}
+
+// isBanGone reports whether Discord rejected an unban because the user is not banned anymore.
+func isBanGone(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownBan
+}
+
+// recordRoleRemoval drops the removed roles from roles_remove_at, clears ban_until if the ban was lifted,
+// and completes the punishment once no roles or ban are left.
+func recordRoleRemoval(ctx context.Context, db *sqlx.DB, punishment model.PunishmentRecord, removedRoles []string, unbanned bool) error {
+	remainingRoles := make(map[string]time.Time)
+	if punishment.RolesRemoveAt != "" {
+		if err := json.Unmarshal([]byte(punishment.RolesRemoveAt), &remainingRoles); err != nil {
+			log.Printf("Failed to parse roles_remove_at for punishment ID %d: %v", punishment.PunishmentID, err)
 		}
+	}
+	for _, roleID := range removedRoles {
+		delete(remainingRoles, roleID)
+	}
 
-		err = punishments_db.RemoveExpiredRoleFromPunishment(db, punishment.PunishmentID, "", string(remainingRolesJSON))
-		if err != nil {
-			log.Printf("Failed to update roles_remove_at for punishment ID %d: %v", punishment.PunishmentID, err)
-			return remainingRoles, err
+	remainingRolesJSON, err := json.Marshal(remainingRoles)
+	if err != nil {
+		return fmt.Errorf("failed to serialize remaining roles: %w", err)
+	}
+
+	updated := punishment
+	var reasons []string
+	if len(removedRoles) > 0 {
+		if err := punishments_db.RemoveExpiredRoleFromPunishment(db, punishment.PunishmentID, "", string(remainingRolesJSON)); err != nil {
+			return err
+		}
+		updated.RolesRemoveAt = string(remainingRolesJSON)
+		reasons = append(reasons, "临时身份组到期移除")
+	}
+	if unbanned {
+		if err := punishments_db.ClearPunishmentBanUntil(db, punishment.PunishmentID); err != nil {
+			return err
 		}
+		updated.BanUntil = 0
+		reasons = append(reasons, "临时封禁到期解封")
 	}
 
-	return remainingRoles, nil
+	if err := punishments_db.RecordAudit(ctx, db, model.AuditActionUpdate, punishments_db.AuditActorSystem, strings.Join(reasons, "，"), &punishment, &updated); err != nil {
+		trace.Logf(ctx, "Failed to write audit entry for punishment ID %d: %v", punishment.PunishmentID, err)
+	}
+
+	// If all roles and the ban have been processed, mark punishment as completed.
+	// This includes appealed punishments; the appeal outcome is kept in punishment_appeals.
+	if len(remainingRoles) == 0 && updated.BanUntil == 0 && punishment.IsInEffect() {
+		return punishments_db.ChangePunishmentStatus(ctx, db, punishment.PunishmentID, model.PunishmentStatusCompleted, punishments_db.AuditActorSystem, "所有临时处罚已到期")
+	}
+	return nil
 }
 
 // rebuildRolesRemoveAt attempts to reconstruct missing role removal times based on the punishment config.
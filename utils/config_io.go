@@ -3,9 +3,12 @@ package utils
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"newer_helper/model"
+	punishments_db "newer_helper/utils/database/punishments"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
 const newCardPushConfigDir = "data/new_card_push_config"
@@ -73,6 +76,8 @@ func LoadTaskConfig(path string) (model.TaskConfig, error) {
 }
 
 // LoadPunishConfig loads the punishment configuration from the specified path.
+// Guilds whose punish actions are managed through /punish_config use the newest version stored in the
+// punishment database instead of the file.
 func LoadPunishConfig(path string) (*model.PunishConfig, error) {
 	fileData, err := os.ReadFile(path)
 	if err != nil {
@@ -84,5 +89,73 @@ func LoadPunishConfig(path string) (*model.PunishConfig, error) {
 		return nil, fmt.Errorf("error unmarshalling punish config from %s: %w", path, err)
 	}
 
+	if config.DatabasePath == "" {
+		return &config, nil
+	}
+
+	overlay, err := loadPunishConfigOverlay(config.DatabasePath)
+	if err != nil {
+		log.Printf("Failed to load punish config from database, using %s only: %v", path, err)
+		return &config, nil
+	}
+	for guildID, actionsJSON := range overlay {
+		var actions map[string]model.ActionConfig
+		if err := json.Unmarshal([]byte(actionsJSON), &actions); err != nil {
+			log.Printf("Failed to parse stored punish config for guild %s: %v", guildID, err)
+			continue
+		}
+		if config.PunishConfig == nil {
+			config.PunishConfig = make(map[string]map[string]model.ActionConfig)
+		}
+		config.PunishConfig[guildID] = actions
+	}
+
 	return &config, nil
 }
+
+// punishConfigOverlay caches the newest stored punish actions per guild so LoadPunishConfig
+// does not query the database on every call.
+var punishConfigOverlay struct {
+	sync.Mutex
+	dbPath  string
+	loaded  bool
+	actions map[string]string // guild ID -> actions JSON
+}
+
+func loadPunishConfigOverlay(dbPath string) (map[string]string, error) {
+	punishConfigOverlay.Lock()
+	defer punishConfigOverlay.Unlock()
+
+	if punishConfigOverlay.loaded && punishConfigOverlay.dbPath == dbPath {
+		return punishConfigOverlay.actions, nil
+	}
+
+	db, err := punishments_db.Init(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	versions, err := punishments_db.GetLatestPunishConfigVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	actions := make(map[string]string, len(versions))
+	for _, version := range versions {
+		actions[version.GuildID] = version.ActionsJSON
+	}
+
+	punishConfigOverlay.dbPath = dbPath
+	punishConfigOverlay.actions = actions
+	punishConfigOverlay.loaded = true
+	return actions, nil
+}
+
+// InvalidatePunishConfigCache makes the next LoadPunishConfig re-read the stored punish actions.
+// Call it after saving a new config version.
+func InvalidatePunishConfigCache() {
+	punishConfigOverlay.Lock()
+	punishConfigOverlay.loaded = false
+	punishConfigOverlay.Unlock()
+}
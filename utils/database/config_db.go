@@ -83,6 +83,10 @@ func CreateGuildTables(db *sql.DB) error {
 	if err != nil {
 		return err
 	}
+
+	if err := CreatePendingActionsTable(db); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrPendingActionNotFound is returned when no pending action exists for the given kind and ID.
+	ErrPendingActionNotFound = errors.New("pending action not found")
+	// ErrPendingActionExpired is returned when the pending action exists but its TTL has passed.
+	ErrPendingActionExpired = errors.New("pending action expired")
+)
+
+// CreatePendingActionsTable creates the table backing interactions that wait for a button click,
+// so that they survive a restart of the bot.
+func CreatePendingActionsTable(db *sql.DB) error {
+	createPendingActionsTableSQL := `CREATE TABLE IF NOT EXISTS pending_actions (
+		"kind" TEXT NOT NULL,
+		"id" TEXT NOT NULL,
+		"payload" TEXT NOT NULL,
+		"created_at" INTEGER NOT NULL,
+		"expires_at" INTEGER NOT NULL,
+		PRIMARY KEY (kind, id)
+	);`
+	_, err := db.Exec(createPendingActionsTableSQL)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_pending_actions_expires_at ON pending_actions (kind, expires_at)`)
+	return err
+}
+
+// SavePendingAction stores a serialized pending action that expires after ttl.
+// An existing entry with the same kind and ID is replaced.
+func SavePendingAction(db *sql.DB, kind, id string, payload []byte, ttl time.Duration) error {
+	now := time.Now()
+	_, err := db.Exec(`INSERT OR REPLACE INTO pending_actions (kind, id, payload, created_at, expires_at) VALUES (?, ?, ?, ?, ?)`,
+		kind, id, string(payload), now.Unix(), now.Add(ttl).Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save pending %s %s: %w", kind, id, err)
+	}
+	return nil
+}
+
+// GetPendingAction returns the payload of a pending action without removing it.
+func GetPendingAction(db *sql.DB, kind, id string) ([]byte, error) {
+	var payload string
+	var expiresAt int64
+	err := db.QueryRow(`SELECT payload, expires_at FROM pending_actions WHERE kind = ? AND id = ?`, kind, id).Scan(&payload, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPendingActionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending %s %s: %w", kind, id, err)
+	}
+	if expiresAt <= time.Now().Unix() {
+		return nil, ErrPendingActionExpired
+	}
+	return []byte(payload), nil
+}
+
+// TakePendingAction atomically removes a pending action and returns its payload.
+// Only one caller can take a given entry, which prevents a double click from executing it twice.
+func TakePendingAction(db *sql.DB, kind, id string) ([]byte, error) {
+	var payload string
+	var expiresAt int64
+	err := db.QueryRow(`DELETE FROM pending_actions WHERE kind = ? AND id = ? RETURNING payload, expires_at`, kind, id).Scan(&payload, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrPendingActionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take pending %s %s: %w", kind, id, err)
+	}
+	if expiresAt <= time.Now().Unix() {
+		return nil, ErrPendingActionExpired
+	}
+	return []byte(payload), nil
+}
+
+// DeletePendingAction removes a pending action regardless of its expiry.
+func DeletePendingAction(db *sql.DB, kind, id string) error {
+	_, err := db.Exec(`DELETE FROM pending_actions WHERE kind = ? AND id = ?`, kind, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending %s %s: %w", kind, id, err)
+	}
+	return nil
+}
+
+// DeleteExpiredPendingActions removes all expired entries of the given kind and returns how many were removed.
+func DeleteExpiredPendingActions(db *sql.DB, kind string) (int64, error) {
+	result, err := db.Exec(`DELETE FROM pending_actions WHERE kind = ? AND expires_at <= ?`, kind, time.Now().Unix())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired pending %s entries: %w", kind, err)
+	}
+	return result.RowsAffected()
+}
+
+// CountPendingActions returns the number of unexpired entries of the given kind.
+func CountPendingActions(db *sql.DB, kind string) (int, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pending_actions WHERE kind = ? AND expires_at > ?`, kind, time.Now().Unix()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count pending %s entries: %w", kind, err)
+	}
+	return count, nil
+}
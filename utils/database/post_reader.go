@@ -487,3 +487,74 @@ func GetTopPostsByAuthor(db *sql.DB, tableName, authorID string, limit int) ([]m
 	}
 	return posts, nil
 }
+
+// GetLatestPostsPage retrieves a page of posts from the given tables ordered by newest first,
+// along with the total number of posts. Each post's TableName is set to the table it came from.
+func GetLatestPostsPage(db *sql.DB, tableNames []string, limit, offset int) ([]model.Post, int, error) {
+	return getPostsPage(db, tableNames, "", nil, limit, offset)
+}
+
+// GetPostsByAuthorPage retrieves a page of an author's posts from the given tables ordered by newest first,
+// along with the author's total number of posts in those tables.
+func GetPostsByAuthorPage(db *sql.DB, tableNames []string, authorID string, limit, offset int) ([]model.Post, int, error) {
+	return getPostsPage(db, tableNames, "author_id = ?", []interface{}{authorID}, limit, offset)
+}
+
+// SearchPostsPage retrieves a page of posts whose title or content contains the query, newest first,
+// along with the total number of matches.
+func SearchPostsPage(db *sql.DB, tableNames []string, query string, limit, offset int) ([]model.Post, int, error) {
+	pattern := "%" + query + "%"
+	return getPostsPage(db, tableNames, "(title LIKE ? OR content LIKE ?)", []interface{}{pattern, pattern}, limit, offset)
+}
+
+// getPostsPage runs a paginated query over the union of the given tables. filter is an optional condition
+// applied to every table, with filterArgs bound once per table.
+func getPostsPage(db *sql.DB, tableNames []string, filter string, filterArgs []interface{}, limit, offset int) ([]model.Post, int, error) {
+	if len(tableNames) == 0 {
+		return []model.Post{}, 0, nil
+	}
+
+	whereClause := ""
+	if filter != "" {
+		whereClause = " WHERE " + filter
+	}
+
+	var queryBuilder strings.Builder
+	var queryArgs []interface{}
+	for i, tableName := range tableNames {
+		queryBuilder.WriteString(`SELECT id, title, author, author_id, content, tags, message_count, timestamp, cover_image_url, ? AS table_name FROM "`)
+		queryBuilder.WriteString(tableName)
+		queryBuilder.WriteString(`"`)
+		queryBuilder.WriteString(whereClause)
+		if i < len(tableNames)-1 {
+			queryBuilder.WriteString(" UNION ALL ")
+		}
+		queryArgs = append(queryArgs, tableName)
+		queryArgs = append(queryArgs, filterArgs...)
+	}
+	unionQuery := queryBuilder.String()
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM (`+unionQuery+`)`, queryArgs...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(`SELECT * FROM (`+unionQuery+`) ORDER BY timestamp DESC LIMIT ? OFFSET ?`, append(queryArgs, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var posts []model.Post
+	for rows.Next() {
+		var post model.Post
+		if err := rows.Scan(&post.ID, &post.Title, &post.Author, &post.AuthorID, &post.Content, &post.Tags, &post.MessageCount, &post.Timestamp, &post.CoverImageURL, &post.TableName); err != nil {
+			return nil, 0, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
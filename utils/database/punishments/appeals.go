@@ -0,0 +1,66 @@
+package punishments
+
+import (
+	"fmt"
+	"newer_helper/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AddPunishmentAppeal stores a new appeal and returns its ID.
+func AddPunishmentAppeal(db *sqlx.DB, appeal model.PunishmentAppeal) (int64, error) {
+	query := `INSERT INTO punishment_appeals (punishment_id, user_id, guild_id, reason, status, reviewer_id, admin_channel_id, admin_message_id, created_at, reviewed_at)
+			  VALUES (:punishment_id, :user_id, :guild_id, :reason, :status, :reviewer_id, :admin_channel_id, :admin_message_id, :created_at, :reviewed_at)`
+
+	result, err := db.NamedExec(query, appeal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert punishment appeal: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetPunishmentAppealByID retrieves a single appeal by its primary key.
+func GetPunishmentAppealByID(db *sqlx.DB, appealID int64) (*model.PunishmentAppeal, error) {
+	var appeal model.PunishmentAppeal
+	query := "SELECT * FROM punishment_appeals WHERE appeal_id = ?"
+	err := db.Get(&appeal, query, appealID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get punishment appeal by id %d: %w", appealID, err)
+	}
+	return &appeal, nil
+}
+
+// SetPunishmentAppealMessage records where the review message for an appeal was posted.
+func SetPunishmentAppealMessage(db *sqlx.DB, appealID int64, channelID, messageID string) error {
+	query := "UPDATE punishment_appeals SET admin_channel_id = ?, admin_message_id = ? WHERE appeal_id = ?"
+	_, err := db.Exec(query, channelID, messageID, appealID)
+	if err != nil {
+		return fmt.Errorf("failed to update review message for appeal %d: %w", appealID, err)
+	}
+	return nil
+}
+
+// ResolvePunishmentAppeal marks a pending appeal as approved or rejected.
+// It fails if the appeal has already been reviewed, which guards against double clicks.
+func ResolvePunishmentAppeal(db *sqlx.DB, appealID int64, status, reviewerID string) error {
+	query := "UPDATE punishment_appeals SET status = ?, reviewer_id = ?, reviewed_at = ? WHERE appeal_id = ? AND status = 'pending'"
+	result, err := db.Exec(query, status, reviewerID, time.Now().Unix(), appealID)
+	if err != nil {
+		return fmt.Errorf("failed to resolve appeal %d: %w", appealID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected for appeal %d: %w", appealID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending appeal found with id %d", appealID)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package punishments
+
+import (
+	"fmt"
+	"newer_helper/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AddPunishmentApproval stores a pending approval request for a punishment record.
+func AddPunishmentApproval(db *sqlx.DB, approval model.PunishmentApproval) error {
+	query := `INSERT INTO punishment_approvals (punishment_id, guild_id, requester_id, approver_id, plan_json, status, admin_channel_id, admin_message_id, created_at, expires_at, resolved_at)
+			  VALUES (:punishment_id, :guild_id, :requester_id, :approver_id, :plan_json, :status, :admin_channel_id, :admin_message_id, :created_at, :expires_at, :resolved_at)`
+
+	_, err := db.NamedExec(query, approval)
+	if err != nil {
+		return fmt.Errorf("failed to insert punishment approval: %w", err)
+	}
+	return nil
+}
+
+// GetPunishmentApprovalByID retrieves the approval request for a punishment.
+func GetPunishmentApprovalByID(db *sqlx.DB, punishmentID int64) (*model.PunishmentApproval, error) {
+	var approval model.PunishmentApproval
+	query := "SELECT * FROM punishment_approvals WHERE punishment_id = ?"
+	err := db.Get(&approval, query, punishmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval for punishment %d: %w", punishmentID, err)
+	}
+	return &approval, nil
+}
+
+// SetPunishmentApprovalMessage records where the approval request was posted.
+func SetPunishmentApprovalMessage(db *sqlx.DB, punishmentID int64, channelID, messageID string) error {
+	query := "UPDATE punishment_approvals SET admin_channel_id = ?, admin_message_id = ? WHERE punishment_id = ?"
+	_, err := db.Exec(query, channelID, messageID, punishmentID)
+	if err != nil {
+		return fmt.Errorf("failed to update approval message for punishment %d: %w", punishmentID, err)
+	}
+	return nil
+}
+
+// ResolvePunishmentApproval marks a pending, unexpired approval request as approved or rejected.
+// It fails if the request was already resolved or has expired, which guards against double clicks.
+func ResolvePunishmentApproval(db *sqlx.DB, punishmentID int64, status, approverID string) error {
+	now := time.Now().Unix()
+	query := "UPDATE punishment_approvals SET status = ?, approver_id = ?, resolved_at = ? WHERE punishment_id = ? AND status = 'pending' AND expires_at > ?"
+	result, err := db.Exec(query, status, approverID, now, punishmentID, now)
+	if err != nil {
+		return fmt.Errorf("failed to resolve approval for punishment %d: %w", punishmentID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected for punishment %d: %w", punishmentID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no pending approval found for punishment %d", punishmentID)
+	}
+	return nil
+}
+
+// ExpirePunishmentApprovals marks all pending approval requests past their deadline as expired,
+// along with their punishment records, and returns the requests that were expired.
+func ExpirePunishmentApprovals(db *sqlx.DB) ([]model.PunishmentApproval, error) {
+	now := time.Now().Unix()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var approvals []model.PunishmentApproval
+	err = tx.Select(&approvals, "SELECT * FROM punishment_approvals WHERE status = 'pending' AND expires_at <= ?", now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired approvals: %w", err)
+	}
+
+	for idx := range approvals {
+		approvals[idx].Status = model.ApprovalStatusExpired
+		approvals[idx].ResolvedAt = now
+		_, err = tx.Exec("UPDATE punishment_approvals SET status = 'expired', resolved_at = ? WHERE punishment_id = ?", now, approvals[idx].PunishmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expire approval for punishment %d: %w", approvals[idx].PunishmentID, err)
+		}
+		_, err = tx.Exec("UPDATE punishments SET punishment_status = 'expired' WHERE punishment_id = ? AND punishment_status = 'pending_approval'", approvals[idx].PunishmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expire punishment %d: %w", approvals[idx].PunishmentID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit expired approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// DiscardPendingPunishment removes a punishment that is still awaiting approval together with its approval request,
+// e.g. when the request could not be posted for review.
+func DiscardPendingPunishment(db *sqlx.DB, punishmentID int64) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM punishment_approvals WHERE punishment_id = ?", punishmentID); err != nil {
+		return fmt.Errorf("failed to delete approval for punishment %d: %w", punishmentID, err)
+	}
+	if _, err := tx.Exec("DELETE FROM punishments WHERE punishment_id = ? AND punishment_status = 'pending_approval'", punishmentID); err != nil {
+		return fmt.Errorf("failed to delete pending punishment %d: %w", punishmentID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit discarded punishment %d: %w", punishmentID, err)
+	}
+	return nil
+}
+
+// ActivatePunishmentRecord marks an approved punishment as active and stores the temporary roles, timeout and ban it applied.
+func ActivatePunishmentRecord(db *sqlx.DB, punishmentID int64, tempRolesJSON, rolesRemoveAtJSON string, timeoutUntil, banUntil int64) error {
+	query := "UPDATE punishments SET punishment_status = 'active', temp_roles_json = ?, roles_remove_at = ?, timeout_until = ?, ban_until = ? WHERE punishment_id = ?"
+	result, err := db.Exec(query, tempRolesJSON, rolesRemoveAtJSON, timeoutUntil, banUntil, punishmentID)
+	if err != nil {
+		return fmt.Errorf("failed to activate punishment %d: %w", punishmentID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected for punishment ID %d: %w", punishmentID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no punishment found with ID %d", punishmentID)
+	}
+	return nil
+}
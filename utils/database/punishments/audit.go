@@ -0,0 +1,142 @@
+package punishments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"newer_helper/model"
+	"newer_helper/utils/trace"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AuditActorSystem is the actor recorded for changes made by background jobs.
+const AuditActorSystem = "system"
+
+// AuditFilter narrows down QueryAuditEntries. Zero values mean "no filter".
+type AuditFilter struct {
+	GuildID      string
+	PunishmentID int64
+	ActorID      string
+	Since        int64 // Unix seconds, inclusive
+	Until        int64 // Unix seconds, exclusive
+	TraceID      string
+}
+
+// RecordAudit appends an entry to the audit log, tagged with the trace ID carried by ctx.
+// before is nil for creations and after is nil for purges.
+func RecordAudit(ctx context.Context, db *sqlx.DB, action, actorID, reason string, before, after *model.PunishmentRecord) error {
+	entry := model.PunishmentAuditEntry{
+		Action:    action,
+		ActorID:   actorID,
+		Reason:    reason,
+		CreatedAt: time.Now().Unix(),
+		TraceID:   trace.ID(ctx),
+	}
+
+	for _, snapshot := range []*model.PunishmentRecord{after, before} {
+		if snapshot != nil {
+			entry.PunishmentID = snapshot.PunishmentID
+			entry.GuildID = snapshot.GuildID
+		}
+	}
+
+	if before != nil {
+		beforeJSON, err := json.Marshal(before)
+		if err != nil {
+			return fmt.Errorf("failed to serialize audit snapshot: %w", err)
+		}
+		entry.BeforeJSON = string(beforeJSON)
+	}
+	if after != nil {
+		afterJSON, err := json.Marshal(after)
+		if err != nil {
+			return fmt.Errorf("failed to serialize audit snapshot: %w", err)
+		}
+		entry.AfterJSON = string(afterJSON)
+	}
+
+	query := `INSERT INTO punishment_audit (punishment_id, guild_id, action, actor_id, reason, before_json, after_json, created_at, trace_id)
+			  VALUES (:punishment_id, :guild_id, :action, :actor_id, :reason, :before_json, :after_json, :created_at, :trace_id)`
+	_, err := db.NamedExec(query, entry)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// ChangePunishmentStatus updates the status of a punishment record and records the change in the audit log.
+func ChangePunishmentStatus(ctx context.Context, db *sqlx.DB, punishmentID int64, status, actorID, reason string) error {
+	before, err := GetPunishmentRecordByID(db, punishmentID)
+	if err != nil {
+		return err
+	}
+	if err := UpdatePunishmentStatus(db, punishmentID, status); err != nil {
+		return err
+	}
+
+	after := *before
+	after.PunishmentStatus = status
+	return RecordAudit(ctx, db, model.AuditActionStatusChange, actorID, reason, before, &after)
+}
+
+// HasAuditEntry reports whether the audit log contains an entry with the given action for a punishment.
+func HasAuditEntry(db *sqlx.DB, punishmentID int64, action string) (bool, error) {
+	var count int
+	err := db.Get(&count, "SELECT COUNT(*) FROM punishment_audit WHERE punishment_id = ? AND action = ?", punishmentID, action)
+	if err != nil {
+		return false, fmt.Errorf("failed to check audit entries for punishment %d: %w", punishmentID, err)
+	}
+	return count > 0, nil
+}
+
+// QueryAuditEntries returns one page of audit entries matching the filter, newest first, and the total number of matches.
+func QueryAuditEntries(db *sqlx.DB, filter AuditFilter, limit, offset int) ([]model.PunishmentAuditEntry, int, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.GuildID != "" {
+		conditions = append(conditions, "guild_id = ?")
+		args = append(args, filter.GuildID)
+	}
+	if filter.PunishmentID != 0 {
+		conditions = append(conditions, "punishment_id = ?")
+		args = append(args, filter.PunishmentID)
+	}
+	if filter.ActorID != "" {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.TraceID != "" {
+		conditions = append(conditions, "trace_id = ?")
+		args = append(args, filter.TraceID)
+	}
+	if filter.Since != 0 {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Until != 0 {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.Until)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := db.Get(&total, "SELECT COUNT(*) FROM punishment_audit"+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit entries: %w", err)
+	}
+
+	var entries []model.PunishmentAuditEntry
+	query := "SELECT * FROM punishment_audit" + where + " ORDER BY created_at DESC, audit_id DESC LIMIT ? OFFSET ?"
+	err = db.Select(&entries, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	return entries, total, nil
+}
@@ -0,0 +1,81 @@
+package punishments
+
+import (
+	"encoding/json"
+	"fmt"
+	"newer_helper/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SavePunishConfigVersion stores the given actions as the newest config version of a guild and returns its ID.
+func SavePunishConfigVersion(db *sqlx.DB, guildID string, actions map[string]model.ActionConfig, actorID, comment string) (int64, error) {
+	if actions == nil {
+		actions = make(map[string]model.ActionConfig)
+	}
+	actionsJSON, err := json.Marshal(actions)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize punish actions: %w", err)
+	}
+
+	version := model.PunishConfigVersion{
+		GuildID:     guildID,
+		ActionsJSON: string(actionsJSON),
+		ActorID:     actorID,
+		Comment:     comment,
+		CreatedAt:   time.Now().Unix(),
+	}
+	query := `INSERT INTO punish_config_versions (guild_id, actions_json, actor_id, comment, created_at)
+			  VALUES (:guild_id, :actions_json, :actor_id, :comment, :created_at)`
+	result, err := db.NamedExec(query, version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert punish config version: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	return id, nil
+}
+
+// GetLatestPunishConfigVersions retrieves the newest config version of every guild that has one.
+func GetLatestPunishConfigVersions(db *sqlx.DB) ([]model.PunishConfigVersion, error) {
+	var versions []model.PunishConfigVersion
+	query := `SELECT * FROM punish_config_versions
+			  WHERE version_id IN (SELECT MAX(version_id) FROM punish_config_versions GROUP BY guild_id)`
+	err := db.Select(&versions, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest punish config versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetPunishConfigVersionByID retrieves a single config version of a guild.
+func GetPunishConfigVersionByID(db *sqlx.DB, guildID string, versionID int64) (*model.PunishConfigVersion, error) {
+	var version model.PunishConfigVersion
+	query := "SELECT * FROM punish_config_versions WHERE guild_id = ? AND version_id = ?"
+	err := db.Get(&version, query, guildID, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get punish config version %d: %w", versionID, err)
+	}
+	return &version, nil
+}
+
+// GetPunishConfigVersions returns one page of a guild's config versions, newest first, and the total number of versions.
+func GetPunishConfigVersions(db *sqlx.DB, guildID string, limit, offset int) ([]model.PunishConfigVersion, int, error) {
+	var total int
+	err := db.Get(&total, "SELECT COUNT(*) FROM punish_config_versions WHERE guild_id = ?", guildID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count punish config versions for guild %s: %w", guildID, err)
+	}
+
+	var versions []model.PunishConfigVersion
+	query := "SELECT * FROM punish_config_versions WHERE guild_id = ? ORDER BY version_id DESC LIMIT ? OFFSET ?"
+	err = db.Select(&versions, query, guildID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get punish config versions for guild %s: %w", guildID, err)
+	}
+	return versions, total, nil
+}
@@ -29,7 +29,13 @@ func Init(dbPath string) (*sqlx.DB, error) {
 		  action_type TEXT DEFAULT '',
 		  temp_roles_json TEXT DEFAULT '[]',
 		  roles_remove_at TEXT DEFAULT '{}',
-		  punishment_status TEXT DEFAULT 'active'
+		  punishment_status TEXT DEFAULT 'active',
+		  timeout_until INTEGER DEFAULT 0,
+		  ban_until INTEGER DEFAULT 0,
+		  level_key TEXT DEFAULT '',
+		  deleted_at INTEGER DEFAULT 0,
+		  deleted_by TEXT DEFAULT '',
+		  delete_reason TEXT DEFAULT ''
 	      );`
 	_, err = db.Exec(punishmentsSchema)
 	if err != nil {
@@ -42,6 +48,12 @@ func Init(dbPath string) (*sqlx.DB, error) {
 		`ALTER TABLE punishments ADD COLUMN temp_roles_json TEXT DEFAULT '[]'`,
 		`ALTER TABLE punishments ADD COLUMN roles_remove_at TEXT DEFAULT '{}'`,
 		`ALTER TABLE punishments ADD COLUMN punishment_status TEXT DEFAULT 'active'`,
+		`ALTER TABLE punishments ADD COLUMN timeout_until INTEGER DEFAULT 0`,
+		`ALTER TABLE punishments ADD COLUMN deleted_at INTEGER DEFAULT 0`,
+		`ALTER TABLE punishments ADD COLUMN deleted_by TEXT DEFAULT ''`,
+		`ALTER TABLE punishments ADD COLUMN delete_reason TEXT DEFAULT ''`,
+		`ALTER TABLE punishments ADD COLUMN ban_until INTEGER DEFAULT 0`,
+		`ALTER TABLE punishments ADD COLUMN level_key TEXT DEFAULT ''`,
 	}
 
 	for _, stmt := range alterStatements {
@@ -51,6 +63,117 @@ func Init(dbPath string) (*sqlx.DB, error) {
 		}
 	}
 
+	// Create the full-text search index used by /punish_search
+	if err := initSearchIndex(db); err != nil {
+		return nil, err
+	}
+
+	// Create appeals table
+	appealsSchema := `CREATE TABLE IF NOT EXISTS punishment_appeals (
+		appeal_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		punishment_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		guild_id TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		status TEXT DEFAULT 'pending',
+		reviewer_id TEXT DEFAULT '',
+		admin_channel_id TEXT DEFAULT '',
+		admin_message_id TEXT DEFAULT '',
+		created_at INTEGER NOT NULL,
+		reviewed_at INTEGER DEFAULT 0
+	);`
+	_, err = db.Exec(appealsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create punishment_appeals table: %w", err)
+	}
+
+	// Create approvals table for punish levels that need a second admin
+	approvalsSchema := `CREATE TABLE IF NOT EXISTS punishment_approvals (
+		punishment_id INTEGER PRIMARY KEY,
+		guild_id TEXT NOT NULL,
+		requester_id TEXT NOT NULL,
+		approver_id TEXT DEFAULT '',
+		plan_json TEXT NOT NULL,
+		status TEXT DEFAULT 'pending',
+		admin_channel_id TEXT DEFAULT '',
+		admin_message_id TEXT DEFAULT '',
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		resolved_at INTEGER DEFAULT 0
+	);`
+	_, err = db.Exec(approvalsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create punishment_approvals table: %w", err)
+	}
+
+	// Create the append-only audit log; triggers reject any attempt to rewrite history
+	auditSchema := `CREATE TABLE IF NOT EXISTS punishment_audit (
+		audit_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		punishment_id INTEGER NOT NULL,
+		guild_id TEXT DEFAULT '',
+		action TEXT NOT NULL,
+		actor_id TEXT NOT NULL,
+		reason TEXT DEFAULT '',
+		before_json TEXT DEFAULT '',
+		after_json TEXT DEFAULT '',
+		created_at INTEGER NOT NULL,
+		trace_id TEXT DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_punishment_audit_punishment_id ON punishment_audit (punishment_id);
+	CREATE INDEX IF NOT EXISTS idx_punishment_audit_actor_created ON punishment_audit (actor_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_punishment_audit_guild_created ON punishment_audit (guild_id, created_at);
+	CREATE TRIGGER IF NOT EXISTS punishment_audit_no_update BEFORE UPDATE ON punishment_audit
+	BEGIN
+		SELECT RAISE(ABORT, 'punishment_audit is append-only');
+	END;
+	CREATE TRIGGER IF NOT EXISTS punishment_audit_no_delete BEFORE DELETE ON punishment_audit
+	BEGIN
+		SELECT RAISE(ABORT, 'punishment_audit is append-only');
+	END;`
+	_, err = db.Exec(auditSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create punishment_audit table: %w", err)
+	}
+	_, err = db.Exec(`ALTER TABLE punishment_audit ADD COLUMN trace_id TEXT DEFAULT ''`)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("failed to add trace_id to punishment_audit: %w", err)
+	}
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_punishment_audit_trace_id ON punishment_audit (trace_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create punishment_audit trace index: %w", err)
+	}
+
+	// Create user notes table for moderator notes and formal warnings that are not punishments
+	userNotesSchema := `CREATE TABLE IF NOT EXISTS user_notes (
+		note_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		author_id TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT 'note',
+		content TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_notes_guild_user ON user_notes (guild_id, user_id, created_at);`
+	_, err = db.Exec(userNotesSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user_notes table: %w", err)
+	}
+
+	// Create versioned punish config table; the newest version of a guild overrides punish_config.json
+	configVersionsSchema := `CREATE TABLE IF NOT EXISTS punish_config_versions (
+		version_id INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id TEXT NOT NULL,
+		actions_json TEXT NOT NULL,
+		actor_id TEXT NOT NULL,
+		comment TEXT DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_punish_config_versions_guild ON punish_config_versions (guild_id, version_id);`
+	_, err = db.Exec(configVersionsSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create punish_config_versions table: %w", err)
+	}
+
 	// Drop the old timed_tasks table since we're integrating it into punishments
 	_, err = db.Exec(`DROP TABLE IF EXISTS timed_tasks`)
 	if err != nil {
@@ -58,4 +181,4 @@ func Init(dbPath string) (*sqlx.DB, error) {
 	}
 
 	return db, nil
-}
\ No newline at end of file
+}
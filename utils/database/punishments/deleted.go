@@ -0,0 +1,89 @@
+package punishments
+
+import (
+	"fmt"
+	"newer_helper/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SoftDeletePunishmentRecord hides a punishment record from all queries without removing it.
+// The record can be brought back with RestorePunishmentRecord until it is purged.
+func SoftDeletePunishmentRecord(db *sqlx.DB, id int64, deletedBy, reason string) error {
+	query := "UPDATE punishments SET deleted_at = ?, deleted_by = ?, delete_reason = ? WHERE punishment_id = ? AND deleted_at = 0"
+	result, err := db.Exec(query, time.Now().Unix(), deletedBy, reason, id)
+	if err != nil {
+		return fmt.Errorf("failed to soft delete punishment record by id %d: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected for punishment id %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no punishment record found with id %d", id)
+	}
+	return nil
+}
+
+// GetDeletedPunishmentRecordByID retrieves a soft-deleted punishment record by its primary key.
+func GetDeletedPunishmentRecordByID(db *sqlx.DB, id int64) (*model.PunishmentRecord, error) {
+	var record model.PunishmentRecord
+	query := "SELECT * FROM punishments WHERE punishment_id = ? AND deleted_at > 0"
+	err := db.Get(&record, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted punishment record by id %d: %w", id, err)
+	}
+	return &record, nil
+}
+
+// RestorePunishmentRecord clears the soft delete markers of a punishment record.
+func RestorePunishmentRecord(db *sqlx.DB, id int64) error {
+	query := "UPDATE punishments SET deleted_at = 0, deleted_by = '', delete_reason = '' WHERE punishment_id = ? AND deleted_at > 0"
+	result, err := db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore punishment record by id %d: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected for punishment id %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no deleted punishment record found with id %d", id)
+	}
+	return nil
+}
+
+// PurgeDeletedPunishmentRecords permanently removes punishment records soft-deleted before the given time,
+// together with their appeals and approval requests, and returns the removed records.
+// The audit log is append-only and keeps its entries for purged records.
+func PurgeDeletedPunishmentRecords(db *sqlx.DB, before time.Time) ([]model.PunishmentRecord, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var records []model.PunishmentRecord
+	err = tx.Select(&records, "SELECT * FROM punishments WHERE deleted_at > 0 AND deleted_at < ?", before.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get soft-deleted punishments: %w", err)
+	}
+
+	for _, record := range records {
+		if _, err := tx.Exec("DELETE FROM punishment_appeals WHERE punishment_id = ?", record.PunishmentID); err != nil {
+			return nil, fmt.Errorf("failed to delete appeals for punishment %d: %w", record.PunishmentID, err)
+		}
+		if _, err := tx.Exec("DELETE FROM punishment_approvals WHERE punishment_id = ?", record.PunishmentID); err != nil {
+			return nil, fmt.Errorf("failed to delete approval for punishment %d: %w", record.PunishmentID, err)
+		}
+		if _, err := tx.Exec("DELETE FROM punishments WHERE punishment_id = ?", record.PunishmentID); err != nil {
+			return nil, fmt.Errorf("failed to purge punishment %d: %w", record.PunishmentID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit purged punishments: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,80 @@
+package punishments
+
+import (
+	"fmt"
+	"newer_helper/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// AddUserNote stores a new moderator note or warning and returns its ID.
+func AddUserNote(db *sqlx.DB, note model.UserNote) (int64, error) {
+	query := `INSERT INTO user_notes (guild_id, user_id, author_id, kind, content, created_at)
+			  VALUES (:guild_id, :user_id, :author_id, :kind, :content, :created_at)`
+
+	result, err := db.NamedExec(query, note)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert user note: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetUserNoteByID retrieves a single note by its primary key.
+func GetUserNoteByID(db *sqlx.DB, noteID int64) (*model.UserNote, error) {
+	var note model.UserNote
+	err := db.Get(&note, "SELECT * FROM user_notes WHERE note_id = ?", noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user note by id %d: %w", noteID, err)
+	}
+	return &note, nil
+}
+
+// GetUserNotes returns one page of a user's notes and warnings in a guild, newest first, and the total number of notes.
+func GetUserNotes(db *sqlx.DB, guildID, userID string, limit, offset int) ([]model.UserNote, int, error) {
+	var total int
+	err := db.Get(&total, "SELECT COUNT(*) FROM user_notes WHERE guild_id = ? AND user_id = ?", guildID, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count notes for user %s in guild %s: %w", userID, guildID, err)
+	}
+
+	var notes []model.UserNote
+	query := "SELECT * FROM user_notes WHERE guild_id = ? AND user_id = ? ORDER BY created_at DESC, note_id DESC LIMIT ? OFFSET ?"
+	err = db.Select(&notes, query, guildID, userID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get notes for user %s in guild %s: %w", userID, guildID, err)
+	}
+	return notes, total, nil
+}
+
+// GetUserWarnings returns all formal warnings given to a user in a guild, oldest first.
+func GetUserWarnings(db *sqlx.DB, guildID, userID string) ([]model.UserNote, error) {
+	var warnings []model.UserNote
+	query := "SELECT * FROM user_notes WHERE guild_id = ? AND user_id = ? AND kind = ? ORDER BY created_at ASC"
+	err := db.Select(&warnings, query, guildID, userID, model.UserNoteKindWarning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get warnings for user %s in guild %s: %w", userID, guildID, err)
+	}
+	return warnings, nil
+}
+
+// DeleteUserNote permanently removes a note.
+func DeleteUserNote(db *sqlx.DB, noteID int64) error {
+	result, err := db.Exec("DELETE FROM user_notes WHERE note_id = ?", noteID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user note %d: %w", noteID, err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user note %d not found", noteID)
+	}
+	return nil
+}
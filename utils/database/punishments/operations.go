@@ -10,8 +10,8 @@ import (
 
 // AddPunishmentRecord adds a new punishment record to the database and returns the new record's ID.
 func AddPunishmentRecord(db *sqlx.DB, record model.PunishmentRecord) (int64, error) {
-	query := `INSERT INTO punishments (message_id, admin_id, user_id, user_username, reason, guild_id, timestamp, evidence, action_type, temp_roles_json, roles_remove_at, punishment_status)
-			  VALUES (:message_id, :admin_id, :user_id, :user_username, :reason, :guild_id, :timestamp, :evidence, :action_type, :temp_roles_json, :roles_remove_at, :punishment_status)`
+	query := `INSERT INTO punishments (message_id, admin_id, user_id, user_username, reason, guild_id, timestamp, evidence, action_type, temp_roles_json, roles_remove_at, punishment_status, timeout_until, ban_until, level_key)
+			  VALUES (:message_id, :admin_id, :user_id, :user_username, :reason, :guild_id, :timestamp, :evidence, :action_type, :temp_roles_json, :roles_remove_at, :punishment_status, :timeout_until, :ban_until, :level_key)`
 
 	result, err := db.NamedExec(query, record)
 	if err != nil {
@@ -29,7 +29,7 @@ func AddPunishmentRecord(db *sqlx.DB, record model.PunishmentRecord) (int64, err
 // GetPunishmentRecordsByUserID retrieves punishment records for a specific user, optionally filtered by a start time.
 func GetPunishmentRecordsByUserID(db *sqlx.DB, userID string, since *time.Time) ([]model.PunishmentRecord, error) {
 	var records []model.PunishmentRecord
-	query := "SELECT * FROM punishments WHERE user_id = ?"
+	query := "SELECT * FROM punishments WHERE user_id = ? AND deleted_at = 0"
 	args := []interface{}{userID}
 
 	if since != nil {
@@ -47,7 +47,7 @@ func GetPunishmentRecordsByUserID(db *sqlx.DB, userID string, since *time.Time)
 // GetPunishmentRecordsByUserIDAndActionType retrieves punishment records for a specific user and action type.
 func GetPunishmentRecordsByUserIDAndActionType(db *sqlx.DB, userID, actionType string) ([]model.PunishmentRecord, error) {
 	var records []model.PunishmentRecord
-	query := "SELECT * FROM punishments WHERE user_id = ? AND action_type = ? ORDER BY timestamp ASC"
+	query := "SELECT * FROM punishments WHERE user_id = ? AND action_type = ? AND deleted_at = 0 ORDER BY timestamp ASC"
 	err := db.Select(&records, query, userID, actionType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get punishment records for user %s and action type %s: %w", userID, actionType, err)
@@ -58,7 +58,7 @@ func GetPunishmentRecordsByUserIDAndActionType(db *sqlx.DB, userID, actionType s
 // GetPunishmentRecordByID retrieves a single punishment record by its primary key.
 func GetPunishmentRecordByID(db *sqlx.DB, id int64) (*model.PunishmentRecord, error) {
 	var record model.PunishmentRecord
-	query := "SELECT * FROM punishments WHERE punishment_id = ?"
+	query := "SELECT * FROM punishments WHERE punishment_id = ? AND deleted_at = 0"
 	err := db.Get(&record, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get punishment record by id %d: %w", id, err)
@@ -66,27 +66,10 @@ func GetPunishmentRecordByID(db *sqlx.DB, id int64) (*model.PunishmentRecord, er
 	return &record, nil
 }
 
-// DeletePunishmentRecordByID deletes a punishment record by its primary key.
-func DeletePunishmentRecordByID(db *sqlx.DB, id int64) error {
-	query := "DELETE FROM punishments WHERE punishment_id = ?"
-	result, err := db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete punishment record by id %d: %w", id, err)
-	}
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check rows affected for punishment id %d: %w", id, err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("no punishment record found with id %d", id)
-	}
-	return nil
-}
-
 // GetPunishmentRecordsByAdminID retrieves punishment records for a specific admin.
 func GetPunishmentRecordsByAdminID(db *sqlx.DB, adminID string) ([]model.PunishmentRecord, error) {
 	var records []model.PunishmentRecord
-	query := "SELECT * FROM punishments WHERE admin_id = ?"
+	query := "SELECT * FROM punishments WHERE admin_id = ? AND deleted_at = 0"
 	err := db.Select(&records, query, adminID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get punishment records for admin %s: %w", adminID, err)
@@ -97,7 +80,7 @@ func GetPunishmentRecordsByAdminID(db *sqlx.DB, adminID string) ([]model.Punishm
 // GetAllPunishmentRecords retrieves all punishment records for a specific guild.
 func GetAllPunishmentRecords(db *sqlx.DB, guildID string) ([]model.PunishmentRecord, error) {
 	var records []model.PunishmentRecord
-	query := "SELECT * FROM punishments WHERE guild_id = ?"
+	query := "SELECT * FROM punishments WHERE guild_id = ? AND deleted_at = 0"
 	err := db.Select(&records, query, guildID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all punishment records for guild %s: %w", guildID, err)
@@ -108,7 +91,7 @@ func GetAllPunishmentRecords(db *sqlx.DB, guildID string) ([]model.PunishmentRec
 // GetLatestPunishmentByUserID retrieves the latest punishment record for a specific user in a specific guild.
 func GetLatestPunishmentByUserID(db *sqlx.DB, guildID, userID string) (*model.PunishmentRecord, error) {
 	var record model.PunishmentRecord
-	query := "SELECT * FROM punishments WHERE guild_id = ? AND user_id = ? ORDER BY timestamp DESC LIMIT 1"
+	query := "SELECT * FROM punishments WHERE guild_id = ? AND user_id = ? AND deleted_at = 0 ORDER BY timestamp DESC LIMIT 1"
 	err := db.Get(&record, query, guildID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest punishment record for user %s in guild %s: %w", userID, guildID, err)
@@ -118,7 +101,7 @@ func GetLatestPunishmentByUserID(db *sqlx.DB, guildID, userID string) (*model.Pu
 
 // GetAdminPunishmentStats retrieves the punishment count for each admin within a given time range.
 func GetAdminPunishmentStats(db *sqlx.DB, guildID string, since time.Time) (map[string]int, error) {
-	query := `SELECT admin_id, COUNT(*) as count FROM punishments WHERE guild_id = ? AND timestamp >= ? GROUP BY admin_id ORDER BY count DESC`
+	query := `SELECT admin_id, COUNT(*) as count FROM punishments WHERE guild_id = ? AND timestamp >= ? AND deleted_at = 0 GROUP BY admin_id ORDER BY count DESC`
 	rows, err := db.Query(query, guildID, since.Unix())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get admin punishment stats for guild %s: %w", guildID, err)
@@ -140,7 +123,7 @@ func GetAdminPunishmentStats(db *sqlx.DB, guildID string, since time.Time) (map[
 // GetTotalPunishmentCount retrieves the total number of punishments within a given time range.
 func GetTotalPunishmentCount(db *sqlx.DB, guildID string, since time.Time) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND timestamp >= ?`
+	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND timestamp >= ? AND deleted_at = 0`
 	err := db.Get(&count, query, guildID, since.Unix())
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total punishment count for guild %s: %w", guildID, err)
@@ -151,7 +134,7 @@ func GetTotalPunishmentCount(db *sqlx.DB, guildID string, since time.Time) (int,
 // GetPunishmentCountByAction retrieves the number of punishments for a specific user and action type within a given time range.
 func GetPunishmentCountByAction(db *sqlx.DB, guildID, userID, actionType string, since time.Time) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND user_id = ? AND action_type = ? AND timestamp >= ?`
+	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND user_id = ? AND action_type = ? AND timestamp >= ? AND deleted_at = 0`
 	err := db.Get(&count, query, guildID, userID, actionType, since.Unix())
 	if err != nil {
 		return 0, fmt.Errorf("failed to get punishment count for user %s with action %s in guild %s: %w", userID, actionType, guildID, err)
@@ -159,10 +142,12 @@ func GetPunishmentCountByAction(db *sqlx.DB, guildID, userID, actionType string,
 	return count, nil
 }
 
-// GetActivePunishmentCountByUser retrieves the total number of active punishments for a specific user (all action types).
+// GetActivePunishmentCountByUser retrieves the total number of punishments still in effect for a specific user
+// (all action types). Punishments under appeal or whose appeal was rejected are still in effect.
 func GetActivePunishmentCountByUser(db *sqlx.DB, guildID, userID string) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND user_id = ? AND punishment_status = 'active'`
+	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND user_id = ?
+			  AND punishment_status IN ('active', 'appealed', 'appeal_rejected') AND deleted_at = 0`
 	err := db.Get(&count, query, guildID, userID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total active punishment count for user %s in guild %s: %w", userID, guildID, err)
@@ -171,9 +156,12 @@ func GetActivePunishmentCountByUser(db *sqlx.DB, guildID, userID string) (int, e
 }
 
 // GetTotalPunishmentCountByUser retrieves the total number of punishments for a specific user (all action types).
+// Punishments cancelled through an approved appeal, and ones that were never approved, are not counted.
 func GetTotalPunishmentCountByUser(db *sqlx.DB, guildID, userID string) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND user_id = ?`
+	query := `SELECT COUNT(*) FROM punishments WHERE guild_id = ? AND user_id = ?
+			  AND punishment_status NOT IN ('cancelled', 'pending_approval', 'rejected', 'expired')
+			  AND deleted_at = 0`
 	err := db.Get(&count, query, guildID, userID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get total punishment count for user %s in guild %s: %w", userID, guildID, err)
@@ -181,13 +169,15 @@ func GetTotalPunishmentCountByUser(db *sqlx.DB, guildID, userID string) (int, er
 	return count, nil
 }
 
-// GetActivePunishments retrieves all active punishment records that have temporary roles.
+// GetActivePunishments retrieves all in-effect punishment records that have temporary roles.
+// Records under appeal are included since the punishment stands until the appeal is approved.
 func GetActivePunishments(db *sqlx.DB) ([]model.PunishmentRecord, error) {
 	var records []model.PunishmentRecord
 	query := `SELECT * FROM punishments
-			  WHERE punishment_status = 'active'
+			  WHERE punishment_status IN ('active', 'appealed', 'appeal_rejected')
 			  AND temp_roles_json != '[]'
-			  AND temp_roles_json != ''`
+			  AND temp_roles_json != ''
+			  AND deleted_at = 0`
 	err := db.Select(&records, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active punishments: %w", err)
@@ -195,6 +185,60 @@ func GetActivePunishments(db *sqlx.DB) ([]model.PunishmentRecord, error) {
 	return records, nil
 }
 
+// GetScheduledUnbans retrieves all in-effect punishment records with a temporary ban that has not been lifted yet.
+func GetScheduledUnbans(db *sqlx.DB) ([]model.PunishmentRecord, error) {
+	var records []model.PunishmentRecord
+	query := `SELECT * FROM punishments
+			  WHERE punishment_status IN ('active', 'appealed', 'appeal_rejected')
+			  AND ban_until > 0
+			  AND deleted_at = 0`
+	err := db.Select(&records, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled unbans: %w", err)
+	}
+	return records, nil
+}
+
+// GetInEffectPunishmentsByUser retrieves the punishments still being enforced on a user in a guild, oldest first.
+func GetInEffectPunishmentsByUser(db *sqlx.DB, guildID, userID string) ([]model.PunishmentRecord, error) {
+	var records []model.PunishmentRecord
+	query := `SELECT * FROM punishments
+			  WHERE guild_id = ? AND user_id = ?
+			  AND punishment_status IN ('active', 'appealed', 'appeal_rejected')
+			  AND deleted_at = 0
+			  ORDER BY timestamp ASC`
+	err := db.Select(&records, query, guildID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get in-effect punishments for user %s in guild %s: %w", userID, guildID, err)
+	}
+	return records, nil
+}
+
+// GetInEffectPunishmentsByGuild retrieves a page of the punishments still being enforced in a guild, newest first,
+// along with the total number of such punishments.
+func GetInEffectPunishmentsByGuild(db *sqlx.DB, guildID string, limit, offset int) ([]model.PunishmentRecord, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM punishments
+			  WHERE guild_id = ?
+			  AND punishment_status IN ('active', 'appealed', 'appeal_rejected')
+			  AND deleted_at = 0`
+	if err := db.Get(&total, countQuery, guildID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count in-effect punishments for guild %s: %w", guildID, err)
+	}
+
+	var records []model.PunishmentRecord
+	query := `SELECT * FROM punishments
+			  WHERE guild_id = ?
+			  AND punishment_status IN ('active', 'appealed', 'appeal_rejected')
+			  AND deleted_at = 0
+			  ORDER BY timestamp DESC
+			  LIMIT ? OFFSET ?`
+	if err := db.Select(&records, query, guildID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to get in-effect punishments for guild %s: %w", guildID, err)
+	}
+	return records, total, nil
+}
+
 // UpdatePunishmentStatus updates the status of a punishment record.
 func UpdatePunishmentStatus(db *sqlx.DB, punishmentID int64, status string) error {
 	query := "UPDATE punishments SET punishment_status = ? WHERE punishment_id = ?"
@@ -212,6 +256,23 @@ func UpdatePunishmentStatus(db *sqlx.DB, punishmentID int64, status string) erro
 	return nil
 }
 
+// ClearPunishmentBanUntil marks the temporary ban of a punishment as lifted.
+func ClearPunishmentBanUntil(db *sqlx.DB, punishmentID int64) error {
+	query := "UPDATE punishments SET ban_until = 0 WHERE punishment_id = ?"
+	result, err := db.Exec(query, punishmentID)
+	if err != nil {
+		return fmt.Errorf("failed to clear ban_until for punishment ID %d: %w", punishmentID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected for punishment ID %d: %w", punishmentID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("no punishment found with ID %d", punishmentID)
+	}
+	return nil
+}
+
 // RemoveExpiredRoleFromPunishment removes a specific role from the roles_remove_at JSON of a punishment.
 func RemoveExpiredRoleFromPunishment(db *sqlx.DB, punishmentID int64, roleID string, newRolesRemoveAtJSON string) error {
 	query := "UPDATE punishments SET roles_remove_at = ? WHERE punishment_id = ?"
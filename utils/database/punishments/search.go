@@ -0,0 +1,214 @@
+package punishments
+
+import (
+	"fmt"
+	"log"
+	"newer_helper/model"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// searchIndexTriggers keep punishments_fts in sync with the punishments table
+var searchIndexTriggers = []string{"punishments_fts_ai", "punishments_fts_ad", "punishments_fts_au"}
+
+// evidenceTextSQL extracts the message contents from the evidence JSON of a row, so that attachment paths
+// and JSON keys are not indexed. Evidence that is not JSON is indexed as is.
+const evidenceTextSQL = `CASE WHEN json_valid(%[1]s.evidence)
+	THEN (SELECT group_concat(json_extract(value, '$.content'), ' ') FROM json_each(%[1]s.evidence))
+	ELSE %[1]s.evidence END`
+
+// minFTSTokenLength is the shortest term the trigram tokenizer can match; shorter terms fall back to LIKE
+const minFTSTokenLength = 3
+
+var noFTSOnce sync.Once
+
+// SearchFilter selects punishment records for /punish_search. Empty fields are not filtered on.
+type SearchFilter struct {
+	GuildIDs     []string // guilds the caller administers; nothing matches if empty
+	PunishmentID int64
+	UserID       string
+	AdminID      string
+	Query        string // free text matched against the reason, username and evidence
+	ActionType   string
+	Status       string
+	Since        int64 // unix seconds, inclusive
+	Until        int64 // unix seconds, exclusive
+}
+
+// initSearchIndex creates the full-text index over the reason, username and evidence text of punishments.
+// FTS5 is only available when the binary is built with the sqlite_fts5 tag; without it the index triggers
+// are dropped so that writes keep working, and searches fall back to LIKE.
+func initSearchIndex(db *sqlx.DB) error {
+	if !fts5Enabled(db) {
+		for _, trigger := range searchIndexTriggers {
+			if _, err := db.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+				return fmt.Errorf("failed to drop search index trigger %s: %w", trigger, err)
+			}
+		}
+		noFTSOnce.Do(func() {
+			log.Println("SQLite was built without FTS5, punishment search falls back to LIKE matching")
+		})
+		return nil
+	}
+	if searchIndexMaintained(db) {
+		return nil
+	}
+
+	// The triggers are missing, so the index is new or went stale while a build without FTS5 wrote to the
+	// database: rebuild it from scratch.
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin search index transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS punishments_fts USING fts5(reason, user_username, evidence_text, tokenize = 'trigram')`,
+		`DELETE FROM punishments_fts`,
+		`INSERT INTO punishments_fts (rowid, reason, user_username, evidence_text)
+			SELECT punishment_id, reason, user_username, ` + fmt.Sprintf(evidenceTextSQL, "punishments") + ` FROM punishments`,
+		`CREATE TRIGGER punishments_fts_ai AFTER INSERT ON punishments BEGIN
+			INSERT INTO punishments_fts (rowid, reason, user_username, evidence_text)
+			VALUES (new.punishment_id, new.reason, new.user_username, ` + fmt.Sprintf(evidenceTextSQL, "new") + `);
+		END`,
+		`CREATE TRIGGER punishments_fts_ad AFTER DELETE ON punishments BEGIN
+			DELETE FROM punishments_fts WHERE rowid = old.punishment_id;
+		END`,
+		`CREATE TRIGGER punishments_fts_au AFTER UPDATE OF reason, user_username, evidence ON punishments BEGIN
+			DELETE FROM punishments_fts WHERE rowid = old.punishment_id;
+			INSERT INTO punishments_fts (rowid, reason, user_username, evidence_text)
+			VALUES (new.punishment_id, new.reason, new.user_username, ` + fmt.Sprintf(evidenceTextSQL, "new") + `);
+		END`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to build search index: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// fts5Enabled reports whether the linked SQLite has the FTS5 extension
+func fts5Enabled(db *sqlx.DB) bool {
+	var enabled bool
+	if err := db.Get(&enabled, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`); err != nil {
+		return false
+	}
+	return enabled
+}
+
+// searchIndexMaintained reports whether all index triggers exist, i.e. punishments_fts is up to date
+func searchIndexMaintained(db *sqlx.DB) bool {
+	var count int
+	query, args, err := sqlx.In(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name IN (?)`, searchIndexTriggers)
+	if err != nil {
+		return false
+	}
+	if err := db.Get(&count, query, args...); err != nil {
+		return false
+	}
+	return count == len(searchIndexTriggers)
+}
+
+// SearchPunishments returns one page of punishment records matching the filter, newest first, and the total
+// number of matches. Every whitespace-separated term of the query must match the reason, username or evidence.
+func SearchPunishments(db *sqlx.DB, filter SearchFilter, limit, offset int) ([]model.PunishmentRecord, int, error) {
+	if len(filter.GuildIDs) == 0 {
+		return nil, 0, nil
+	}
+
+	conditions := []string{"p.deleted_at = 0"}
+	var args []interface{}
+
+	inGuilds, guildArgs, err := sqlx.In("p.guild_id IN (?)", filter.GuildIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to build guild filter: %w", err)
+	}
+	conditions = append(conditions, inGuilds)
+	args = append(args, guildArgs...)
+
+	if filter.PunishmentID != 0 {
+		conditions = append(conditions, "p.punishment_id = ?")
+		args = append(args, filter.PunishmentID)
+	}
+	if filter.UserID != "" {
+		conditions = append(conditions, "p.user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.AdminID != "" {
+		conditions = append(conditions, "p.admin_id = ?")
+		args = append(args, filter.AdminID)
+	}
+	if filter.ActionType != "" {
+		conditions = append(conditions, "p.action_type = ?")
+		args = append(args, filter.ActionType)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "p.punishment_status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Since != 0 {
+		conditions = append(conditions, "p.timestamp >= ?")
+		args = append(args, filter.Since)
+	}
+	if filter.Until != 0 {
+		conditions = append(conditions, "p.timestamp < ?")
+		args = append(args, filter.Until)
+	}
+
+	if terms := strings.Fields(filter.Query); len(terms) > 0 {
+		if canUseSearchIndex(db, terms) {
+			conditions = append(conditions, "p.punishment_id IN (SELECT rowid FROM punishments_fts WHERE punishments_fts MATCH ?)")
+			args = append(args, ftsQuery(terms))
+		} else {
+			for _, term := range terms {
+				pattern := "%" + escapeLike(term) + "%"
+				conditions = append(conditions, `(p.reason LIKE ? ESCAPE '\' OR p.user_username LIKE ? ESCAPE '\' OR (`+fmt.Sprintf(evidenceTextSQL, "p")+`) LIKE ? ESCAPE '\')`)
+				args = append(args, pattern, pattern, pattern)
+			}
+		}
+	}
+
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	var total int
+	err = db.Get(&total, "SELECT COUNT(*) FROM punishments p"+where, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count punishment search results: %w", err)
+	}
+
+	var records []model.PunishmentRecord
+	query := "SELECT p.* FROM punishments p" + where + " ORDER BY p.timestamp DESC, p.punishment_id DESC LIMIT ? OFFSET ?"
+	err = db.Select(&records, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search punishment records: %w", err)
+	}
+	return records, total, nil
+}
+
+// canUseSearchIndex reports whether the terms can be matched with the FTS5 index
+func canUseSearchIndex(db *sqlx.DB, terms []string) bool {
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minFTSTokenLength {
+			return false
+		}
+	}
+	return fts5Enabled(db) && searchIndexMaintained(db)
+}
+
+// ftsQuery quotes every term as an FTS5 string, so that user input cannot inject query syntax
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, " ")
+}
+
+// escapeLike escapes the LIKE wildcards in s, using '\' as the escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"math"
+	"newer_helper/model"
+	"sort"
+	"time"
+)
+
+// IsPointsEscalation reports whether the action picks its punish level by decaying points instead of by count.
+func IsPointsEscalation(actionConfig model.ActionConfig) bool {
+	return actionConfig.Escalation != nil && actionConfig.Escalation.Mode == model.EscalationModePoints
+}
+
+// escalationWeight returns the points and half-life a record of the given action type contributes.
+// Actions without their own escalation settings fall back to those of the action being evaluated.
+func escalationWeight(actionType string, guildActions map[string]model.ActionConfig, fallback *model.EscalationConfig) (float64, time.Duration) {
+	points := 1.0
+	halfLifeStr := ""
+	if fallback != nil {
+		if fallback.Points > 0 {
+			points = fallback.Points
+		}
+		halfLifeStr = fallback.HalfLife
+	}
+
+	if recordAction, ok := guildActions[actionType]; ok && recordAction.Escalation != nil {
+		if recordAction.Escalation.Points > 0 {
+			points = recordAction.Escalation.Points
+		}
+		if recordAction.Escalation.HalfLife != "" {
+			halfLifeStr = recordAction.Escalation.HalfLife
+		}
+	}
+
+	halfLife, err := ParseDuration(halfLifeStr)
+	if halfLifeStr == "" || err != nil || halfLife <= 0 {
+		return points, 0
+	}
+	return points, halfLife
+}
+
+// WarningRecords converts formal warnings into records that can be scored alongside punishments, for actions
+// with count_warnings enabled. They have no action type, so each is weighted like a punishment of the action
+// being evaluated.
+func WarningRecords(warnings []model.UserNote) []model.PunishmentRecord {
+	records := make([]model.PunishmentRecord, 0, len(warnings))
+	for _, warning := range warnings {
+		if !warning.IsWarning() {
+			continue
+		}
+		records = append(records, model.PunishmentRecord{
+			AdminID:          warning.AuthorID,
+			UserID:           warning.UserID,
+			GuildID:          warning.GuildID,
+			Reason:           warning.Content,
+			Timestamp:        warning.CreatedAt,
+			PunishmentStatus: model.PunishmentStatusActive,
+		})
+	}
+	return records
+}
+
+// CalculatePunishmentScore returns the user's decayed punishment score at the given time.
+// Punishments that were never enforced or were cancelled do not count towards the score.
+func CalculatePunishmentScore(records []model.PunishmentRecord, guildActions map[string]model.ActionConfig, actionConfig model.ActionConfig, at time.Time) float64 {
+	score := 0.0
+	for _, record := range records {
+		if !record.CountsTowardEscalation() {
+			continue
+		}
+		points, halfLife := escalationWeight(record.ActionType, guildActions, actionConfig.Escalation)
+		age := at.Sub(time.Unix(record.Timestamp, 0))
+		if halfLife > 0 && age > 0 {
+			points *= math.Pow(0.5, float64(age)/float64(halfLife))
+		}
+		score += points
+	}
+	return score
+}
+
+// sortedThresholdKeys returns the level keys that have a threshold, ordered by ascending threshold.
+func sortedThresholdKeys(actionConfig model.ActionConfig) []string {
+	var keys []string
+	if actionConfig.Escalation == nil {
+		return keys
+	}
+	for key := range actionConfig.Escalation.Thresholds {
+		if _, ok := actionConfig.Data[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	thresholds := actionConfig.Escalation.Thresholds
+	sort.Slice(keys, func(a, b int) bool {
+		if thresholds[keys[a]] == thresholds[keys[b]] {
+			return keys[a] < keys[b]
+		}
+		return thresholds[keys[a]] < thresholds[keys[b]]
+	})
+	return keys
+}
+
+// SelectLevelByScore returns the level with the highest threshold not above score.
+// If the score is below every threshold, the lowest level is returned.
+func SelectLevelByScore(actionConfig model.ActionConfig, score float64) (string, *model.PunishLevel) {
+	keys := sortedThresholdKeys(actionConfig)
+	if len(keys) == 0 {
+		return "", nil
+	}
+
+	selected := keys[0]
+	for _, key := range keys {
+		if actionConfig.Escalation.Thresholds[key] <= score {
+			selected = key
+		}
+	}
+	level := actionConfig.Data[selected]
+	return selected, &level
+}
+
+// NextLevelDropTime returns when the user's score will fall below the threshold of their current level.
+// It returns false if the user is already at the lowest level or their score never decays.
+func NextLevelDropTime(records []model.PunishmentRecord, guildActions map[string]model.ActionConfig, actionConfig model.ActionConfig, now time.Time) (time.Time, bool) {
+	keys := sortedThresholdKeys(actionConfig)
+	if len(keys) < 2 {
+		return time.Time{}, false
+	}
+
+	score := CalculatePunishmentScore(records, guildActions, actionConfig, now)
+	currentKey, _ := SelectLevelByScore(actionConfig, score)
+	threshold := actionConfig.Escalation.Thresholds[currentKey]
+	if currentKey == keys[0] || score < threshold {
+		return time.Time{}, false
+	}
+
+	var longestHalfLife time.Duration
+	for _, record := range records {
+		if !record.CountsTowardEscalation() {
+			continue
+		}
+		_, halfLife := escalationWeight(record.ActionType, guildActions, actionConfig.Escalation)
+		if halfLife == 0 {
+			// Points that never decay may keep the score above the threshold forever;
+			// the search below will detect that.
+			continue
+		}
+		if halfLife > longestHalfLife {
+			longestHalfLife = halfLife
+		}
+	}
+	if longestHalfLife == 0 {
+		return time.Time{}, false
+	}
+
+	// Find an upper bound where the score has dropped, then bisect down to minute precision.
+	low, high := now, now.Add(longestHalfLife)
+	for CalculatePunishmentScore(records, guildActions, actionConfig, high) >= threshold {
+		if high.Sub(now) > 64*longestHalfLife {
+			return time.Time{}, false
+		}
+		low = high
+		high = now.Add(2 * high.Sub(now))
+	}
+	for high.Sub(low) > time.Minute {
+		mid := low.Add(high.Sub(low) / 2)
+		if CalculatePunishmentScore(records, guildActions, actionConfig, mid) >= threshold {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return high, true
+}
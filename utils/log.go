@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"newer_helper/utils/trace"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -28,7 +30,7 @@ func getColor(level LogLevel) int {
 	}
 }
 
-func sendLog(s *discordgo.Session, channelID string, level LogLevel, module, operation, extraInfo string) error {
+func sendLog(ctx context.Context, s *discordgo.Session, channelID string, level LogLevel, module, operation, extraInfo string) error {
 	embedFields := []*discordgo.MessageEmbedField{}
 	if module != "" {
 		embedFields = append(embedFields, &discordgo.MessageEmbedField{Name: "模块", Value: module})
@@ -45,7 +47,7 @@ func sendLog(s *discordgo.Session, channelID string, level LogLevel, module, ope
 		Color:  getColor(level),
 		Fields: embedFields,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: time.Now().Format(time.RFC1123),
+			Text: trace.Footer(ctx, time.Now().Format(time.RFC1123)),
 		},
 	}
 
@@ -58,13 +60,28 @@ func sendLog(s *discordgo.Session, channelID string, level LogLevel, module, ope
 }
 
 func LogInfo(s *discordgo.Session, channelID, module, operation, extraInfo string) error {
-	return sendLog(s, channelID, Info, module, operation, extraInfo)
+	return sendLog(context.Background(), s, channelID, Info, module, operation, extraInfo)
 }
 
 func LogWarn(s *discordgo.Session, channelID, module, operation, extraInfo string) error {
-	return sendLog(s, channelID, Warn, module, operation, extraInfo)
+	return sendLog(context.Background(), s, channelID, Warn, module, operation, extraInfo)
 }
 
 func LogError(s *discordgo.Session, channelID, module, operation, extraInfo string) error {
-	return sendLog(s, channelID, Error, module, operation, extraInfo)
+	return sendLog(context.Background(), s, channelID, Error, module, operation, extraInfo)
+}
+
+// LogInfoCtx is LogInfo with the trace ID carried by ctx shown in the embed footer.
+func LogInfoCtx(ctx context.Context, s *discordgo.Session, channelID, module, operation, extraInfo string) error {
+	return sendLog(ctx, s, channelID, Info, module, operation, extraInfo)
+}
+
+// LogWarnCtx is LogWarn with the trace ID carried by ctx shown in the embed footer.
+func LogWarnCtx(ctx context.Context, s *discordgo.Session, channelID, module, operation, extraInfo string) error {
+	return sendLog(ctx, s, channelID, Warn, module, operation, extraInfo)
+}
+
+// LogErrorCtx is LogError with the trace ID carried by ctx shown in the embed footer.
+func LogErrorCtx(ctx context.Context, s *discordgo.Session, channelID, module, operation, extraInfo string) error {
+	return sendLog(ctx, s, channelID, Error, module, operation, extraInfo)
 }
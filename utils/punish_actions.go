@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"newer_helper/model"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// customEmojiPattern matches custom emoji in message syntax, e.g. "<:name:id>" or "<a:name:id>" for animated ones.
+var customEmojiPattern = regexp.MustCompile(`^<(a?):(\w+):(\d+)>$`)
+
+// punishActionButtonStyles maps ActionConfig.ButtonStyle to Discord button styles.
+var punishActionButtonStyles = map[string]discordgo.ButtonStyle{
+	"":          discordgo.PrimaryButton,
+	"primary":   discordgo.PrimaryButton,
+	"secondary": discordgo.SecondaryButton,
+	"success":   discordgo.SuccessButton,
+	"danger":    discordgo.DangerButton,
+}
+
+// legacyPunishActionOrder is the fixed button order used before actions could be ordered in the config.
+// Actions without an order keep it, so existing configs show their buttons as before.
+var legacyPunishActionOrder = map[string]int{
+	"re-answer": 1,
+	"cheat":     2,
+	"tag":       3,
+}
+
+// OrderedPunishActions returns the keys of a guild's punish actions in display order: ascending ActionConfig.Order,
+// then actions without an order, the legacy re-answer, cheat and tag first, and ties by key.
+func OrderedPunishActions(actions map[string]model.ActionConfig) []string {
+	keys := make([]string, 0, len(actions))
+	for key := range actions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		orderA, orderB := actions[keys[a]].Order, actions[keys[b]].Order
+		if orderA != orderB {
+			if orderA == 0 || orderB == 0 {
+				return orderB == 0
+			}
+			return orderA < orderB
+		}
+		if orderA == 0 {
+			legacyA, okA := legacyPunishActionOrder[keys[a]]
+			legacyB, okB := legacyPunishActionOrder[keys[b]]
+			if okA != okB {
+				return okA
+			}
+			if legacyA != legacyB {
+				return legacyA < legacyB
+			}
+		}
+		return keys[a] < keys[b]
+	})
+	return keys
+}
+
+// PunishActionButtonStyle returns the button style configured for an action, and false if the style is unknown.
+func PunishActionButtonStyle(actionConfig model.ActionConfig) (discordgo.ButtonStyle, bool) {
+	style, ok := punishActionButtonStyles[actionConfig.ButtonStyle]
+	if !ok {
+		return discordgo.PrimaryButton, false
+	}
+	return style, true
+}
+
+// PunishActionEmoji parses the button emoji configured for an action. It returns nil if none is configured,
+// and false if the emoji looks like a malformed custom emoji.
+func PunishActionEmoji(actionConfig model.ActionConfig) (*discordgo.ComponentEmoji, bool) {
+	if actionConfig.ButtonEmoji == "" {
+		return nil, true
+	}
+	if match := customEmojiPattern.FindStringSubmatch(actionConfig.ButtonEmoji); match != nil {
+		return &discordgo.ComponentEmoji{Name: match[2], ID: match[3], Animated: match[1] == "a"}, true
+	}
+	if actionConfig.ButtonEmoji[0] == '<' || actionConfig.ButtonEmoji[0] == ':' {
+		return nil, false
+	}
+	return &discordgo.ComponentEmoji{Name: actionConfig.ButtonEmoji}, true
+}
+
+// PunishActionLabel returns the quick-punish label of an action.
+func PunishActionLabel(actionKey string, actionConfig model.ActionConfig) string {
+	switch {
+	case actionConfig.ButtonLabel != "":
+		return actionConfig.ButtonLabel
+	case actionConfig.Name != "":
+		return actionConfig.Name
+	}
+	return actionKey
+}
+
+// PunishPreviewRequired reports whether the admin must confirm a preview before the level of the action is applied.
+// Levels that ban the user or need a second admin's approval always need one.
+func PunishPreviewRequired(actionConfig model.ActionConfig, level model.PunishLevel) bool {
+	return actionConfig.RequirePreview || level.RequiresPreview || level.RequiresApproval ||
+		level.Ban != "" || level.Timeout == "ban"
+}
+
+// RoleMentionList mentions the given roles, skipping empty and "0" placeholder IDs, or returns "无" if there are none.
+func RoleMentionList(roleIDs []string) string {
+	var mentions []string
+	for _, roleID := range roleIDs {
+		if roleID != "" && roleID != "0" {
+			mentions = append(mentions, "<@&"+roleID+">")
+		}
+	}
+	if len(mentions) == 0 {
+		return "无"
+	}
+	return strings.Join(mentions, " ")
+}
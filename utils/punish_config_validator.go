@@ -0,0 +1,399 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"newer_helper/model"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Severities of punish config issues.
+const (
+	ConfigIssueError   = "error"
+	ConfigIssueWarning = "warning"
+)
+
+// PunishConfigIssue is one problem found in a guild's punish configuration.
+type PunishConfigIssue struct {
+	Severity string
+	Action   string // action key, empty for guild-wide issues
+	Level    string // level key, empty for action-wide issues
+	Message  string
+}
+
+// punishConfigValidator collects issues for one guild, caching the Discord lookups it needs.
+type punishConfigValidator struct {
+	s          *discordgo.Session
+	guildID    string
+	roles      map[string]*discordgo.Role
+	botTopRole int // position of the bot's highest role, -1 if unknown
+	channels   map[string]error
+	presets    map[string]bool
+	issues     []PunishConfigIssue
+}
+
+// ValidatePunishConfig checks every action and level of a guild against the live guild: roles exist and
+// are below the bot's highest role, durations parse, level keys have no gaps, preset IDs exist and admin
+// channels are reachable. rawActions is the guild's action JSON as written in the config file, used to
+// spot misspelled fields; it may be nil.
+func ValidatePunishConfig(s *discordgo.Session, cfg *model.Config, punishConfig *model.PunishConfig, guildID string, rawActions json.RawMessage) []PunishConfigIssue {
+	v := &punishConfigValidator{
+		s:          s,
+		guildID:    guildID,
+		botTopRole: -1,
+		channels:   make(map[string]error),
+		presets:    make(map[string]bool),
+	}
+
+	for _, serverConfig := range cfg.ServerConfigs {
+		for _, preset := range serverConfig.PresetMessages {
+			v.presets[preset.ID] = true
+		}
+	}
+
+	guildRoles, err := s.GuildRoles(guildID)
+	if err != nil {
+		v.add(ConfigIssueError, "", "", fmt.Sprintf("无法获取服务器身份组，跳过身份组检查: %v", err))
+	} else {
+		v.roles = make(map[string]*discordgo.Role, len(guildRoles))
+		for _, role := range guildRoles {
+			v.roles[role.ID] = role
+		}
+		if s.State != nil && s.State.User != nil {
+			if botMember, err := s.GuildMember(guildID, s.State.User.ID); err == nil {
+				for _, roleID := range botMember.Roles {
+					if role, ok := v.roles[roleID]; ok && role.Position > v.botTopRole {
+						v.botTopRole = role.Position
+					}
+				}
+			}
+		}
+	}
+
+	actions := punishConfig.PunishConfig[guildID]
+	if len(actions) == 0 {
+		v.add(ConfigIssueWarning, "", "", "本服务器没有配置任何处罚类型。")
+	}
+
+	actionKeys := make([]string, 0, len(actions))
+	for key := range actions {
+		actionKeys = append(actionKeys, key)
+	}
+	sort.Strings(actionKeys)
+	for _, key := range actionKeys {
+		v.validateAction(key, actions[key])
+	}
+
+	visible := 0
+	for _, action := range actions {
+		if !action.Hidden {
+			visible++
+		}
+	}
+	if visible > 25 {
+		v.add(ConfigIssueWarning, "", "", fmt.Sprintf("有 %d 个处罚类型在快速处罚中显示，只有前 25 个会出现在选择菜单中。", visible))
+	}
+
+	if revocation, ok := punishConfig.Revocation[guildID]; ok {
+		v.checkRole("", "", "revocation.recover_roleid", revocation.RecoverRoleID, true)
+	}
+
+	if rawActions != nil {
+		v.checkUnknownFields(rawActions)
+	}
+
+	return v.issues
+}
+
+func (v *punishConfigValidator) add(severity, action, level, message string) {
+	v.issues = append(v.issues, PunishConfigIssue{Severity: severity, Action: action, Level: level, Message: message})
+}
+
+func (v *punishConfigValidator) validateAction(key string, action model.ActionConfig) {
+	if action.Type == "" {
+		v.add(ConfigIssueWarning, key, "", "`tpye` 字段为空（注意字段名保留了拼写 tpye）。")
+	}
+	if action.Name == "" {
+		v.add(ConfigIssueWarning, key, "", "`name` 为空，通知中将无法显示处罚名称。")
+	}
+	if action.GuildID != "" && action.GuildID != v.guildID {
+		v.add(ConfigIssueError, key, "", fmt.Sprintf("`guilds_id` 为 `%s`，与所在服务器不一致。", action.GuildID))
+	}
+
+	if _, ok := PunishActionButtonStyle(action); !ok {
+		v.add(ConfigIssueError, key, "", fmt.Sprintf("未知的 `button_style` `%s`，应为 primary、secondary、success 或 danger。", action.ButtonStyle))
+	}
+	if _, ok := PunishActionEmoji(action); !ok {
+		v.add(ConfigIssueError, key, "", fmt.Sprintf("`button_emoji` 的值 `%s` 无法解析，自定义表情应写作 `<:name:id>`。", action.ButtonEmoji))
+	}
+	if len([]rune(action.ButtonLabel)) > 80 {
+		v.add(ConfigIssueWarning, key, "", "`button_label` 超过 80 个字符，将被截断。")
+	}
+
+	v.checkDuration(key, "", "timescale", action.Timescale)
+	v.checkDuration(key, "", "approval_timeout", action.ApprovalTimeout)
+
+	if action.AdminChannelID == "" {
+		v.add(ConfigIssueWarning, key, "", "未设置 `admin_channel_id`，处罚通知、申诉和审批将无法发送。")
+	} else {
+		v.checkChannel(key, action.AdminChannelID)
+	}
+
+	v.checkRole(key, "", "base_role_id", action.BaseRoleID, false)
+	for _, roleID := range action.RemoveRoleID {
+		v.checkRole(key, "", "remove_role_id", roleID, true)
+	}
+	for _, roleID := range action.WhitelistRoleID {
+		v.checkRole(key, "", "whitelist_role_id", roleID, false)
+	}
+
+	if len(action.Data) == 0 {
+		v.add(ConfigIssueError, key, "", "没有配置任何等级。")
+	}
+
+	// Level keys are looked up by punishment count, so they must be 0..n-1
+	present := make(map[int]bool, len(action.Data))
+	for levelKey := range action.Data {
+		n, err := strconv.Atoi(levelKey)
+		if err != nil || n < 0 {
+			v.add(ConfigIssueError, key, levelKey, "等级键必须是非负整数。")
+			continue
+		}
+		present[n] = true
+	}
+	for n := 0; n < len(action.Data); n++ {
+		if !present[n] {
+			v.add(ConfigIssueError, key, "", fmt.Sprintf("等级编号不连续，缺少等级 `%d`。", n))
+			break
+		}
+	}
+
+	levelKeys := make([]string, 0, len(action.Data))
+	for levelKey := range action.Data {
+		levelKeys = append(levelKeys, levelKey)
+	}
+	sort.Strings(levelKeys)
+	for _, levelKey := range levelKeys {
+		v.validateLevel(key, levelKey, action.Data[levelKey])
+	}
+
+	if action.Escalation != nil {
+		escalation := action.Escalation
+		switch escalation.Mode {
+		case "", model.EscalationModeCount, model.EscalationModePoints:
+		default:
+			v.add(ConfigIssueError, key, "", fmt.Sprintf("未知的 escalation.mode `%s`。", escalation.Mode))
+		}
+		v.checkDuration(key, "", "escalation.half_life", escalation.HalfLife)
+		for levelKey := range escalation.Thresholds {
+			if _, ok := action.Data[levelKey]; !ok {
+				v.add(ConfigIssueError, key, "", fmt.Sprintf("escalation.thresholds 引用了不存在的等级 `%s`。", levelKey))
+			}
+		}
+		if escalation.Mode == model.EscalationModePoints && len(escalation.Thresholds) == 0 {
+			v.add(ConfigIssueError, key, "", "积分模式未配置 escalation.thresholds。")
+		}
+	}
+}
+
+func (v *punishConfigValidator) validateLevel(action, levelKey string, level model.PunishLevel) {
+	switch level.Timeout {
+	case "", "0", "ban":
+	default:
+		if days, err := strconv.Atoi(level.Timeout); err != nil || days < 0 {
+			v.add(ConfigIssueError, action, levelKey, fmt.Sprintf("`timeout` 的值 `%s` 无法解析，应为天数、`0` 或 `ban`。", level.Timeout))
+		} else if days > 28 {
+			v.add(ConfigIssueWarning, action, levelKey, "`timeout` 超过 Discord 允许的最长禁言时间 28 天。")
+		}
+	}
+
+	if level.Ban != "" {
+		if level.Ban != model.BanPermanent {
+			v.checkDuration(action, levelKey, "ban", level.Ban)
+		}
+		if level.Timeout != "" && level.Timeout != "0" {
+			v.add(ConfigIssueWarning, action, levelKey, "同时配置了 `ban` 和 `timeout`，只会执行封禁。")
+		}
+	}
+	if level.BanDeleteMessageDays < 0 || level.BanDeleteMessageDays > 7 {
+		v.add(ConfigIssueError, action, levelKey, fmt.Sprintf("`ban_delete_message_days` 的值 `%d` 超出范围，应为 0 到 7。", level.BanDeleteMessageDays))
+	} else if level.BanDeleteMessageDays > 0 && level.Ban == "" && level.Timeout != "ban" {
+		v.add(ConfigIssueWarning, action, levelKey, "未配置封禁，`ban_delete_message_days` 不会生效。")
+	}
+
+	switch level.AddRoleTimeoutTime {
+	case "", "0", "-1":
+	default:
+		if days, err := strconv.Atoi(level.AddRoleTimeoutTime); err != nil || days < 0 {
+			v.add(ConfigIssueError, action, levelKey, fmt.Sprintf("`add_role_timeout_time` 的值 `%s` 无法解析，应为天数。", level.AddRoleTimeoutTime))
+		}
+	}
+
+	for _, roleID := range level.AddRole {
+		v.checkRole(action, levelKey, "add_role", roleID, true)
+	}
+	for _, roleID := range level.RemoveRoleID {
+		v.checkRole(action, levelKey, "remove_role_id", roleID, true)
+	}
+
+	if level.SendPresetID != "" && !v.presets[level.SendPresetID] {
+		v.add(ConfigIssueError, action, levelKey, fmt.Sprintf("`send_preset_id` 引用了不存在的预设 `%s`。", level.SendPresetID))
+	}
+}
+
+// checkRole reports role IDs that do not exist, and, if the bot must manage the role, ones at or above the bot's highest role.
+func (v *punishConfigValidator) checkRole(action, level, field, roleID string, managed bool) {
+	if roleID == "" || roleID == "0" || v.roles == nil {
+		return
+	}
+	role, ok := v.roles[roleID]
+	if !ok {
+		v.add(ConfigIssueError, action, level, fmt.Sprintf("`%s` 中的身份组 `%s` 不存在。", field, roleID))
+		return
+	}
+	if managed && v.botTopRole >= 0 && role.Position >= v.botTopRole {
+		v.add(ConfigIssueError, action, level, fmt.Sprintf("`%s` 中的身份组 <@&%s> 不低于机器人的最高身份组，机器人无法管理它。", field, roleID))
+	}
+}
+
+func (v *punishConfigValidator) checkDuration(action, level, field, value string) {
+	if value == "" {
+		return
+	}
+	if d, err := ParseDuration(value); err != nil || d <= 0 {
+		v.add(ConfigIssueError, action, level, fmt.Sprintf("`%s` 的值 `%s` 无法解析为时长。", field, value))
+	}
+}
+
+func (v *punishConfigValidator) checkChannel(action, channelID string) {
+	err, checked := v.channels[channelID]
+	if !checked {
+		_, err = v.s.Channel(channelID)
+		v.channels[channelID] = err
+	}
+	if err != nil {
+		v.add(ConfigIssueError, action, "", fmt.Sprintf("无法访问管理频道 `%s`: %v", channelID, err))
+	}
+}
+
+// checkUnknownFields reports fields in the raw config that do not map to any ActionConfig/PunishLevel field
+// and are therefore silently ignored, e.g. `type` instead of `tpye`.
+func (v *punishConfigValidator) checkUnknownFields(rawActions json.RawMessage) {
+	var actions map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(rawActions, &actions); err != nil {
+		return
+	}
+
+	actionFields := jsonFieldNames(reflect.TypeOf(model.ActionConfig{}))
+	levelFields := jsonFieldNames(reflect.TypeOf(model.PunishLevel{}))
+
+	for actionKey, fields := range actions {
+		for field := range fields {
+			if !actionFields[field] {
+				v.add(ConfigIssueWarning, actionKey, "", fmt.Sprintf("未知字段 `%s` 会被忽略。", field))
+			}
+		}
+
+		var levels map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(fields["data"], &levels); err != nil {
+			continue
+		}
+		for levelKey, levelFieldsRaw := range levels {
+			for field := range levelFieldsRaw {
+				if !levelFields[field] {
+					v.add(ConfigIssueWarning, actionKey, levelKey, fmt.Sprintf("未知字段 `%s` 会被忽略。", field))
+				}
+			}
+		}
+	}
+}
+
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for idx := 0; idx < t.NumField(); idx++ {
+		tag := t.Field(idx).Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = t.Field(idx).Name
+		}
+		if name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// LoadRawPunishActions returns the raw action JSON currently in effect for a guild: the newest stored
+// version if the guild is managed through /punish_config, otherwise the config file's. Returns nil if the
+// guild has none.
+func LoadRawPunishActions(path, guildID string) json.RawMessage {
+	fileData, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var raw struct {
+		DatabasePath string                     `json:"database_path"`
+		PunishConfig map[string]json.RawMessage `json:"punish_config"`
+	}
+	if err := json.Unmarshal(fileData, &raw); err != nil {
+		return nil
+	}
+	if raw.DatabasePath != "" {
+		if overlay, err := loadPunishConfigOverlay(raw.DatabasePath); err == nil {
+			if actionsJSON, ok := overlay[guildID]; ok {
+				return json.RawMessage(actionsJSON)
+			}
+		}
+	}
+	return raw.PunishConfig[guildID]
+}
+
+// BuildPunishConfigReportEmbed formats validation issues as an embed.
+func BuildPunishConfigReportEmbed(guildID string, issues []PunishConfigIssue) *discordgo.MessageEmbed {
+	errors, warnings := 0, 0
+	var lines []string
+	for _, issue := range issues {
+		icon := "⚠️"
+		if issue.Severity == ConfigIssueError {
+			icon = "❌"
+			errors++
+		} else {
+			warnings++
+		}
+		location := ""
+		switch {
+		case issue.Action != "" && issue.Level != "":
+			location = fmt.Sprintf("`%s` 等级 `%s`: ", issue.Action, issue.Level)
+		case issue.Action != "":
+			location = fmt.Sprintf("`%s`: ", issue.Action)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s%s", icon, location, issue.Message))
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "处罚配置检查",
+		Color: 0x00FF00, // Green
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("服务器: %s | %d 个错误，%d 个警告", guildID, errors, warnings),
+		},
+	}
+	switch {
+	case errors > 0:
+		embed.Color = 0xFF0000 // Red
+	case warnings > 0:
+		embed.Color = 0xFFA500 // Orange
+	}
+
+	if len(lines) == 0 {
+		embed.Description = "✅ 未发现问题。"
+	} else {
+		embed.Description = TruncateString(strings.Join(lines, "\n"), 4000)
+	}
+	return embed
+}
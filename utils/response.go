@@ -92,3 +92,16 @@ func SendPrivateEmbedMessage(s *discordgo.Session, userID string, embed *discord
 		log.Printf("Error sending private embed message to user %s: %v", userID, err)
 	}
 }
+
+// SendPrivateComplexMessage sends a direct message with arbitrary content, embeds and components to a user.
+func SendPrivateComplexMessage(s *discordgo.Session, userID string, message *discordgo.MessageSend) {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		log.Printf("Error creating private channel with user %s: %v", userID, err)
+		return
+	}
+	_, err = s.ChannelMessageSendComplex(channel.ID, message)
+	if err != nil {
+		log.Printf("Error sending private message to user %s: %v", userID, err)
+	}
+}
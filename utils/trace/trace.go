@@ -0,0 +1,92 @@
+// Package trace carries a request-scoped trace ID through context.Context, so that the log lines, database
+// writes and log-channel embeds caused by one Discord interaction or gateway request can be found together.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+)
+
+// Header is the request header and gRPC metadata key a caller can set to supply its own trace ID
+const Header = "x-trace-id"
+
+// maxIDLength bounds trace IDs taken from request headers
+const maxIDLength = 64
+
+type contextKey struct{}
+
+// NewID returns a new random trace ID
+func NewID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		log.Printf("Failed to generate trace ID: %v", err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// New returns a copy of ctx carrying a new trace ID
+func New(ctx context.Context) context.Context {
+	return WithID(ctx, NewID())
+}
+
+// FromHeader returns a copy of ctx carrying the trace ID supplied by a caller, or a new one if value is empty
+// or not a valid trace ID
+func FromHeader(ctx context.Context, value string) context.Context {
+	if !validID(value) {
+		return New(ctx)
+	}
+	return WithID(ctx, value)
+}
+
+// WithID returns a copy of ctx carrying the trace ID id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the trace ID carried by ctx, or "" if there is none
+func ID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logf logs like log.Printf, prefixed with the trace ID carried by ctx
+func Logf(ctx context.Context, format string, args ...any) {
+	if id := ID(ctx); id != "" {
+		log.Printf("[trace:%s] %s", id, fmt.Sprintf(format, args...))
+		return
+	}
+	log.Printf(format, args...)
+}
+
+// Footer appends the trace ID carried by ctx to an embed footer text
+func Footer(ctx context.Context, text string) string {
+	id := ID(ctx)
+	if id == "" {
+		return text
+	}
+	if text == "" {
+		return "Trace: " + id
+	}
+	return text + " • Trace: " + id
+}
+
+// validID accepts IDs of letters, digits, '-' and '_', which covers UUIDs and W3C trace IDs
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}